@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/demo007x/oauth2-client/oauth"
+	"github.com/demo007x/oauth2-client/types"
+	"io"
+	"time"
+)
+
+// token type hints. RFC 7009 section 2.1
+const (
+	hintAccessToken  = "access_token"
+	hintRefreshToken = "refresh_token"
+)
+
+// tokenFlags flags shared by the token lifecycle commands
+type tokenFlags struct {
+	profileFlags
+	output string
+	token  string
+}
+
+func (f *tokenFlags) register(fs *flag.FlagSet, tokenUsage string) {
+	f.profileFlags.register(fs)
+	fs.StringVar(&f.output, "output", outputTable, "output format: table or json")
+	fs.StringVar(&f.token, "token", "", tokenUsage)
+}
+
+func (f *tokenFlags) parse(fs *flag.FlagSet, args []string) bool {
+	if err := fs.Parse(args); err != nil {
+		return false
+	}
+	if f.output != outputTable && f.output != outputJSON {
+		fmt.Fprintf(fs.Output(), "invalid -output %q, want table or json\n", f.output)
+		return false
+	}
+	return true
+}
+
+// storedToken the flag token or the stored token of the hint, refresh token first when hint is empty
+func storedToken(flagToken, hint string, profile *Profile) (string, string) {
+	if flagToken != "" || profile.Token == nil {
+		return flagToken, hint
+	}
+	if hint != hintAccessToken && profile.Token.RefreshToken != "" {
+		return profile.Token.RefreshToken, hintRefreshToken
+	}
+	if hint != hintRefreshToken && profile.Token.AccessToken != "" {
+		return profile.Token.AccessToken, hintAccessToken
+	}
+	return "", hint
+}
+
+// runRefresh renew the token with the refresh token and save it to the profile
+func runRefresh(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	var fs = flag.NewFlagSet("refresh", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var f tokenFlags
+	f.register(fs, "refresh token, the stored one by default")
+	if !f.parse(fs, args) {
+		return exitUsage
+	}
+	store, profile, err := f.resolve()
+	if err != nil {
+		return fail(stderr, err)
+	}
+	if profile.TokenURL == "" {
+		return fail(stderr, errors.New("token endpoint is required, set -issuer, -provider or -token-url"))
+	}
+	refreshToken, _ := storedToken(f.token, hintRefreshToken, profile)
+	if refreshToken == "" {
+		return fail(stderr, errors.New("no refresh token, set -token or login first"))
+	}
+
+	token, err := newConfig(profile, "").Refresh(ctx, refreshToken)
+	if err != nil {
+		return fail(stderr, err)
+	}
+	profile.Token = token
+	if err := store.save(f.name, profile); err != nil {
+		return fail(stderr, err)
+	}
+	fmt.Fprintf(stderr, "Token saved to profile %q in %s\n", f.name, store.path)
+
+	var result = make(map[string]interface{}, len(token.Raw)+1)
+	for key, value := range token.Raw {
+		result[key] = value
+	}
+	if !token.Expiry.IsZero() {
+		result["expiry"] = token.Expiry.UTC().Format(time.RFC3339)
+	}
+	if err := printResult(stdout, f.output, result); err != nil {
+		return fail(stderr, err)
+	}
+	return exitOK
+}
+
+// runRevoke revoke the token, a revoked stored token is removed from the profile
+func runRevoke(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	var fs = flag.NewFlagSet("revoke", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var f tokenFlags
+	f.register(fs, "token to revoke, the stored refresh token or access token by default")
+	var hint = fs.String("token-type-hint", "", "access_token or refresh_token")
+	if !f.parse(fs, args) {
+		return exitUsage
+	}
+	store, profile, err := f.resolve()
+	if err != nil {
+		return fail(stderr, err)
+	}
+	if profile.RevokeURL == "" {
+		return fail(stderr, errors.New("revocation endpoint is required, set -issuer, -provider or -revoke-url"))
+	}
+	token, tokenTypeHint := storedToken(f.token, *hint, profile)
+	if token == "" {
+		return fail(stderr, errors.New("no token to revoke, set -token or login first"))
+	}
+
+	var opts []oauth.RevokeTokenOption
+	if tokenTypeHint != "" {
+		opts = append(opts, oauth.RevokeTokenWithTokenTypeHint(tokenTypeHint))
+	}
+	if err := newConfig(profile, "").Revoke(ctx, token, opts...); err != nil {
+		return fail(stderr, err)
+	}
+	if f.token == "" {
+		// the server may revoke the whole grant, keep nothing of it
+		profile.Token = nil
+		if err := store.save(f.name, profile); err != nil {
+			return fail(stderr, err)
+		}
+	}
+	if err := printResult(stdout, f.output, map[string]interface{}{"revoked": true, "token_type_hint": tokenTypeHint}); err != nil {
+		return fail(stderr, err)
+	}
+	return exitOK
+}
+
+// runIntrospect print the introspection response, an inactive token exit with exitInvalidToken
+func runIntrospect(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	var fs = flag.NewFlagSet("introspect", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var f tokenFlags
+	f.register(fs, "token to introspect, the stored access token by default")
+	var hint = fs.String("token-type-hint", hintAccessToken, "access_token or refresh_token")
+	if !f.parse(fs, args) {
+		return exitUsage
+	}
+	_, profile, err := f.resolve()
+	if err != nil {
+		return fail(stderr, err)
+	}
+	if profile.IntrospectURL == "" {
+		return fail(stderr, errors.New("introspection endpoint is required, set -issuer or -introspect-url"))
+	}
+	token, tokenTypeHint := storedToken(f.token, *hint, profile)
+	if token == "" {
+		return fail(stderr, errors.New("no token to introspect, set -token or login first"))
+	}
+
+	data, err := oauth.NewIntrospectToken(profile.IntrospectURL, profile.ClientID, profile.ClientSecret, token,
+		oauth.IntrospectTokenWithTokenTypeHint(tokenTypeHint),
+		oauth.IntrospectTokenWithAuthMethod(profile.authMethod()),
+		oauth.IntrospectTokenWithContext(ctx),
+		oauth.IntrospectTokenWithResponseMiddleware(types.CheckStatus(nil)),
+	).DoRequest()
+	if err != nil {
+		return fail(stderr, err)
+	}
+	result, err := decodeObject(data)
+	if err != nil {
+		return fail(stderr, err)
+	}
+	if err := printResult(stdout, f.output, result); err != nil {
+		return fail(stderr, err)
+	}
+	if active, _ := result["active"].(bool); !active {
+		return exitInvalidToken
+	}
+	return exitOK
+}
+
+// runUserinfo print the claims of the userinfo endpoint for the access token
+func runUserinfo(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	var fs = flag.NewFlagSet("userinfo", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var f tokenFlags
+	f.register(fs, "access token, the stored one by default")
+	if !f.parse(fs, args) {
+		return exitUsage
+	}
+	_, profile, err := f.resolve()
+	if err != nil {
+		return fail(stderr, err)
+	}
+	if profile.UserinfoURL == "" {
+		return fail(stderr, errors.New("userinfo endpoint is required, set -issuer, -provider or -userinfo-url"))
+	}
+	token, _ := storedToken(f.token, hintAccessToken, profile)
+	if token == "" {
+		return fail(stderr, errors.New("no access token, set -token or login first"))
+	}
+
+	data, err := oauth.NewUserInfo(profile.UserinfoURL, token,
+		oauth.UserInfoWithMethod(profile.UserinfoMethod),
+		oauth.UserInfoWithContext(ctx),
+		oauth.UserInfoWithResponseMiddleware(types.CheckStatus(nil)),
+	).DoRequest()
+	if err != nil {
+		return fail(stderr, err)
+	}
+	result, err := decodeObject(data)
+	if err != nil {
+		return fail(stderr, err)
+	}
+	if err := printResult(stdout, f.output, result); err != nil {
+		return fail(stderr, err)
+	}
+	return exitOK
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/demo007x/oauth2-client/oauth"
+	"github.com/demo007x/oauth2-client/oauthtest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newLifecycleStore(t *testing.T, srv *oauthtest.Server) (*profileStore, string, string) {
+	accessToken, refreshToken := srv.IssueToken(oauthtest.DefaultClientID, oauthtest.DefaultSubject, "openid profile")
+	var store = &profileStore{path: filepath.Join(t.TempDir(), "profiles.json")}
+	if err := store.save("mock", &Profile{
+		TokenURL:      srv.TokenURL(),
+		RevokeURL:     srv.RevocationURL(),
+		IntrospectURL: srv.IntrospectionURL(),
+		UserinfoURL:   srv.UserinfoURL(),
+		ClientID:      oauthtest.DefaultClientID,
+		ClientSecret:  oauthtest.DefaultClientSecret,
+		Token:         &oauth.Token{AccessToken: accessToken, RefreshToken: refreshToken},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return store, accessToken, refreshToken
+}
+
+func runCommand(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestTokenLifecycle(t *testing.T) {
+	srv := oauthtest.NewServer()
+	defer srv.Close()
+	store, accessToken, refreshToken := newLifecycleStore(t, srv)
+	var flags = []string{"-store", store.path, "-profile", "mock"}
+
+	code, stdout, stderr := runCommand(append([]string{"introspect", "-output", "json"}, flags...)...)
+	if code != exitOK {
+		t.Fatalf("introspect exit code %d: %s", code, stderr)
+	}
+	var introspection map[string]interface{}
+	if err := json.Unmarshal([]byte(stdout), &introspection); err != nil || introspection["active"] != true || introspection["sub"] != oauthtest.DefaultSubject {
+		t.Errorf("unexpected introspection %s %v", stdout, err)
+	}
+
+	code, stdout, stderr = runCommand(append([]string{"userinfo"}, flags...)...)
+	if code != exitOK || !strings.Contains(stdout, oauthtest.DefaultSubject) {
+		t.Errorf("userinfo exit code %d: %s %s", code, stdout, stderr)
+	}
+
+	code, stdout, stderr = runCommand(append([]string{"refresh"}, flags...)...)
+	if code != exitOK || !strings.Contains(stdout, "access_token") || !strings.Contains(stdout, "expiry") {
+		t.Fatalf("refresh exit code %d: %s %s", code, stdout, stderr)
+	}
+	profiles, err := store.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var refreshed = profiles["mock"].Token
+	if refreshed == nil || refreshed.AccessToken == "" || refreshed.AccessToken == accessToken {
+		t.Errorf("refreshed token is not saved: %+v", refreshed)
+	}
+
+	// unknown tokens are reported inactive, not as an error response
+	code, _, _ = runCommand(append([]string{"introspect", "-token", "unknown"}, flags...)...)
+	if code != exitInvalidToken {
+		t.Errorf("expected inactive token exit code, got %d", code)
+	}
+
+	code, stdout, stderr = runCommand(append([]string{"revoke", "-output", "json"}, flags...)...)
+	if code != exitOK || !strings.Contains(stdout, `"token_type_hint": "refresh_token"`) {
+		t.Fatalf("revoke exit code %d: %s %s", code, stdout, stderr)
+	}
+	if profiles, _ = store.load(); profiles["mock"].Token != nil {
+		t.Error("revoked token should be removed from the profile")
+	}
+
+	code, _, stderr = runCommand(append([]string{"refresh", "-token", refreshToken}, flags...)...)
+	if code != exitInvalidGrant {
+		t.Errorf("expected invalid_grant exit code, got %d: %s", code, stderr)
+	}
+	code, _, stderr = runCommand(append([]string{"userinfo", "-token", refreshed.AccessToken}, flags...)...)
+	if code != exitInvalidToken {
+		t.Errorf("expected invalid_token exit code, got %d: %s", code, stderr)
+	}
+}
+
+func TestTokenLifecycleErrors(t *testing.T) {
+	srv := oauthtest.NewServer()
+	defer srv.Close()
+	store, _, _ := newLifecycleStore(t, srv)
+	var flags = []string{"-store", store.path, "-profile", "mock"}
+
+	if code, _, _ := runCommand(append([]string{"introspect", "-output", "yaml"}, flags...)...); code != exitUsage {
+		t.Errorf("expected usage exit code, got %d", code)
+	}
+	if code, _, _ := runCommand(append([]string{"refresh", "-client-secret", "wrong"}, flags...)...); code != exitInvalidClient {
+		t.Errorf("expected invalid_client exit code, got %d", code)
+	}
+
+	srv.InjectError(oauthtest.TokenPath, oauthtest.ErrorResponse{StatusCode: 503, Code: "temporarily_unavailable"})
+	if code, _, _ := runCommand(append([]string{"refresh"}, flags...)...); code != exitServerError {
+		t.Errorf("expected server error exit code, got %d", code)
+	}
+	if code, _, stderr := runCommand("revoke", "-store", store.path, "-profile", "other", "-client-id", "x"); code != exitFailure || !strings.Contains(stderr, "revocation endpoint") {
+		t.Errorf("expected missing endpoint failure, got %d: %s", code, stderr)
+	}
+}
+
+func TestTokenLifecyclePublicClient(t *testing.T) {
+	srv := oauthtest.NewServer(oauthtest.ServerWithClient(oauthtest.Client{ID: "cli"}))
+	defer srv.Close()
+	accessToken, _ := srv.IssueToken("cli", oauthtest.DefaultSubject, "openid")
+	var flags = []string{"-store", filepath.Join(t.TempDir(), "profiles.json"), "-client-id", "cli", "-token", accessToken,
+		"-introspect-url", srv.IntrospectionURL(), "-userinfo-url", srv.UserinfoURL()}
+
+	// a public client authenticate with none, as refresh and revoke do
+	if code, stdout, stderr := runCommand(append([]string{"introspect"}, flags...)...); code != exitOK || !strings.Contains(stdout, "cli") {
+		t.Errorf("introspect exit code %d: %s %s", code, stdout, stderr)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var stdout, stderr bytes.Buffer
+	if code := run(ctx, append([]string{"userinfo"}, flags...), &stdout, &stderr); code != exitFailure || !strings.Contains(stderr.String(), "context canceled") {
+		t.Errorf("expected canceled userinfo, got %d: %s", code, stderr.String())
+	}
+}
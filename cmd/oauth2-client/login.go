@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/demo007x/oauth2-client/oauth"
+	"github.com/demo007x/oauth2-client/utils"
+	"io"
+	"net"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const callbackPath = "/callback"
+
+// callbackResult authorization response received on the loopback redirect uri
+type callbackResult struct {
+	code string
+	err  error
+}
+
+// openBrowser open the url with the desktop browser
+var openBrowser = func(url string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", url).Start()
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
+	default:
+		return exec.Command("xdg-open", url).Start()
+	}
+}
+
+// newConfig library config of the profile
+func newConfig(profile *Profile, redirectURI string) *oauth.Config {
+	return oauth.NewConfig(
+		oauth.Endpoint{AuthorizeURL: profile.AuthorizeURL, TokenURL: profile.TokenURL, RevokeURL: profile.RevokeURL, AuthMethod: profile.authMethod()},
+		profile.ClientID,
+		oauth.ConfigWithSecret(profile.ClientSecret),
+		oauth.ConfigWithRedirectURI(redirectURI),
+		oauth.ConfigWithScopes(profile.Scopes...),
+	)
+}
+
+// runLogin authorization code flow with PKCE on a loopback redirect uri. RFC 8252
+func runLogin(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	var fs = flag.NewFlagSet("login", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var pf profileFlags
+	pf.register(fs)
+	var port = fs.Int("port", 0, "loopback port, random by default")
+	var noBrowser = fs.Bool("no-browser", false, "only print the authorize url")
+	var timeout = fs.Duration("timeout", 5*time.Minute, "time to wait for the callback")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	store, profile, err := pf.resolve()
+	if err != nil {
+		return fail(stderr, err)
+	}
+	if profile.AuthorizeURL == "" || profile.TokenURL == "" {
+		return fail(stderr, errors.New("authorize and token endpoints are required, set -issuer, -provider or the urls"))
+	}
+
+	// the loopback address, not localhost, avoid listening on other interfaces. RFC 8252 section 8.3
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", *port))
+	if err != nil {
+		return fail(stderr, err)
+	}
+	defer listener.Close()
+	var redirectURI = fmt.Sprintf("http://%s%s", listener.Addr().String(), callbackPath)
+
+	state, err := utils.GenerateRandomString(16)
+	if err != nil {
+		return fail(stderr, err)
+	}
+	verifier, err := oauth.GenerateCodeVerifier()
+	if err != nil {
+		return fail(stderr, err)
+	}
+	var config = newConfig(profile, redirectURI)
+	authURL, err := config.AuthCodeURL(state, oauth.WithPKCE(verifier))
+	if err != nil {
+		return fail(stderr, err)
+	}
+
+	var results = make(chan callbackResult, 1)
+	var server = &http.Server{Handler: callbackHandler(state, results)}
+	go server.Serve(listener)
+	defer server.Close()
+
+	fmt.Fprintf(stderr, "Open the following url to sign in:\n\n  %s\n\n", authURL)
+	if !*noBrowser {
+		if err := openBrowser(authURL); err != nil {
+			fmt.Fprintf(stderr, "could not open the browser: %v\n", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+	var result callbackResult
+	select {
+	case result = <-results:
+	case <-ctx.Done():
+		return fail(stderr, fmt.Errorf("waiting for the callback: %w", ctx.Err()))
+	}
+	if result.err != nil {
+		return fail(stderr, result.err)
+	}
+
+	token, err := config.Exchange(ctx, result.code, oauth.AccessTokenWithCodeVerifier(verifier))
+	if err != nil {
+		return fail(stderr, err)
+	}
+	profile.Token = token
+	if err := store.save(pf.name, profile); err != nil {
+		return fail(stderr, err)
+	}
+	fmt.Fprintf(stdout, "Logged in, token saved to profile %q in %s\n", pf.name, store.path)
+	return exitOK
+}
+
+// callbackHandler receive the authorization response once
+func callbackHandler(state string, results chan<- callbackResult) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != callbackPath {
+			http.NotFound(w, r)
+			return
+		}
+		var query = r.URL.Query()
+		var result callbackResult
+		switch {
+		case query.Get("state") != state:
+			result.err = errors.New("callback state mismatch")
+		case query.Get("error") != "":
+			result.err = fmt.Errorf("authorization failed: %s", strings.TrimSpace(query.Get("error")+" "+query.Get("error_description")))
+		case query.Get("code") == "":
+			result.err = errors.New("callback without code")
+		default:
+			result.code = query.Get("code")
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if result.err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "Login failed: %v\n", result.err)
+		} else {
+			fmt.Fprintln(w, "Login complete, you can close this window.")
+		}
+		select {
+		case results <- result:
+		default:
+		}
+	})
+}
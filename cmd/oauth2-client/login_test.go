@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"github.com/demo007x/oauth2-client/oauthtest"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLogin(t *testing.T) {
+	srv := oauthtest.NewServer(oauthtest.ServerWithClient(oauthtest.Client{ID: "cli"}))
+	defer srv.Close()
+
+	var opened string
+	openBrowser = func(url string) error {
+		opened = url
+		// the mock server consent and redirect to the loopback callback
+		resp, err := http.Get(url)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	var store = filepath.Join(t.TempDir(), "profiles.json")
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), []string{"login", "-issuer", srv.Issuer(), "-client-id", "cli", "-scope", "openid profile", "-store", store, "-profile", "mock"}, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("unexpected exit code %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(opened, "code_challenge=") || !strings.Contains(opened, "redirect_uri=http%3A%2F%2F127.0.0.1%3A") || !strings.Contains(stderr.String(), opened) {
+		t.Errorf("unexpected authorize url %s", opened)
+	}
+
+	profiles, err := (&profileStore{path: store}).load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var profile = profiles["mock"]
+	if profile == nil || profile.Token == nil || profile.Token.AccessToken == "" || profile.Token.IDToken == "" || profile.TokenURL != srv.TokenURL() {
+		t.Errorf("unexpected stored profile %+v", profile)
+	}
+}
+
+func TestCallbackHandler(t *testing.T) {
+	var results = make(chan callbackResult, 1)
+	var handler = callbackHandler("state", results)
+
+	for query, wantErr := range map[string]string{
+		"?code=c&state=other":                           "state mismatch",
+		"?error=access_denied&state=state":              "access_denied",
+		"?code=c&state=state":                           "",
+		"?error=x&error_description=denied&state=state": "denied",
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, callbackPath+query, nil))
+		var result = <-results
+		if wantErr == "" && (result.err != nil || result.code != "c" || w.Code != http.StatusOK) {
+			t.Errorf("%s: unexpected result %+v", query, result)
+		}
+		if wantErr != "" && (result.err == nil || !strings.Contains(result.err.Error(), wantErr) || w.Code != http.StatusBadRequest) {
+			t.Errorf("%s: expected error %s, got %v", query, wantErr, result.err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Command oauth2-client sign in to an authorization server and keep the tokens in named profiles.
+//
+//	oauth2-client login -issuer https://accounts.example.com -client-id cli
+//	oauth2-client login -provider github -client-id xxx -client-secret yyy -profile github
+//	oauth2-client introspect -profile github -output json
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+)
+
+// exit codes, oauth error responses get their own codes so scripts can tell them apart
+const (
+	exitOK            = 0
+	exitFailure       = 1
+	exitUsage         = 2
+	exitInvalidGrant  = 3
+	exitInvalidToken  = 4
+	exitInvalidClient = 5
+	exitAccessDenied  = 6
+	exitServerError   = 7
+	exitOauthError    = 8
+)
+
+const usage = `Usage: oauth2-client <command> [flags]
+
+Commands:
+  login       sign in with the browser and save the token to a profile
+  refresh     renew the token with the refresh token and save it
+  revoke      revoke the refresh token or the access token. RFC 7009
+  introspect  print the state of the token. RFC 7662
+  userinfo    print the claims of the userinfo endpoint
+
+Exit codes:
+  0  success
+  1  failure, eg: network or profile error
+  2  invalid command or flags
+  3  invalid_grant, expired_token: sign in again
+  4  invalid_token or inactive token
+  5  invalid_client, unauthorized_client
+  6  access_denied, insufficient_scope, invalid_scope
+  7  server_error, temporarily_unavailable or status 5xx
+  8  other oauth error
+
+Run "oauth2-client <command> -h" for the flags of a command.
+`
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	var code = run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+	switch args[0] {
+	case "login":
+		return runLogin(ctx, args[1:], stdout, stderr)
+	case "refresh":
+		return runRefresh(ctx, args[1:], stdout, stderr)
+	case "revoke":
+		return runRevoke(ctx, args[1:], stdout, stderr)
+	case "introspect":
+		return runIntrospect(ctx, args[1:], stdout, stderr)
+	case "userinfo":
+		return runUserinfo(ctx, args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return exitOK
+	}
+	fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+	return exitUsage
+}
+
+// fail report the error and return the exit code
+func fail(stderr io.Writer, err error) int {
+	fmt.Fprintln(stderr, "error:", err)
+	return exitCode(err)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/demo007x/oauth2-client/errorx"
+	"io"
+	"net/http"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// output formats of -output
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// timeClaims numeric date fields, the table print them as utc time as well
+var timeClaims = map[string]bool{"exp": true, "iat": true, "nbf": true, "auth_time": true}
+
+// exitCode exit code of the error, oauth error responses are mapped by their error code
+func exitCode(err error) int {
+	var oe *errorx.OauthError
+	if !errors.As(err, &oe) {
+		return exitFailure
+	}
+	switch oe.Code {
+	case "invalid_grant", "expired_token":
+		return exitInvalidGrant
+	case "invalid_token":
+		return exitInvalidToken
+	case "invalid_client", "unauthorized_client":
+		return exitInvalidClient
+	case "access_denied", "insufficient_scope", "invalid_scope":
+		return exitAccessDenied
+	case "server_error", "temporarily_unavailable":
+		return exitServerError
+	case "":
+		// bare status, eg: userinfo answer 401 with the error in WWW-Authenticate only
+		switch {
+		case oe.StatusCode == http.StatusUnauthorized:
+			return exitInvalidToken
+		case oe.StatusCode == http.StatusForbidden:
+			return exitAccessDenied
+		case oe.StatusCode >= http.StatusInternalServerError:
+			return exitServerError
+		}
+	}
+	return exitOauthError
+}
+
+// decodeObject decode json object response, numbers are kept as json.Number
+func decodeObject(data []byte) (map[string]interface{}, error) {
+	var result = map[string]interface{}{}
+	var decoder = json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return result, nil
+}
+
+// printResult write the result as indented json or as a two column table sorted by key
+func printResult(w io.Writer, format string, result map[string]interface{}) error {
+	if format == outputJSON {
+		var encoder = json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+	var keys = make([]string, 0, len(result))
+	for key := range result {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, key := range keys {
+		fmt.Fprintf(tw, "%s\t%s\n", key, formatValue(key, result[key]))
+	}
+	return tw.Flush()
+}
+
+func formatValue(key string, value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		if n, err := v.Int64(); err == nil && timeClaims[key] {
+			return fmt.Sprintf("%d (%s)", n, time.Unix(n, 0).UTC().Format(time.RFC3339))
+		}
+		return v.String()
+	case nil:
+		return ""
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
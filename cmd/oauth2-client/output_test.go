@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/demo007x/oauth2-client/errorx"
+	"strings"
+	"testing"
+)
+
+func TestPrintResult(t *testing.T) {
+	result, err := decodeObject([]byte(`{"sub":"user-1","exp":1700000000,"aud":["a","b"],"active":true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := printResult(&buf, outputTable, result); err != nil {
+		t.Fatal(err)
+	}
+	var lines = strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "active") || !strings.Contains(lines[1], `["a","b"]`) || !strings.Contains(lines[2], "1700000000 (2023-11-14T22:13:20Z)") {
+		t.Errorf("unexpected table\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := printResult(&buf, outputJSON, result); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"exp": 1700000000`) || !json.Valid(buf.Bytes()) {
+		t.Errorf("unexpected json %s", buf.String())
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	for err, want := range map[error]int{
+		errors.New("dial tcp"): exitFailure,
+		fmt.Errorf("wrapped: %w", &errorx.OauthError{Code: "invalid_grant"}): exitInvalidGrant,
+		&errorx.OauthError{Code: "invalid_client"}:                           exitInvalidClient,
+		&errorx.OauthError{Code: "insufficient_scope"}:                       exitAccessDenied,
+		&errorx.OauthError{StatusCode: 401}:                                  exitInvalidToken,
+		&errorx.OauthError{StatusCode: 502}:                                  exitServerError,
+		&errorx.OauthError{Code: "invalid_request"}:                          exitOauthError,
+	} {
+		if got := exitCode(err); got != want {
+			t.Errorf("exitCode(%v) = %d, want %d", err, got, want)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/demo007x/oauth2-client/oauth"
+	"github.com/demo007x/oauth2-client/providers"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// storeEnv override the default profile store path
+const storeEnv = "OAUTH2_CLIENT_PROFILES"
+
+type (
+	// Profile endpoints, client credentials and the last token of one authorization server
+	Profile struct {
+		Issuer         string       `json:"issuer,omitempty"`
+		AuthorizeURL   string       `json:"authorize_url,omitempty"`
+		TokenURL       string       `json:"token_url,omitempty"`
+		RevokeURL      string       `json:"revoke_url,omitempty"`
+		IntrospectURL  string       `json:"introspect_url,omitempty"`
+		UserinfoURL    string       `json:"userinfo_url,omitempty"`
+		UserinfoMethod string       `json:"userinfo_method,omitempty"`
+		ClientID       string       `json:"client_id,omitempty"`
+		ClientSecret   string       `json:"client_secret,omitempty"`
+		AuthMethod     string       `json:"auth_method,omitempty"`
+		Scopes         []string     `json:"scopes,omitempty"`
+		Token          *oauth.Token `json:"token,omitempty"`
+	}
+
+	// profileStore json file of named profiles, readable by the owner only
+	profileStore struct {
+		path string
+	}
+
+	// profileFlags flags selecting a stored profile and overriding its fields
+	profileFlags struct {
+		name         string
+		store        string
+		provider     string
+		issuer       string
+		authorizeURL string
+		tokenURL     string
+		revokeURL    string
+		introspect   string
+		userinfoURL  string
+		clientID     string
+		clientSecret string
+		authMethod   string
+		scope        string
+	}
+)
+
+// presets providers selectable by -provider
+var presets = map[string]func() *providers.Provider{
+	"github":    providers.GitHub,
+	"google":    providers.Google,
+	"facebook":  providers.Facebook,
+	"slack":     providers.Slack,
+	"discord":   providers.Discord,
+	"bitbucket": providers.Bitbucket,
+}
+
+func defaultStorePath() string {
+	if path := os.Getenv(storeEnv); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "oauth2-client", "profiles.json")
+}
+
+func (s *profileStore) load() (map[string]*Profile, error) {
+	var profiles = map[string]*Profile{}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return profiles, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, fmt.Errorf("profile store %s: %w", s.path, err)
+	}
+	return profiles, nil
+}
+
+// save write the profile, the file is replaced atomically
+func (s *profileStore) save(name string, profile *Profile) error {
+	profiles, err := s.load()
+	if err != nil {
+		return err
+	}
+	profiles[name] = profile
+	data, err := json.MarshalIndent(profiles, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	var tmp = s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// authMethod client auth method of the profile, public clients authenticate with none
+func (p *Profile) authMethod() string {
+	if p.AuthMethod == "" && p.ClientSecret == "" {
+		return oauth.AuthMethodNone
+	}
+	return p.AuthMethod
+}
+
+func (f *profileFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.name, "profile", "default", "profile name in the store")
+	fs.StringVar(&f.store, "store", defaultStorePath(), "profile store file, $"+storeEnv+" by default")
+	fs.StringVar(&f.provider, "provider", "", "provider preset: "+strings.Join(presetNames(), ", "))
+	fs.StringVar(&f.issuer, "issuer", "", "issuer url, endpoints are discovered from its openid configuration")
+	fs.StringVar(&f.authorizeURL, "authorize-url", "", "authorization endpoint")
+	fs.StringVar(&f.tokenURL, "token-url", "", "token endpoint")
+	fs.StringVar(&f.revokeURL, "revoke-url", "", "revocation endpoint")
+	fs.StringVar(&f.introspect, "introspect-url", "", "introspection endpoint")
+	fs.StringVar(&f.userinfoURL, "userinfo-url", "", "userinfo endpoint")
+	fs.StringVar(&f.clientID, "client-id", "", "client id")
+	fs.StringVar(&f.clientSecret, "client-secret", "", "client secret, empty for public clients")
+	fs.StringVar(&f.authMethod, "auth-method", "", "token endpoint auth method. eg: client_secret_post, none")
+	fs.StringVar(&f.scope, "scope", "", "space separated scopes")
+}
+
+func presetNames() []string {
+	var names = make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolve the stored profile overridden by the flags: provider preset, discovered metadata, then explicit values
+func (f *profileFlags) resolve() (*profileStore, *Profile, error) {
+	var store = &profileStore{path: f.store}
+	profiles, err := store.load()
+	if err != nil {
+		return nil, nil, err
+	}
+	var profile = &Profile{}
+	if stored, ok := profiles[f.name]; ok {
+		profile = stored
+	}
+
+	if f.provider != "" {
+		preset, ok := presets[f.provider]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown provider %q", f.provider)
+		}
+		var p = preset()
+		profile.AuthorizeURL, profile.TokenURL, profile.RevokeURL = p.AuthorizeURL, p.TokenURL, p.RevokeURL
+		profile.IntrospectURL, profile.UserinfoURL, profile.UserinfoMethod = p.IntrospectURL, p.UserInfoURL, p.UserInfoMethod
+		profile.AuthMethod, profile.Scopes = p.AuthMethod, p.Scopes
+	}
+	if f.issuer != "" {
+		profile.Issuer = strings.TrimRight(f.issuer, "/")
+		md, err := oauth.NewDiscovery(profile.Issuer + "/.well-known/openid-configuration").Metadata()
+		if err != nil {
+			return nil, nil, fmt.Errorf("discover %s: %w", profile.Issuer, err)
+		}
+		profile.AuthorizeURL, profile.TokenURL, profile.RevokeURL = md.AuthorizationEndpoint, md.TokenEndpoint, md.RevocationEndpoint
+		profile.IntrospectURL, profile.UserinfoURL = md.IntrospectionEndpoint, md.UserinfoEndpoint
+	}
+
+	for _, v := range []struct {
+		flag  string
+		field *string
+	}{
+		{f.authorizeURL, &profile.AuthorizeURL},
+		{f.tokenURL, &profile.TokenURL},
+		{f.revokeURL, &profile.RevokeURL},
+		{f.introspect, &profile.IntrospectURL},
+		{f.userinfoURL, &profile.UserinfoURL},
+		{f.clientID, &profile.ClientID},
+		{f.clientSecret, &profile.ClientSecret},
+		{f.authMethod, &profile.AuthMethod},
+	} {
+		if v.flag != "" {
+			*v.field = v.flag
+		}
+	}
+	if f.scope != "" {
+		profile.Scopes = strings.Fields(f.scope)
+	}
+	if profile.ClientID == "" {
+		return nil, nil, errors.New("client id is required, set -client-id or use a stored profile")
+	}
+	return store, profile, nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"github.com/demo007x/oauth2-client/oauth"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProfileStore(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "nested", "profiles.json")
+	var store = &profileStore{path: path}
+	if err := store.save("work", &Profile{ClientID: "cli", TokenURL: "https://idp/token", Token: &oauth.Token{AccessToken: "at"}}); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("expected owner only store, got %v %v", info, err)
+	}
+
+	var fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	var pf profileFlags
+	pf.register(fs)
+	if err := fs.Parse([]string{"-store", path, "-profile", "work", "-provider", "github", "-scope", "repo"}); err != nil {
+		t.Fatal(err)
+	}
+	_, profile, err := pf.resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.ClientID != "cli" || profile.Token.AccessToken != "at" || profile.TokenURL != "https://github.com/login/oauth/access_token" || len(profile.Scopes) != 1 || profile.Scopes[0] != "repo" {
+		t.Errorf("unexpected resolved profile %+v", profile)
+	}
+
+	pf.name = "missing"
+	if _, _, err := pf.resolve(); err == nil {
+		t.Error("expected client id required")
+	}
+}
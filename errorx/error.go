@@ -1,12 +1,60 @@
 package errorx
 
-import "errors"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
 
 var (
-	ServerURLError        = errors.New("server uri error")
-	ClientKeyError        = errors.New("not set client key")
-	SecretKeyError        = errors.New("not set secret")
-	CodeEmptyError        = errors.New("code is empty")
-	RequestServerURLError = errors.New("request server url error")
-	RefreshTokenNotEmpty  = errors.New("refresh token not empty")
+	ServerURLError           = errors.New("server uri error")
+	ClientKeyError           = errors.New("not set client key")
+	SecretKeyError           = errors.New("not set secret")
+	CodeEmptyError           = errors.New("code is empty")
+	RequestServerURLError    = errors.New("request server url error")
+	RefreshTokenNotEmpty     = errors.New("refresh token not empty")
+	RequestURIEmptyError     = errors.New("request uri is empty")
+	TokenEmptyError          = errors.New("token is empty")
+	GrantEmptyError          = errors.New("grant is empty")
+	ResponseTooLargeError    = errors.New("response body too large")
+	ExchangeNotRecordedError = errors.New("exchange not recorded")
+
+	SecretRotationUnsupportedError = errors.New("server does not support client secret rotation")
+	SigningKeyNotFoundError        = errors.New("signing key not found in jwks")
+	NonceMismatchError             = errors.New("id token nonce mismatch")
+	SubjectEmptyError              = errors.New("user subject is empty")
+	VerifierConfigError            = errors.New("id token verifier requires issuer, client id and key set")
+	FetcherEmptyError              = errors.New("app token fetcher is not set")
 )
+
+// OauthError error response from oauth server. RFC 6749 section 5.2
+type OauthError struct {
+	StatusCode  int    `json:"-"`
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+	URI         string `json:"error_uri,omitempty"`
+}
+
+func (e *OauthError) Error() string {
+	var msg = fmt.Sprintf("oauth server error: status %d", e.StatusCode)
+	if e.Code != "" {
+		msg += ", error " + e.Code
+	}
+	if e.Description != "" {
+		msg += ": " + e.Description
+	}
+	return msg
+}
+
+// ParseOauthError parse oauth server error response body.
+// If the body is not a json error object, it is kept as description
+func ParseOauthError(statusCode int, body []byte) *OauthError {
+	var oe = &OauthError{}
+	if err := json.Unmarshal(body, oe); err != nil || oe.Code == "" {
+		oe.Code = ""
+		oe.Description = strings.TrimSpace(string(body))
+	}
+	oe.StatusCode = statusCode
+	return oe
+}
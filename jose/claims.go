@@ -0,0 +1,96 @@
+package jose
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var (
+	InvalidIssuerError    = errors.New("jose: invalid issuer")
+	InvalidAudienceError  = errors.New("jose: invalid audience")
+	TokenExpiredError     = errors.New("jose: token is expired")
+	TokenNotValidYetError = errors.New("jose: token is not valid yet")
+	MissingClaimError     = errors.New("jose: required claim is missing")
+)
+
+type (
+	// Audience aud claim, a single string or an array of strings
+	Audience []string
+
+	// Claims registered jwt claims. RFC 7519 section 4.1
+	Claims struct {
+		Issuer    string   `json:"iss,omitempty"`
+		Subject   string   `json:"sub,omitempty"`
+		Audience  Audience `json:"aud,omitempty"`
+		Expiry    int64    `json:"exp,omitempty"`
+		NotBefore int64    `json:"nbf,omitempty"`
+		IssuedAt  int64    `json:"iat,omitempty"`
+		ID        string   `json:"jti,omitempty"`
+	}
+
+	// Expected expected claim values, empty values are not checked
+	Expected struct {
+		Issuer   string
+		Audience string
+		// Time validation time, now by default
+		Time time.Time
+		// Leeway tolerated clock skew for exp, nbf and iat
+		Leeway time.Duration
+	}
+)
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var multi []string
+	if err := json.Unmarshal(data, &multi); err != nil {
+		return err
+	}
+	*a = multi
+	return nil
+}
+
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+// Contains whether aud contains the audience
+func (a Audience) Contains(audience string) bool {
+	for _, aud := range a {
+		if aud == audience {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate check iss, aud, exp, nbf and iat against the expected values
+func (c *Claims) Validate(e Expected) error {
+	if e.Issuer != "" && c.Issuer != e.Issuer {
+		return InvalidIssuerError
+	}
+	if e.Audience != "" && !c.Audience.Contains(e.Audience) {
+		return InvalidAudienceError
+	}
+	var now = e.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	if c.Expiry != 0 && !now.Add(-e.Leeway).Before(time.Unix(c.Expiry, 0)) {
+		return TokenExpiredError
+	}
+	if c.NotBefore != 0 && now.Add(e.Leeway).Before(time.Unix(c.NotBefore, 0)) {
+		return TokenNotValidYetError
+	}
+	if c.IssuedAt != 0 && now.Add(e.Leeway).Before(time.Unix(c.IssuedAt, 0)) {
+		return TokenNotValidYetError
+	}
+	return nil
+}
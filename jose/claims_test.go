@@ -0,0 +1,45 @@
+package jose
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestClaimsValidate(t *testing.T) {
+	var now = time.Unix(1700000000, 0)
+	var claims Claims
+	if err := json.Unmarshal([]byte(`{"iss":"https://as.example.com","aud":"client","exp":1700000300,"iat":1700000000}`), &claims); err != nil {
+		t.Fatal(err)
+	}
+	var expected = Expected{Issuer: "https://as.example.com", Audience: "client", Time: now}
+	if err := claims.Validate(expected); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	cases := map[string]struct {
+		modify func(e *Expected)
+		err    error
+	}{
+		"issuer":   {func(e *Expected) { e.Issuer = "https://evil.example.com" }, InvalidIssuerError},
+		"audience": {func(e *Expected) { e.Audience = "other" }, InvalidAudienceError},
+		"expired":  {func(e *Expected) { e.Time = now.Add(10 * time.Minute) }, TokenExpiredError},
+		"skew":     {func(e *Expected) { e.Time = now.Add(-time.Minute) }, TokenNotValidYetError},
+		"leeway":   {func(e *Expected) { e.Time, e.Leeway = now.Add(-time.Minute), 2*time.Minute }, nil},
+	}
+	for name, c := range cases {
+		var e = expected
+		c.modify(&e)
+		if err := claims.Validate(e); err != c.err {
+			t.Errorf("%s: expected %v, got %v", name, c.err, err)
+		}
+	}
+
+	if err := json.Unmarshal([]byte(`{"aud":["a","b"]}`), &claims); err != nil || !claims.Audience.Contains("b") {
+		t.Errorf("unexpected audience %v %v", claims.Audience, err)
+	}
+	data, _ := json.Marshal(Audience{"a"})
+	if string(data) != `"a"` {
+		t.Errorf("single audience should marshal as string, got %s", data)
+	}
+}
@@ -0,0 +1,137 @@
+package jose
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/json"
+	"hash"
+	"strings"
+)
+
+// JWE key management and content encryption algorithms. RFC 7518 section 4 and 5
+const (
+	RSAOAEP    = "RSA-OAEP"
+	RSAOAEP256 = "RSA-OAEP-256"
+	A128GCM    = "A128GCM"
+	A256GCM    = "A256GCM"
+)
+
+// Encrypt create a compact serialized jwe with the recipient rsa public key.
+// header can carry kid and cty
+func Encrypt(alg, enc string, key *rsa.PublicKey, header map[string]interface{}, plaintext []byte) (string, error) {
+	if key == nil {
+		return "", InvalidKeyError
+	}
+	var oaepHash hash.Hash
+	switch alg {
+	case RSAOAEP:
+		oaepHash = sha1.New()
+	case RSAOAEP256:
+		oaepHash = sha256.New()
+	default:
+		return "", UnsupportedAlgorithmError
+	}
+	var keySize int
+	switch enc {
+	case A128GCM:
+		keySize = 16
+	case A256GCM:
+		keySize = 32
+	default:
+		return "", UnsupportedAlgorithmError
+	}
+
+	var h = map[string]interface{}{}
+	for k, v := range header {
+		h[k] = v
+	}
+	h["alg"], h["enc"] = alg, enc
+	headerJSON, err := json.Marshal(h)
+	if err != nil {
+		return "", err
+	}
+	var protected = Encode(headerJSON)
+
+	var cek = make([]byte, keySize)
+	if _, err := rand.Read(cek); err != nil {
+		return "", err
+	}
+	encryptedKey, err := rsa.EncryptOAEP(oaepHash, rand.Reader, key, cek, nil)
+	if err != nil {
+		return "", err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	var iv = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+	// aad is the ascii of protected header
+	var sealed = gcm.Seal(nil, iv, plaintext, []byte(protected))
+	var tagStart = len(sealed) - gcm.Overhead()
+
+	return protected + "." + Encode(encryptedKey) + "." + Encode(iv) + "." +
+		Encode(sealed[:tagStart]) + "." + Encode(sealed[tagStart:]), nil
+}
+
+// Decrypt decrypt a compact serialized jwe with the rsa private key
+func Decrypt(token string, key *rsa.PrivateKey) ([]byte, error) {
+	var parts = strings.Split(token, ".")
+	if len(parts) != 5 {
+		return nil, MalformedTokenError
+	}
+	headerJSON, err := Decode(parts[0])
+	if err != nil {
+		return nil, MalformedTokenError
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Enc string `json:"enc"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, MalformedTokenError
+	}
+	var oaepHash hash.Hash
+	switch header.Alg {
+	case RSAOAEP:
+		oaepHash = sha1.New()
+	case RSAOAEP256:
+		oaepHash = sha256.New()
+	default:
+		return nil, UnsupportedAlgorithmError
+	}
+
+	var decoded = make([][]byte, 4)
+	for i := range decoded {
+		if decoded[i], err = Decode(parts[i+1]); err != nil {
+			return nil, MalformedTokenError
+		}
+	}
+	cek, err := rsa.DecryptOAEP(oaepHash, rand.Reader, key, decoded[0], nil)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(decoded[1]) != gcm.NonceSize() {
+		return nil, MalformedTokenError
+	}
+	return gcm.Open(nil, decoded[1], append(decoded[2], decoded[3]...), []byte(parts[0]))
+}
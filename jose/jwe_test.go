@@ -0,0 +1,27 @@
+package jose
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+)
+
+func TestEncrypt(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, enc := range []string{A128GCM, A256GCM} {
+		token, err := Encrypt(RSAOAEP256, enc, &key.PublicKey, map[string]interface{}{"cty": "JWT"}, []byte("nested.jwt.value"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		plaintext, err := Decrypt(token, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(plaintext) != "nested.jwt.value" {
+			t.Errorf("unexpected plaintext %s", plaintext)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package jose
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"math/big"
+)
+
+// JSONWebKey public json web key. RFC 7517
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	// EC and OKP
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+}
+
+// NewJSONWebKey convert rsa, ecdsa or ed25519 public key to json web key
+func NewJSONWebKey(pub crypto.PublicKey) (*JSONWebKey, error) {
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		return &JSONWebKey{
+			Kty: "RSA",
+			N:   Encode(key.N.Bytes()),
+			E:   Encode(big.NewInt(int64(key.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		var size = (key.Curve.Params().BitSize + 7) / 8
+		var x, y = make([]byte, size), make([]byte, size)
+		key.X.FillBytes(x)
+		key.Y.FillBytes(y)
+		return &JSONWebKey{
+			Kty: "EC",
+			Crv: key.Curve.Params().Name,
+			X:   Encode(x),
+			Y:   Encode(y),
+		}, nil
+	case ed25519.PublicKey:
+		return &JSONWebKey{Kty: "OKP", Crv: "Ed25519", X: Encode(key)}, nil
+	}
+	return nil, InvalidKeyError
+}
+
+// PublicKey convert json web key to crypto public key
+func (k *JSONWebKey) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := Decode(k.N)
+		if err != nil {
+			return nil, InvalidKeyError
+		}
+		e, err := Decode(k.E)
+		if err != nil {
+			return nil, InvalidKeyError
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, InvalidKeyError
+		}
+		x, err := Decode(k.X)
+		if err != nil {
+			return nil, InvalidKeyError
+		}
+		y, err := Decode(k.Y)
+		if err != nil {
+			return nil, InvalidKeyError
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		x, err := Decode(k.X)
+		if err != nil || k.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, InvalidKeyError
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, InvalidKeyError
+}
+
+// Thumbprint base64url sha256 jwk thumbprint. RFC 7638
+func (k *JSONWebKey) Thumbprint() (string, error) {
+	// required members in lexicographic order
+	var members interface{}
+	switch k.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{k.E, k.Kty, k.N}
+	case "EC":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{k.Crv, k.Kty, k.X, k.Y}
+	case "OKP":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{k.Crv, k.Kty, k.X}
+	default:
+		return "", InvalidKeyError
+	}
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	var sum = sha256.Sum256(data)
+	return Encode(sum[:]), nil
+}
+
+// JSONWebKeySet json web key set. RFC 7517 section 5
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// Key find key by kid
+func (set *JSONWebKeySet) Key(kid string) (*JSONWebKey, bool) {
+	for i := range set.Keys {
+		if set.Keys[i].Kid == kid {
+			return &set.Keys[i], true
+		}
+	}
+	return nil, false
+}
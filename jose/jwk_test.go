@@ -0,0 +1,35 @@
+package jose
+
+import (
+	"crypto/rsa"
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONWebKeyThumbprint(t *testing.T) {
+	// example key from RFC 7638 section 3.1
+	var raw = `{"kty":"RSA","n":"0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw","e":"AQAB","alg":"RS256","kid":"2011-04-29"}`
+	var jwk JSONWebKey
+	if err := json.Unmarshal([]byte(raw), &jwk); err != nil {
+		t.Fatal(err)
+	}
+	thumbprint, err := jwk.Thumbprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if thumbprint != "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs" {
+		t.Errorf("unexpected thumbprint %s", thumbprint)
+	}
+
+	pub, err := jwk.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	back, err := NewJSONWebKey(pub.(*rsa.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back.N != jwk.N || back.E != jwk.E {
+		t.Error("public key round trip mismatch")
+	}
+}
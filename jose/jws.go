@@ -0,0 +1,298 @@
+package jose
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// JWS signing algorithms. RFC 7518 section 3
+const (
+	HS256 = "HS256"
+	HS384 = "HS384"
+	HS512 = "HS512"
+	RS256 = "RS256"
+	RS384 = "RS384"
+	RS512 = "RS512"
+	PS256 = "PS256"
+	PS384 = "PS384"
+	PS512 = "PS512"
+	ES256 = "ES256"
+	ES384 = "ES384"
+	ES512 = "ES512"
+	EdDSA = "EdDSA"
+)
+
+var (
+	UnsupportedAlgorithmError = errors.New("jose: unsupported algorithm")
+	InvalidKeyError           = errors.New("jose: key does not match algorithm")
+	MalformedTokenError       = errors.New("jose: malformed token")
+	InvalidSignatureError     = errors.New("jose: invalid signature")
+)
+
+// JSONWebSignature parsed compact serialized jws
+type JSONWebSignature struct {
+	Header  map[string]interface{}
+	Payload []byte
+
+	signingInput string
+	signature    []byte
+}
+
+// Sign create a compact serialized jws. key is a crypto.Signer for asymmetric
+// algorithms or []byte secret for HMAC algorithms.
+// alg and typ in header are filled automatically
+func Sign(alg string, key interface{}, header map[string]interface{}, claims interface{}) (string, error) {
+	var h = map[string]interface{}{}
+	for k, v := range header {
+		h[k] = v
+	}
+	h["alg"] = alg
+	if _, ok := h["typ"]; !ok {
+		h["typ"] = "JWT"
+	}
+
+	headerJSON, err := json.Marshal(h)
+	if err != nil {
+		return "", err
+	}
+	var payload []byte
+	if raw, ok := claims.([]byte); ok {
+		payload = raw
+	} else if payload, err = json.Marshal(claims); err != nil {
+		return "", err
+	}
+
+	var signingInput = Encode(headerJSON) + "." + Encode(payload)
+	signature, err := signBytes(alg, key, []byte(signingInput))
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + Encode(signature), nil
+}
+
+// Parse split and decode a compact serialized jws. The signature is not verified
+func Parse(token string) (*JSONWebSignature, error) {
+	var parts = strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, MalformedTokenError
+	}
+	headerJSON, err := Decode(parts[0])
+	if err != nil {
+		return nil, MalformedTokenError
+	}
+	var jws = &JSONWebSignature{signingInput: parts[0] + "." + parts[1]}
+	if err := json.Unmarshal(headerJSON, &jws.Header); err != nil {
+		return nil, MalformedTokenError
+	}
+	if jws.Payload, err = Decode(parts[1]); err != nil {
+		return nil, MalformedTokenError
+	}
+	if jws.signature, err = Decode(parts[2]); err != nil {
+		return nil, MalformedTokenError
+	}
+	return jws, nil
+}
+
+// Algorithm alg header
+func (jws *JSONWebSignature) Algorithm() string {
+	alg, _ := jws.Header["alg"].(string)
+	return alg
+}
+
+// KeyID kid header
+func (jws *JSONWebSignature) KeyID() string {
+	kid, _ := jws.Header["kid"].(string)
+	return kid
+}
+
+// Claims decode payload into v
+func (jws *JSONWebSignature) Claims(v interface{}) error {
+	return json.Unmarshal(jws.Payload, v)
+}
+
+// Verify check the signature with the alg header. key is a public key, *JSONWebKey
+// or []byte secret for HMAC algorithms. alg none is never accepted
+func (jws *JSONWebSignature) Verify(key interface{}) error {
+	if jwk, ok := key.(*JSONWebKey); ok {
+		if jwk.Alg != "" && jwk.Alg != jws.Algorithm() {
+			return InvalidKeyError
+		}
+		pub, err := jwk.PublicKey()
+		if err != nil {
+			return err
+		}
+		key = pub
+	}
+	if signer, ok := key.(crypto.Signer); ok {
+		key = signer.Public()
+	}
+	return verifyBytes(jws.Algorithm(), key, []byte(jws.signingInput), jws.signature)
+}
+
+// Encode base64url encode without padding
+func Encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Decode base64url decode without padding
+func Decode(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+func hashFor(alg string) (crypto.Hash, error) {
+	switch alg {
+	case HS256, RS256, PS256, ES256:
+		return crypto.SHA256, nil
+	case HS384, RS384, PS384, ES384:
+		return crypto.SHA384, nil
+	case HS512, RS512, PS512, ES512:
+		return crypto.SHA512, nil
+	}
+	return 0, UnsupportedAlgorithmError
+}
+
+func signBytes(alg string, key interface{}, input []byte) ([]byte, error) {
+	if alg == EdDSA {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, InvalidKeyError
+		}
+		if _, ok := signer.Public().(ed25519.PublicKey); !ok {
+			return nil, InvalidKeyError
+		}
+		return signer.Sign(rand.Reader, input, crypto.Hash(0))
+	}
+
+	hash, err := hashFor(alg)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasPrefix(alg, "HS") {
+		secret, ok := key.([]byte)
+		if !ok || len(secret) == 0 {
+			return nil, InvalidKeyError
+		}
+		mac := hmac.New(hash.New, secret)
+		mac.Write(input)
+		return mac.Sum(nil), nil
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, InvalidKeyError
+	}
+	h := hash.New()
+	h.Write(input)
+	var digest = h.Sum(nil)
+
+	switch alg[:2] {
+	case "RS":
+		if _, ok := signer.Public().(*rsa.PublicKey); !ok {
+			return nil, InvalidKeyError
+		}
+		return signer.Sign(rand.Reader, digest, hash)
+	case "PS":
+		if _, ok := signer.Public().(*rsa.PublicKey); !ok {
+			return nil, InvalidKeyError
+		}
+		return signer.Sign(rand.Reader, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: hash})
+	case "ES":
+		pub, ok := signer.Public().(*ecdsa.PublicKey)
+		if !ok {
+			return nil, InvalidKeyError
+		}
+		der, err := signer.Sign(rand.Reader, digest, hash)
+		if err != nil {
+			return nil, err
+		}
+		// jws use fixed size R || S instead of asn.1
+		var sig struct{ R, S *big.Int }
+		if _, err := asn1.Unmarshal(der, &sig); err != nil {
+			return nil, err
+		}
+		var size = (pub.Curve.Params().BitSize + 7) / 8
+		var out = make([]byte, 2*size)
+		sig.R.FillBytes(out[:size])
+		sig.S.FillBytes(out[size:])
+		return out, nil
+	}
+	return nil, UnsupportedAlgorithmError
+}
+
+func verifyBytes(alg string, key interface{}, input, sig []byte) error {
+	if alg == EdDSA {
+		pub, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return InvalidKeyError
+		}
+		if !ed25519.Verify(pub, input, sig) {
+			return InvalidSignatureError
+		}
+		return nil
+	}
+
+	hash, err := hashFor(alg)
+	if err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(alg, "HS") {
+		secret, ok := key.([]byte)
+		if !ok || len(secret) == 0 {
+			return InvalidKeyError
+		}
+		mac := hmac.New(hash.New, secret)
+		mac.Write(input)
+		if !hmac.Equal(mac.Sum(nil), sig) {
+			return InvalidSignatureError
+		}
+		return nil
+	}
+
+	h := hash.New()
+	h.Write(input)
+	var digest = h.Sum(nil)
+
+	switch alg[:2] {
+	case "RS", "PS":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return InvalidKeyError
+		}
+		if alg[:2] == "RS" {
+			err = rsa.VerifyPKCS1v15(pub, hash, digest, sig)
+		} else {
+			err = rsa.VerifyPSS(pub, hash, digest, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto, Hash: hash})
+		}
+		if err != nil {
+			return InvalidSignatureError
+		}
+		return nil
+	case "ES":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return InvalidKeyError
+		}
+		var size = (pub.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return InvalidSignatureError
+		}
+		var r, s = new(big.Int).SetBytes(sig[:size]), new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return InvalidSignatureError
+		}
+		return nil
+	}
+	return UnsupportedAlgorithmError
+}
@@ -0,0 +1,140 @@
+package jose
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestSign(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var claims = map[string]interface{}{"iss": "client", "scope": "openid"}
+
+	verify := map[string]func(input, sig []byte) bool{
+		RS256: func(input, sig []byte) bool {
+			sum := sha256.Sum256(input)
+			return rsa.VerifyPKCS1v15(&rsaKey.PublicKey, crypto.SHA256, sum[:], sig) == nil
+		},
+		PS256: func(input, sig []byte) bool {
+			sum := sha256.Sum256(input)
+			return rsa.VerifyPSS(&rsaKey.PublicKey, crypto.SHA256, sum[:], sig, nil) == nil
+		},
+		ES256: func(input, sig []byte) bool {
+			sum := sha256.Sum256(input)
+			r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+			return len(sig) == 64 && ecdsa.Verify(&ecKey.PublicKey, sum[:], r, s)
+		},
+		HS256: func(input, sig []byte) bool {
+			mac := hmac.New(sha256.New, []byte("secret"))
+			mac.Write(input)
+			return hmac.Equal(mac.Sum(nil), sig)
+		},
+	}
+	keys := map[string]interface{}{RS256: rsaKey, PS256: rsaKey, ES256: ecKey, HS256: []byte("secret")}
+
+	for alg, key := range keys {
+		token, err := Sign(alg, key, map[string]interface{}{"kid": "k1"}, claims)
+		if err != nil {
+			t.Fatalf("%s: %v", alg, err)
+		}
+		parts := strings.Split(token, ".")
+		if len(parts) != 3 {
+			t.Fatalf("%s: malformed token %s", alg, token)
+		}
+		headerJSON, _ := Decode(parts[0])
+		var header map[string]string
+		if err := json.Unmarshal(headerJSON, &header); err != nil || header["alg"] != alg || header["kid"] != "k1" {
+			t.Errorf("%s: unexpected header %s", alg, headerJSON)
+		}
+		sig, _ := Decode(parts[2])
+		if !verify[alg]([]byte(parts[0]+"."+parts[1]), sig) {
+			t.Errorf("%s: signature verify failed", alg)
+		}
+	}
+
+	if _, err := Sign(ES256, rsaKey, nil, claims); err != InvalidKeyError {
+		t.Errorf("expected InvalidKeyError, got %v", err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwk, err := NewJSONWebKey(&ecKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var claims = map[string]interface{}{"sub": "user"}
+
+	cases := []struct {
+		alg       string
+		signKey   interface{}
+		verifyKey interface{}
+	}{
+		{RS256, rsaKey, &rsaKey.PublicKey},
+		{PS256, rsaKey, &rsaKey.PublicKey},
+		{ES256, ecKey, jwk},
+		{HS256, []byte("secret"), []byte("secret")},
+	}
+	for _, c := range cases {
+		token, err := Sign(c.alg, c.signKey, map[string]interface{}{"kid": "k1"}, claims)
+		if err != nil {
+			t.Fatalf("%s: %v", c.alg, err)
+		}
+		jws, err := Parse(token)
+		if err != nil {
+			t.Fatalf("%s: %v", c.alg, err)
+		}
+		if jws.Algorithm() != c.alg || jws.KeyID() != "k1" {
+			t.Errorf("%s: unexpected header %v", c.alg, jws.Header)
+		}
+		if err := jws.Verify(c.verifyKey); err != nil {
+			t.Errorf("%s: %v", c.alg, err)
+		}
+		var got map[string]string
+		if err := jws.Claims(&got); err != nil || got["sub"] != "user" {
+			t.Errorf("%s: unexpected claims %v %v", c.alg, got, err)
+		}
+
+		// tamper the payload
+		var parts = strings.Split(token, ".")
+		tampered, _ := Parse(parts[0] + "." + Encode([]byte(`{"sub":"admin"}`)) + "." + parts[2])
+		if err := tampered.Verify(c.verifyKey); err != InvalidSignatureError {
+			t.Errorf("%s: expected InvalidSignatureError, got %v", c.alg, err)
+		}
+	}
+
+	token, _ := Sign(ES256, ecKey, nil, claims)
+	jws, _ := Parse(token)
+	if err := jws.Verify(&rsaKey.PublicKey); err != InvalidKeyError {
+		t.Errorf("expected InvalidKeyError, got %v", err)
+	}
+	none, _ := Parse(Encode([]byte(`{"alg":"none"}`)) + "." + Encode([]byte(`{}`)) + ".")
+	if err := none.Verify(&rsaKey.PublicKey); err != UnsupportedAlgorithmError {
+		t.Errorf("alg none must be rejected, got %v", err)
+	}
+	if _, err := Parse("a.b"); err != MalformedTokenError {
+		t.Errorf("expected MalformedTokenError, got %v", err)
+	}
+}
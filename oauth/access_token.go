@@ -1,12 +1,9 @@
 package oauth
 
 import (
-	"github.com/demo007x/oauth2-client/errorx"
+	"context"
 	"github.com/demo007x/oauth2-client/types"
 	"github.com/demo007x/oauth2-client/utils"
-	"net/http"
-	"net/url"
-	"strings"
 )
 
 type (
@@ -18,13 +15,24 @@ type (
 		Code        string
 		GrantType   string
 		RedirectURI string
-		ContentType string
+		// CodeVerifier PKCE code verifier of the authorization request
+		CodeVerifier string
+		ContentType  string
+		// AuthMethod client_secret_basic by default
+		AuthMethod string
+		// Method http method of token request, POST by default
+		Method string
+		Accept string
+		// Query custom params of token request
+		Query map[string]string
 		// Internal field
-		handler types.OauthResponseHandler
-		sup     *url.URL
-		values  url.Values
-		header  map[string]string
-		err     error
+		handler      types.OauthResponseHandler
+		ctx          context.Context
+		dpop         *DPoP
+		mtls         *MutualTLS
+		header       map[string]string
+		interceptors []utils.RequestInterceptor
+		observer     Observer
 	}
 )
 
@@ -44,14 +52,46 @@ func AccessTokenWithRedirectURI(redirectURI string) AccessTokenOption {
 	}
 }
 
-// AccessTokenWithContentType set content type
+// AccessTokenWithCodeVerifier send the PKCE code verifier. eg: WithPKCE
+func AccessTokenWithCodeVerifier(verifier string) AccessTokenOption {
+	return func(ac *AccessToken) {
+		ac.CodeVerifier = verifier
+	}
+}
+
+// AccessTokenWithContentType set encoding of the params, form by default. eg: application/json
 func AccessTokenWithContentType(contentType string) AccessTokenOption {
 	return func(ac *AccessToken) {
 		ac.ContentType = contentType
-		if strings.TrimSpace(contentType) == "" {
-			ac.ContentType = "application/json"
-		}
-		ac.header["Content-Type"] = contentType
+	}
+}
+
+// AccessTokenWithAuthMethod set client authentication method. eg: client_secret_post
+func AccessTokenWithAuthMethod(authMethod string) AccessTokenOption {
+	return func(ac *AccessToken) {
+		ac.AuthMethod = authMethod
+	}
+}
+
+// AccessTokenWithMethod set http method of token request
+func AccessTokenWithMethod(method string) AccessTokenOption {
+	return func(ac *AccessToken) {
+		ac.Method = method
+	}
+}
+
+// AccessTokenWithAccept set accept header. eg: application/json
+func AccessTokenWithAccept(accept string) AccessTokenOption {
+	return func(ac *AccessToken) {
+		ac.Accept = accept
+		ac.header["Accept"] = accept
+	}
+}
+
+// AccessTokenWithQuery custom params of token request. eg: appid=x&secret=y
+func AccessTokenWithQuery(query map[string]string) AccessTokenOption {
+	return func(ac *AccessToken) {
+		ac.Query = query
 	}
 }
 
@@ -81,92 +121,70 @@ func AccessTokenWithResponseHandler(handler types.OauthResponseHandler) AccessTo
 	}
 }
 
-// set server uri
-func (ac *AccessToken) setServerURI() *AccessToken {
-	if ac.err == nil {
-		ac.sup, ac.err = url.Parse(ac.ServerURL)
-		if ac.err == nil {
-			ac.values = ac.sup.Query()
-		}
-	}
-	return ac
+// AccessTokenWithResponseMiddleware stack middlewares on the default response handler. eg: types.CheckStatus(nil), types.DecodeBody()
+func AccessTokenWithResponseMiddleware(middlewares ...types.ResponseMiddleware) AccessTokenOption {
+	return AccessTokenWithResponseHandler(types.ChainResponseHandler(middlewares...))
 }
 
-// set key and secret
-func (ac *AccessToken) setKeyAndSecret() *AccessToken {
-	if ac.err == nil {
-		if strings.TrimSpace(ac.Key) == "" {
-			ac.err = errorx.ClientKeyError
-			return ac
-		}
-
-		if strings.TrimSpace(ac.Secret) == "" {
-			ac.err = errorx.SecretKeyError
-			return ac
-		}
-		// set authorization
-		ac.header["Authorization"] = utils.GenerateBaseAuthorization(ac.Key, ac.Secret)
+// AccessTokenWithDPoP request a DPoP bound access token
+func AccessTokenWithDPoP(d *DPoP) AccessTokenOption {
+	return func(ac *AccessToken) {
+		ac.dpop = d
 	}
-	return ac
 }
 
-// set grant type. if empty set default
-func (ac *AccessToken) setGrantType() *AccessToken {
-	if ac.err == nil {
-		if strings.TrimSpace(ac.GrantType) != "" {
-			ac.GrantType = types.DefaultAccessTokenGrantType
-			ac.values.Set("grant_type", ac.GrantType)
-		}
+// AccessTokenWithMutualTLS authenticate with client certificate and bind the token to it
+func AccessTokenWithMutualTLS(m *MutualTLS) AccessTokenOption {
+	return func(ac *AccessToken) {
+		ac.mtls = m
 	}
-	return ac
 }
 
-// set code
-func (ac *AccessToken) setCode() *AccessToken {
-	if ac.err == nil {
-		if strings.TrimSpace(ac.Code) == "" {
-			ac.err = errorx.CodeEmptyError
-			return ac
-		}
-		ac.values.Set("code", ac.Code)
+// AccessTokenWithContext send the token request with context
+func AccessTokenWithContext(ctx context.Context) AccessTokenOption {
+	return func(ac *AccessToken) {
+		ac.ctx = ctx
 	}
-	return ac
 }
 
-// set redirect uri
-func (ac *AccessToken) setRedirectURI() *AccessToken {
-	if ac.err == nil {
-		if strings.TrimSpace(ac.RedirectURI) != "" {
-			ac.values.Set("redirect_url", ac.RedirectURI)
-		}
+// AccessTokenWithInterceptors modify or audit the request before it is sent. eg: custom headers, signing
+func AccessTokenWithInterceptors(interceptors ...utils.RequestInterceptor) AccessTokenOption {
+	return func(ac *AccessToken) {
+		ac.interceptors = append(ac.interceptors, interceptors...)
 	}
-	return ac
 }
 
-// DoRequest request access token from oauth server
-func (ac *AccessToken) DoRequest() ([]byte, error) {
-	if err := ac.setServerURI().
-		setKeyAndSecret().
-		setGrantType().
-		setCode().
-		setRedirectURI().
-		err; err != nil {
-		return nil, ac.err
-	}
-
-	ac.sup.RawQuery = ac.values.Encode()
-	var requestHost = ac.sup.String()
-
-	resp, err := utils.DoRequest(requestHost, http.MethodPost, ac.header)
-	if err != nil {
-		return nil, err
+// AccessTokenWithObserver report the call to observer. eg: PrometheusCollector
+func AccessTokenWithObserver(observer Observer) AccessTokenOption {
+	return func(ac *AccessToken) {
+		ac.observer = observer
 	}
+}
 
-	if ac.handler == nil {
-		return types.DefaultOauthResponseHandler(resp)
+// endpoint token endpoint of the request
+func (ac *AccessToken) endpoint() *TokenEndpoint {
+	return &TokenEndpoint{
+		ServerURL:    ac.ServerURL,
+		ClientID:     ac.Key,
+		Secret:       ac.Secret,
+		AuthMethod:   ac.AuthMethod,
+		Method:       ac.Method,
+		ContentType:  ac.ContentType,
+		Header:       ac.header,
+		Query:        ac.Query,
+		handler:      rawResponseHandler(ac.handler),
+		ctx:          ac.ctx,
+		dpop:         ac.dpop,
+		mtls:         ac.mtls,
+		interceptors: ac.interceptors,
+		observer:     ac.observer,
 	}
+}
 
-	return ac.handler(resp)
+// DoRequest request access token from oauth server
+func (ac *AccessToken) DoRequest() ([]byte, error) {
+	var grant = &AuthorizationCodeGrant{Code: ac.Code, RedirectURI: ac.RedirectURI, CodeVerifier: ac.CodeVerifier}
+	return ac.endpoint().Do(withGrantType(grant, ac.GrantType))
 }
 
 // NewAccessToken return AccessToken implement
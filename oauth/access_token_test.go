@@ -1,19 +1,29 @@
 package oauth
 
 import (
+	"github.com/demo007x/oauth2-client/oauthtest"
 	"io"
 	"log"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
 func TestNewAccessToken(t *testing.T) {
-	var serverURL = "http://localhost:8200/api/v1/oauth2/token"
+	srv := oauthtest.NewServer()
+	defer srv.Close()
+
 	var redirectURI = "http://localhost:8200/"
-	var username = "A9GzcBd1Qt3jbv4YBPOEHb1xKXDyNBBIFiK"
-	var password = "aOh4131NG3odJWF3o1c2TWncpwy2qgoCuNxcS5uw"
-	var code = "OGNJNTIXNGMTZWFKNC0ZZDJKLWJMMWUTYMY3NTYXOTU4ZTC2"
-	token := NewAccessToken(serverURL, redirectURI, username, password, code, AccessTokenWithGrantType("authorization_code"), AccessTokenWithContentType("application/json"), AccessTokenWithResponseHandler(func(resp *http.Response) ([]byte, error) {
+	authURL, err := NewOauth2Client(srv.AuthorizeURL(), oauthtest.DefaultClientID, WithRedirectURI(redirectURI), WithState("xxxxx")).AuthorizeURL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	callback, err := srv.Authorize(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var code = callback.Query().Get("code")
+	token := NewAccessToken(srv.TokenURL(), oauthtest.DefaultClientID, oauthtest.DefaultClientSecret, code, AccessTokenWithRedirectURI(redirectURI), AccessTokenWithGrantType("authorization_code"), AccessTokenWithContentType("application/json"), AccessTokenWithResponseHandler(func(resp *http.Response) ([]byte, error) {
 		defer func() {
 			if err := resp.Body.Close(); err != nil {
 				log.Println(err)
@@ -27,7 +37,34 @@ func TestNewAccessToken(t *testing.T) {
 	}))
 	data, err := token.DoRequest()
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
+	}
+	tk, err := ParseToken(data)
+	if err != nil || tk.AccessToken == "" || tk.RefreshToken == "" {
+		t.Errorf("unexpected token response %s %v", data, err)
+	}
+
+	// codes are single use
+	data, _ = token.DoRequest()
+	if _, err := ParseToken(data); err == nil {
+		t.Errorf("expected reused code to be rejected, got %s", data)
+	}
+}
+
+func TestAccessTokenRedirectURI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("redirect_uri") != "https://app.example.com/cb" || r.PostForm.Get("redirect_url") != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_request"}`))
+			return
+		}
+		w.Write([]byte(`{"access_token":"at"}`))
+	}))
+	defer server.Close()
+
+	// RFC 6749 section 4.1.3 name the parameter redirect_uri, not redirect_url
+	data, err := NewAccessToken(server.URL, "client", "secret", "code", AccessTokenWithRedirectURI("https://app.example.com/cb")).DoRequest()
+	if err != nil || string(data) != `{"access_token":"at"}` {
+		t.Errorf("expected redirect_uri parameter, got %s %v", data, err)
 	}
-	t.Log(string(data))
 }
@@ -0,0 +1,135 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/demo007x/oauth2-client/types"
+	"github.com/demo007x/oauth2-client/utils"
+	"net/http"
+	"net/url"
+)
+
+type (
+	APIRequestOption func(r *APIRequest)
+	// APIRequest provider specific api call sent on the shared request path of the endpoints:
+	// interceptors, retry, tracing and observation. eg: the json apis of the provider adapters
+	APIRequest struct {
+		ServerURL string
+		// Method http method, POST by default
+		Method string
+
+		// internal field
+		header  map[string]string
+		body    []byte
+		handler types.OauthResponseHandler
+		retry   *RetryPolicy
+		ctx     context.Context
+		err     error
+		// interceptors run on the built request before it is sent
+		interceptors []utils.RequestInterceptor
+		observer     Observer
+	}
+)
+
+// APIRequestWithHeader add custom request headers, set after the body content type
+func APIRequestWithHeader(header map[string]string) APIRequestOption {
+	return func(r *APIRequest) {
+		for key, val := range header {
+			r.header[key] = val
+		}
+	}
+}
+
+// APIRequestWithJSON send v as json body
+func APIRequestWithJSON(v interface{}) APIRequestOption {
+	return func(r *APIRequest) {
+		body, err := json.Marshal(v)
+		if err != nil {
+			r.err = err
+			return
+		}
+		r.body = body
+		r.header["Content-Type"] = "application/json"
+		r.header["Accept"] = "application/json"
+	}
+}
+
+// APIRequestWithForm send values as form encoded body
+func APIRequestWithForm(values url.Values) APIRequestOption {
+	return func(r *APIRequest) {
+		r.body = []byte(values.Encode())
+		r.header["Content-Type"] = "application/x-www-form-urlencoded"
+	}
+}
+
+// APIRequestWithResponseHandler read the response, types.DefaultOauthResponseHandler by default
+func APIRequestWithResponseHandler(handler types.OauthResponseHandler) APIRequestOption {
+	return func(r *APIRequest) {
+		r.handler = handler
+	}
+}
+
+// APIRequestWithRetryPolicy retry dial failures, api calls are not known to be safe to replay
+func APIRequestWithRetryPolicy(policy *RetryPolicy) APIRequestOption {
+	return func(r *APIRequest) {
+		r.retry = policy
+	}
+}
+
+// APIRequestWithContext send the request with context
+func APIRequestWithContext(ctx context.Context) APIRequestOption {
+	return func(r *APIRequest) {
+		r.ctx = ctx
+	}
+}
+
+// APIRequestWithInterceptors modify or audit the request before it is sent. eg: tenant header
+func APIRequestWithInterceptors(interceptors ...utils.RequestInterceptor) APIRequestOption {
+	return func(r *APIRequest) {
+		r.interceptors = append(r.interceptors, interceptors...)
+	}
+}
+
+// APIRequestWithObserver report the call to observer as EndpointAPI
+func APIRequestWithObserver(observer Observer) APIRequestOption {
+	return func(r *APIRequest) {
+		r.observer = observer
+	}
+}
+
+// DoRequest send the request and return the body read by the response handler
+func (r *APIRequest) DoRequest() ([]byte, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if _, err := url.Parse(r.ServerURL); err != nil {
+		return nil, err
+	}
+	var method = r.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	var opts = []utils.RequestOption{utils.RequestWithContext(r.ctx), utils.RequestWithInterceptors(r.interceptors...)}
+	var handler = r.handler
+	if handler == nil {
+		handler = types.DefaultOauthResponseHandler
+	}
+	var c = &call{ctx: r.ctx, endpoint: EndpointAPI, retry: r.retry, dialOnly: true, observer: r.observer}
+	_, data, err := c.send(func(trace ...utils.RequestOption) (*http.Response, error) {
+		var opts = append(opts[:len(opts):len(opts)], trace...)
+		if r.body != nil {
+			opts = append(opts, utils.RequestWithBodyBytes(r.body))
+		}
+		return utils.DoRequest(r.ServerURL, method, r.header, opts...)
+	}, handler)
+	return data, err
+}
+
+// NewAPIRequest return request of a provider api
+func NewAPIRequest(serverURL, method string, opts ...APIRequestOption) *APIRequest {
+	var r = &APIRequest{ServerURL: serverURL, Method: method, header: map[string]string{}}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
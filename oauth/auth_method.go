@@ -0,0 +1,51 @@
+package oauth
+
+import (
+	"github.com/demo007x/oauth2-client/errorx"
+	"github.com/demo007x/oauth2-client/utils"
+	"net/url"
+	"strings"
+)
+
+// Token endpoint client authentication methods.
+// RFC 7591 section 2 and RFC 8705 section 2
+const (
+	AuthMethodClientSecretBasic       = "client_secret_basic"
+	AuthMethodClientSecretPost        = "client_secret_post"
+	AuthMethodNone                    = "none"
+	AuthMethodTLSClientAuth           = "tls_client_auth"
+	AuthMethodSelfSignedTLSClientAuth = "self_signed_tls_client_auth"
+)
+
+// authenticate apply client credentials to the token request by auth method
+func authenticate(authMethod, clientID, secret string, values url.Values, header map[string]string) error {
+	if strings.TrimSpace(clientID) == "" {
+		return errorx.ClientKeyError
+	}
+	switch authMethod {
+	case AuthMethodNone, AuthMethodTLSClientAuth, AuthMethodSelfSignedTLSClientAuth:
+		values.Set("client_id", clientID)
+		return nil
+	}
+	if strings.TrimSpace(secret) == "" {
+		return errorx.SecretKeyError
+	}
+	if authMethod == AuthMethodClientSecretPost {
+		values.Set("client_id", clientID)
+		values.Set("client_secret", secret)
+		return nil
+	}
+	header["Authorization"] = utils.GenerateBaseAuthorization(clientID, secret)
+	return nil
+}
+
+// authMethod explicit auth method or the mutual tls one, client_secret_basic by default
+func authMethod(explicit string, m *MutualTLS) string {
+	if strings.TrimSpace(explicit) != "" {
+		return explicit
+	}
+	if m.clientAuth() {
+		return m.AuthMethod
+	}
+	return AuthMethodClientSecretBasic
+}
@@ -1,8 +1,10 @@
 package oauth
 
 import (
+	"encoding/json"
 	"github.com/demo007x/oauth2-client/errorx"
 	"github.com/demo007x/oauth2-client/types"
+	"github.com/demo007x/oauth2-client/utils"
 	"net/url"
 	"strings"
 )
@@ -17,10 +19,24 @@ type (
 		Scope        string
 		ResponseType string
 		Query        map[string]string
+		Nonce        string
+		// Claims requested individual claims. OpenID Connect Core 5.5
+		Claims map[string]interface{}
+		// PushedAuthorizationEndpoint enable PAR mode. RFC 9126
+		PushedAuthorizationEndpoint string
+		// RequestObject pack the authorization params into a signed request jwt. RFC 9101
+		RequestObject *RequestObject
+		// DPoP bind the authorization code to the DPoP key. RFC 9449
+		DPoP *DPoP
+		// CodeVerifier send its code challenge. RFC 7636
+		CodeVerifier string
 		// internal filed
 		u      *url.URL
 		values url.Values
 		err    error
+		// interceptors run on the pushed authorization request before it is sent
+		interceptors []utils.RequestInterceptor
+		observer     Observer
 	}
 
 	// WithOption config option with oauth client field
@@ -63,6 +79,42 @@ func WithState(state string) WithOption {
 	}
 }
 
+// WithSecret set client secret
+// used for client authentication at pushed authorization request endpoint
+func WithSecret(secret string) WithOption {
+	return func(client *Client) {
+		client.Secret = secret
+	}
+}
+
+// WithNonce set openid connect nonce, the id token will carry the same value
+func WithNonce(nonce string) WithOption {
+	return func(client *Client) {
+		client.Nonce = nonce
+	}
+}
+
+// WithClaims set openid connect claims request parameter
+func WithClaims(claims map[string]interface{}) WithOption {
+	return func(client *Client) {
+		client.Claims = claims
+	}
+}
+
+// WithInterceptors modify or audit the pushed authorization request before it is sent
+func WithInterceptors(interceptors ...utils.RequestInterceptor) WithOption {
+	return func(client *Client) {
+		client.interceptors = append(client.interceptors, interceptors...)
+	}
+}
+
+// WithObserver report the pushed authorization call to observer
+func WithObserver(observer Observer) WithOption {
+	return func(client *Client) {
+		client.observer = observer
+	}
+}
+
 func withClientID(clientID string) WithOption {
 	return func(client *Client) {
 		client.ClientID = clientID
@@ -129,10 +181,29 @@ func (client *Client) setState() *Client {
 	return client
 }
 
+func (client *Client) setNonce() *Client {
+	if client.err == nil && strings.TrimSpace(client.Nonce) != "" {
+		client.values.Set("nonce", client.Nonce)
+	}
+	return client
+}
+
+func (client *Client) setClaims() *Client {
+	if client.err == nil && len(client.Claims) > 0 {
+		data, err := json.Marshal(client.Claims)
+		if err != nil {
+			client.err = err
+			return client
+		}
+		client.values.Set("claims", string(data))
+	}
+	return client
+}
+
 func (client *Client) setQuery() *Client {
-	if client.err == nil && len(client.Query) >= 0 {
+	if client.err == nil {
 		for key, val := range client.Query {
-			client.values.Add(key, val)
+			client.values.Set(key, val)
 		}
 	}
 	return client
@@ -149,21 +220,40 @@ func (client *Client) setClientID() *Client {
 	return client
 }
 
-func (client *Client) AuthorizeURL() (string, error) {
-	if err := client.
+// build authorize request params
+func (client *Client) build() *Client {
+	return client.
 		setServerURI().
 		setRedirect().
 		setQuery().
 		setResponseType().
 		setScope().
 		setState().
+		setNonce().
+		setClaims().
 		setClientID().
-		err; err != nil {
-		return "", client.err
+		setDPoPThumbprint().
+		setCodeChallenge().
+		setRequestObject()
+}
+
+func (client *Client) AuthorizeURL() (string, error) {
+	if strings.TrimSpace(client.PushedAuthorizationEndpoint) != "" {
+		par, err := client.PushAuthorizationRequest()
+		if err != nil {
+			return "", err
+		}
+		return par.AuthorizeURL, nil
+	}
+
+	// build on a copy, so the client can be reused and shared between goroutines
+	var c = *client
+	if err := c.build().err; err != nil {
+		return "", err
 	}
 
-	client.u.RawQuery = client.values.Encode()
-	return client.u.String(), nil
+	c.u.RawQuery = c.values.Encode()
+	return c.u.String(), nil
 }
 
 func NewOauth2Client(serverURL, clientID string, opts ...WithOption) *Client {
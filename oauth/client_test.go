@@ -1,24 +1,32 @@
 package oauth
 
 import (
+	"github.com/demo007x/oauth2-client/oauthtest"
 	"testing"
 )
 
 func TestNewOauth2Client(t *testing.T) {
-	var serverURL = "http://127.0.0.1:8200/auth/authorize"
-	var redirectURI = ""
+	srv := oauthtest.NewServer()
+	defer srv.Close()
+
+	var redirectURI = "http://127.0.0.1:8080/oauth/callback"
 	var client = NewOauth2Client(
-		serverURL,
-		"2wLCawQ1fFhmsj0ADIQIquCLiGR6qSLA",
+		srv.AuthorizeURL(),
+		oauthtest.DefaultClientID,
 		WithResponseType("code"),
 		WithRedirectURI(redirectURI),
 		WithState("xxxxx"),
 	)
 
-	resp, err := client.AuthorizeURL()
+	authURL, err := client.AuthorizeURL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	callback, err := srv.Authorize(authURL)
 	if err != nil {
-		t.Error(err)
-	} else {
-		t.Log(resp)
+		t.Fatal(err)
+	}
+	if callback.Query().Get("code") == "" || callback.Query().Get("state") != "xxxxx" {
+		t.Errorf("unexpected callback %s", callback)
 	}
 }
@@ -0,0 +1,215 @@
+package oauth
+
+import (
+	"context"
+	"github.com/demo007x/oauth2-client/errorx"
+	"github.com/demo007x/oauth2-client/types"
+	"github.com/demo007x/oauth2-client/utils"
+	"strings"
+)
+
+type (
+	// Endpoint authorization server endpoints of a Config
+	Endpoint struct {
+		AuthorizeURL string
+		TokenURL     string
+		RevokeURL    string
+		// AuthMethod token endpoint client authentication method, client_secret_basic by default
+		AuthMethod string
+	}
+
+	ConfigOption func(c *Config)
+	// Config immutable client configuration, build it once at startup.
+	// Every call create its own request state, so a Config is safe for concurrent use
+	Config struct {
+		endpoint    Endpoint
+		clientID    string
+		secret      string
+		redirectURI string
+		scopes      []string
+		dpop        *DPoP
+		mtls        *MutualTLS
+		retry       *RetryPolicy
+		// interceptors run on every request of the Config
+		interceptors []utils.RequestInterceptor
+		observer     Observer
+	}
+)
+
+// ConfigWithSecret set client secret of confidential client
+func ConfigWithSecret(secret string) ConfigOption {
+	return func(c *Config) {
+		c.secret = secret
+	}
+}
+
+// ConfigWithRedirectURI set redirect uri of authorization and token requests
+func ConfigWithRedirectURI(redirectURI string) ConfigOption {
+	return func(c *Config) {
+		c.redirectURI = redirectURI
+	}
+}
+
+// ConfigWithScopes set requested scopes
+func ConfigWithScopes(scopes ...string) ConfigOption {
+	return func(c *Config) {
+		c.scopes = append([]string(nil), scopes...)
+	}
+}
+
+// ConfigWithDPoP bind codes and tokens to the DPoP key
+func ConfigWithDPoP(d *DPoP) ConfigOption {
+	return func(c *Config) {
+		c.dpop = d
+	}
+}
+
+// ConfigWithMutualTLS authenticate with client certificate
+func ConfigWithMutualTLS(m *MutualTLS) ConfigOption {
+	return func(c *Config) {
+		c.mtls = m
+	}
+}
+
+// ConfigWithRetryPolicy retry refresh requests that failed to connect.
+// Authorization codes are single use and never retried. A refresh answered with 5xx
+// is not retried either, the server may already have rotated the refresh token
+func ConfigWithRetryPolicy(policy *RetryPolicy) ConfigOption {
+	return func(c *Config) {
+		c.retry = policy
+	}
+}
+
+// ConfigWithInterceptors modify or audit every request before it is sent. eg: User-Agent, correlation id
+func ConfigWithInterceptors(interceptors ...utils.RequestInterceptor) ConfigOption {
+	return func(c *Config) {
+		c.interceptors = append(c.interceptors, interceptors...)
+	}
+}
+
+// ConfigWithObserver report every call of the Config to observer. eg: PrometheusCollector
+func ConfigWithObserver(observer Observer) ConfigOption {
+	return func(c *Config) {
+		c.observer = observer
+	}
+}
+
+func (c *Config) ClientID() string {
+	return c.clientID
+}
+
+func (c *Config) RedirectURI() string {
+	return c.redirectURI
+}
+
+func (c *Config) Endpoint() Endpoint {
+	return c.endpoint
+}
+
+// Scopes return a copy of the requested scopes
+func (c *Config) Scopes() []string {
+	return append([]string(nil), c.scopes...)
+}
+
+// AuthCodeURL build authorize url with state. opts override the configured values per call.
+// eg: WithNonce, WithQuery
+func (c *Config) AuthCodeURL(state string, opts ...WithOption) (string, error) {
+	opts = append([]WithOption{
+		WithRedirectURI(c.redirectURI),
+		WithScope(strings.Join(c.scopes, " ")),
+		WithState(state),
+		WithSecret(c.secret),
+		WithDPoP(c.dpop),
+		WithInterceptors(c.interceptors...),
+		WithObserver(c.observer),
+	}, opts...)
+	return NewOauth2Client(c.endpoint.AuthorizeURL, c.clientID, opts...).AuthorizeURL()
+}
+
+// Exchange exchange authorization code for token
+func (c *Config) Exchange(ctx context.Context, code string, opts ...AccessTokenOption) (*Token, error) {
+	opts = append([]AccessTokenOption{
+		AccessTokenWithContext(ctx),
+		AccessTokenWithGrantType(types.DefaultAccessTokenGrantType),
+		AccessTokenWithRedirectURI(c.redirectURI),
+		AccessTokenWithAuthMethod(c.endpoint.AuthMethod),
+		AccessTokenWithAccept("application/json"),
+		AccessTokenWithDPoP(c.dpop),
+		AccessTokenWithResponseHandler(statusResponseHandler),
+		AccessTokenWithMutualTLS(c.mtls),
+		AccessTokenWithInterceptors(c.interceptors...),
+		AccessTokenWithObserver(c.observer),
+	}, opts...)
+	data, err := NewAccessToken(c.endpoint.TokenURL, c.clientID, c.secret, code, opts...).DoRequest()
+	if err != nil {
+		return nil, err
+	}
+	return ParseToken(data)
+}
+
+// Refresh renew token with refresh token. The old refresh token is kept when the server does not rotate it
+func (c *Config) Refresh(ctx context.Context, refreshToken string, opts ...RefreshTokenOption) (*Token, error) {
+	opts = append([]RefreshTokenOption{
+		RefreshTokenWithContext(ctx),
+		RefreshTokenWithAuthMethod(c.endpoint.AuthMethod),
+		RefreshTokenWithAccept("application/json"),
+		RefreshTokenWithDPoP(c.dpop),
+		RefreshTokenWithResponseHandler(statusResponseHandler),
+		RefreshTokenWithMutualTLS(c.mtls),
+		RefreshTokenWithInterceptors(c.interceptors...),
+		RefreshTokenWithObserver(c.observer),
+		RefreshTokenWithRetryPolicy(c.retry),
+	}, opts...)
+	data, err := NewRefreshToken(c.endpoint.TokenURL, c.clientID, c.secret, refreshToken, opts...).DoRequest()
+	if err != nil {
+		return nil, err
+	}
+	token, err := ParseToken(data)
+	if err != nil {
+		return nil, err
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+	return token, nil
+}
+
+// Grant send any grant to the token endpoint. eg: ClientCredentialsGrant or a grant implemented outside the package
+func (c *Config) Grant(ctx context.Context, grant Grant, opts ...TokenEndpointOption) (*Token, error) {
+	opts = append([]TokenEndpointOption{
+		TokenEndpointWithContext(ctx),
+		TokenEndpointWithAuthMethod(c.endpoint.AuthMethod),
+		TokenEndpointWithHeader(map[string]string{"Accept": "application/json"}),
+		TokenEndpointWithDPoP(c.dpop),
+		TokenEndpointWithMutualTLS(c.mtls),
+		TokenEndpointWithInterceptors(c.interceptors...),
+		TokenEndpointWithObserver(c.observer),
+	}, opts...)
+	return NewTokenEndpoint(c.endpoint.TokenURL, c.clientID, c.secret, opts...).Token(grant)
+}
+
+// Revoke revoke access token or refresh token. RFC 7009
+func (c *Config) Revoke(ctx context.Context, token string, opts ...RevokeTokenOption) error {
+	if strings.TrimSpace(c.endpoint.RevokeURL) == "" {
+		return errorx.ServerURLError
+	}
+	opts = append([]RevokeTokenOption{
+		RevokeTokenWithContext(ctx),
+		RevokeTokenWithAuthMethod(c.endpoint.AuthMethod),
+		RevokeTokenWithResponseHandler(statusResponseHandler),
+		RevokeTokenWithMutualTLS(c.mtls),
+		RevokeTokenWithInterceptors(c.interceptors...),
+		RevokeTokenWithObserver(c.observer),
+	}, opts...)
+	_, err := NewOauthRevokeToken(c.endpoint.RevokeURL, c.clientID, c.secret, token, opts...).DoRequest()
+	return err
+}
+
+// NewConfig return immutable Config of the endpoint
+func NewConfig(endpoint Endpoint, clientID string, opts ...ConfigOption) *Config {
+	var c = &Config{endpoint: endpoint, clientID: clientID}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
@@ -0,0 +1,149 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"github.com/demo007x/oauth2-client/errorx"
+	"github.com/demo007x/oauth2-client/utils"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestConfig(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "client" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/token":
+			switch r.FormValue("grant_type") {
+			case "authorization_code":
+				if r.FormValue("code") != "code" || r.FormValue("redirect_uri") != "https://app.example.com/cb" {
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(`{"error":"invalid_grant"}`))
+					return
+				}
+				w.Write([]byte(`{"access_token":"AT","token_type":"Bearer","expires_in":3600,"refresh_token":"RT"}`))
+			case "refresh_token":
+				w.Write([]byte(`{"access_token":"AT2","token_type":"Bearer","expires_in":3600}`))
+			}
+		case "/revoke":
+			if r.FormValue("token") != "RT" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"unsupported_token_type"}`))
+			}
+		}
+	}))
+	defer server.Close()
+
+	config := NewConfig(Endpoint{
+		AuthorizeURL: "https://as.example.com/authorize?prompt=login",
+		TokenURL:     server.URL + "/token",
+		RevokeURL:    server.URL + "/revoke",
+	}, "client", ConfigWithSecret("secret"), ConfigWithRedirectURI("https://app.example.com/cb"), ConfigWithScopes("openid", "profile"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var state = strconv.Itoa(i)
+			authURL, err := config.AuthCodeURL(state, WithQuery(map[string]string{"prompt": "consent"}))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			u, _ := url.Parse(authURL)
+			if q := u.Query(); q.Get("state") != state || len(q["prompt"]) != 1 || q.Get("prompt") != "consent" || q.Get("scope") != "openid profile" {
+				t.Errorf("unexpected authorize url %s", authURL)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	ctx := context.Background()
+	token, err := config.Exchange(ctx, "code")
+	if err != nil || token.AccessToken != "AT" || token.RefreshToken != "RT" {
+		t.Fatalf("unexpected token %+v %v", token, err)
+	}
+	var oe *errorx.OauthError
+	if _, err := config.Exchange(ctx, "used"); !errors.As(err, &oe) || oe.StatusCode != http.StatusBadRequest || oe.Code != "invalid_grant" {
+		t.Errorf("expected invalid_grant, got %v", err)
+	}
+	if token, err = config.Refresh(ctx, "RT"); err != nil || token.AccessToken != "AT2" || token.RefreshToken != "RT" {
+		t.Errorf("refresh should keep the old refresh token, got %+v %v", token, err)
+	}
+	if err := config.Revoke(ctx, "RT", RevokeTokenWithTokenTypeHint("refresh_token")); err != nil {
+		t.Error(err)
+	}
+	if err := config.Revoke(ctx, "unknown"); !errors.As(err, &oe) || oe.Code != "unsupported_token_type" {
+		t.Errorf("expected revoke error, got %v", err)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := config.Exchange(canceled, "code"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context canceled, got %v", err)
+	}
+}
+
+func TestClientAuthorizeURLReuse(t *testing.T) {
+	client := NewOauth2Client("http://[::1", "client", WithQuery(map[string]string{"display": "mobile"}))
+	if _, err := client.AuthorizeURL(); err == nil {
+		t.Fatal("expected invalid server url error")
+	}
+	// the error of previous call must not stick
+	client.ServerURL = "https://as.example.com/authorize"
+	first, err := client.AuthorizeURL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := client.AuthorizeURL()
+	u, _ := url.Parse(second)
+	if first != second || len(u.Query()["display"]) != 1 {
+		t.Errorf("repeated calls should build the same url, got %s and %s", first, second)
+	}
+}
+
+func TestConfigInterceptors(t *testing.T) {
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.URL.Path+" "+r.Header.Get("User-Agent")+" "+r.Header.Get("X-Correlation-ID"))
+		w.Write([]byte(`{"access_token":"AT","token_type":"Bearer"}`))
+	}))
+	defer server.Close()
+
+	config := NewConfig(Endpoint{TokenURL: server.URL + "/token", RevokeURL: server.URL + "/revoke"}, "client",
+		ConfigWithSecret("secret"), ConfigWithInterceptors(utils.SetHeader("User-Agent", "app/1.0")))
+	ctx := context.Background()
+	correlation := AccessTokenWithInterceptors(utils.SetHeader("X-Correlation-ID", "c1"))
+	if _, err := config.Exchange(ctx, "code", correlation); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.Refresh(ctx, "RT"); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Revoke(ctx, "RT"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"/token app/1.0 c1", "/token app/1.0 ", "/revoke app/1.0 "}
+	if strings.Join(seen, ",") != strings.Join(want, ",") {
+		t.Errorf("unexpected requests %q", seen)
+	}
+
+	var denied = errors.New("denied")
+	abort := RefreshTokenWithInterceptors(func(req *http.Request) error { return denied })
+	if _, err := config.Refresh(ctx, "RT", abort); err != denied {
+		t.Errorf("interceptor error should abort the request, got %v", err)
+	}
+	if len(seen) != 3 {
+		t.Errorf("aborted request must not be sent")
+	}
+}
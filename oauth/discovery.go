@@ -0,0 +1,143 @@
+package oauth
+
+import (
+	"encoding/json"
+	"github.com/demo007x/oauth2-client/errorx"
+	"github.com/demo007x/oauth2-client/types"
+	"github.com/demo007x/oauth2-client/utils"
+	"net/http"
+	"net/url"
+)
+
+type (
+	// ProviderMetadata authorization server metadata. RFC 8414 and OpenID Connect Discovery
+	ProviderMetadata struct {
+		Issuer                                 string           `json:"issuer"`
+		AuthorizationEndpoint                  string           `json:"authorization_endpoint,omitempty"`
+		TokenEndpoint                          string           `json:"token_endpoint,omitempty"`
+		UserinfoEndpoint                       string           `json:"userinfo_endpoint,omitempty"`
+		JwksURI                                string           `json:"jwks_uri,omitempty"`
+		RegistrationEndpoint                   string           `json:"registration_endpoint,omitempty"`
+		RevocationEndpoint                     string           `json:"revocation_endpoint,omitempty"`
+		IntrospectionEndpoint                  string           `json:"introspection_endpoint,omitempty"`
+		PushedAuthorizationRequestEndpoint     string           `json:"pushed_authorization_request_endpoint,omitempty"`
+		DeviceAuthorizationEndpoint            string           `json:"device_authorization_endpoint,omitempty"`
+		ScopesSupported                        []string         `json:"scopes_supported,omitempty"`
+		ResponseTypesSupported                 []string         `json:"response_types_supported,omitempty"`
+		GrantTypesSupported                    []string         `json:"grant_types_supported,omitempty"`
+		TokenEndpointAuthMethodsSupported      []string         `json:"token_endpoint_auth_methods_supported,omitempty"`
+		CodeChallengeMethodsSupported          []string         `json:"code_challenge_methods_supported,omitempty"`
+		DPoPSigningAlgValuesSupported          []string         `json:"dpop_signing_alg_values_supported,omitempty"`
+		TLSClientCertificateBoundAccessTokens  bool             `json:"tls_client_certificate_bound_access_tokens,omitempty"`
+		RequirePushedAuthorizationRequests     bool             `json:"require_pushed_authorization_requests,omitempty"`
+		MTLSEndpointAliases                    *EndpointAliases `json:"mtls_endpoint_aliases,omitempty"`
+		RequestObjectSigningAlgValuesSupported []string         `json:"request_object_signing_alg_values_supported,omitempty"`
+	}
+
+	// EndpointAliases mutual tls endpoint aliases. RFC 8705 section 5
+	EndpointAliases struct {
+		TokenEndpoint                      string `json:"token_endpoint,omitempty"`
+		RevocationEndpoint                 string `json:"revocation_endpoint,omitempty"`
+		IntrospectionEndpoint              string `json:"introspection_endpoint,omitempty"`
+		UserinfoEndpoint                   string `json:"userinfo_endpoint,omitempty"`
+		PushedAuthorizationRequestEndpoint string `json:"pushed_authorization_request_endpoint,omitempty"`
+		DeviceAuthorizationEndpoint        string `json:"device_authorization_endpoint,omitempty"`
+		RegistrationEndpoint               string `json:"registration_endpoint,omitempty"`
+	}
+
+	DiscoveryOption func(d *Discovery)
+	// Discovery fetch authorization server metadata
+	Discovery struct {
+		ServerURL string
+
+		// internal field
+		handler types.OauthResponseHandler
+		retry   *RetryPolicy
+		err     error
+		// interceptors run on the built request before it is sent
+		interceptors []utils.RequestInterceptor
+		observer     Observer
+	}
+)
+
+func DiscoveryWithResponseHandler(handler types.OauthResponseHandler) DiscoveryOption {
+	return func(d *Discovery) {
+		d.handler = handler
+	}
+}
+
+// DiscoveryWithResponseMiddleware stack middlewares on the default response handler. eg: types.MaxBodySize(1<<20)
+func DiscoveryWithResponseMiddleware(middlewares ...types.ResponseMiddleware) DiscoveryOption {
+	return DiscoveryWithResponseHandler(types.ChainResponseHandler(middlewares...))
+}
+
+// DiscoveryWithRetryPolicy retry transient failures of the metadata request
+func DiscoveryWithRetryPolicy(policy *RetryPolicy) DiscoveryOption {
+	return func(d *Discovery) {
+		d.retry = policy
+	}
+}
+
+// DiscoveryWithInterceptors modify or audit the metadata request before it is sent
+func DiscoveryWithInterceptors(interceptors ...utils.RequestInterceptor) DiscoveryOption {
+	return func(d *Discovery) {
+		d.interceptors = append(d.interceptors, interceptors...)
+	}
+}
+
+// DiscoveryWithObserver report the metadata call to observer
+func DiscoveryWithObserver(observer Observer) DiscoveryOption {
+	return func(d *Discovery) {
+		d.observer = observer
+	}
+}
+
+func (d *Discovery) setServerURL() *Discovery {
+	if d.err == nil {
+		_, d.err = url.Parse(d.ServerURL)
+	}
+	return d
+}
+
+// DoRequest request the metadata document
+func (d *Discovery) DoRequest() ([]byte, error) {
+	if err := d.setServerURL().err; err != nil {
+		return nil, err
+	}
+	var handler = d.handler
+	if handler == nil {
+		handler = types.DefaultOauthResponseHandler
+	}
+	var c = &call{endpoint: EndpointDiscovery, retry: d.retry, observer: d.observer}
+	resp, data, err := c.send(func(trace ...utils.RequestOption) (*http.Response, error) {
+		var opts = append([]utils.RequestOption{utils.RequestWithInterceptors(d.interceptors...)}, trace...)
+		return utils.DoRequest(d.ServerURL, http.MethodGet, map[string]string{"Accept": "application/json"}, opts...)
+	}, handler)
+	if err != nil && resp == nil {
+		return nil, errorx.RequestServerURLError
+	}
+	return data, err
+}
+
+// Metadata request and decode the metadata document
+func (d *Discovery) Metadata() (*ProviderMetadata, error) {
+	data, err := d.DoRequest()
+	if err != nil {
+		return nil, err
+	}
+	var md = &ProviderMetadata{}
+	if err := json.Unmarshal(data, md); err != nil {
+		return nil, err
+	}
+	return md, nil
+}
+
+// NewDiscovery return Discovery for the metadata url.
+// eg: https://server.example.com/.well-known/oauth-authorization-server
+func NewDiscovery(serverURL string, opts ...DiscoveryOption) *Discovery {
+	var d = &Discovery{ServerURL: serverURL}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
@@ -0,0 +1,218 @@
+package oauth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"github.com/demo007x/oauth2-client/jose"
+	"github.com/demo007x/oauth2-client/utils"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	DPoPOption func(d *DPoP)
+	// DPoP proof generator bound to one client key pair. RFC 9449.
+	// A struct literal works as well, the public jwk is derived from Key on first use
+	DPoP struct {
+		Alg string
+		Key crypto.Signer
+
+		// internal field
+		jwk    *jose.JSONWebKey
+		mu     sync.Mutex
+		nonces map[string]string
+	}
+)
+
+// DPoPWithKey use the given key pair instead of a generated P-256 key
+func DPoPWithKey(alg string, key crypto.Signer) DPoPOption {
+	return func(d *DPoP) {
+		d.Alg, d.Key = alg, key
+	}
+}
+
+// WithDPoP bind the authorization code to the DPoP key with dpop_jkt
+func WithDPoP(d *DPoP) WithOption {
+	return func(client *Client) {
+		client.DPoP = d
+	}
+}
+
+func (client *Client) setDPoPThumbprint() *Client {
+	if client.err == nil && client.DPoP != nil {
+		thumbprint, err := client.DPoP.Thumbprint()
+		if err != nil {
+			client.err = err
+			return client
+		}
+		client.values.Set("dpop_jkt", thumbprint)
+	}
+	return client
+}
+
+// publicJWK jwk of the public key, derived once from Key
+func (d *DPoP) publicJWK() (*jose.JSONWebKey, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.jwk == nil {
+		if d.Key == nil {
+			return nil, jose.InvalidKeyError
+		}
+		jwk, err := jose.NewJSONWebKey(d.Key.Public())
+		if err != nil {
+			return nil, err
+		}
+		d.jwk = jwk
+	}
+	return d.jwk, nil
+}
+
+// Thumbprint jwk thumbprint of the public key, used as dpop_jkt
+func (d *DPoP) Thumbprint() (string, error) {
+	jwk, err := d.publicJWK()
+	if err != nil {
+		return "", err
+	}
+	return jwk.Thumbprint()
+}
+
+// Proof generate DPoP proof jwt for one request.
+// accessToken is hashed into ath when calling protected resource
+func (d *DPoP) Proof(method, requestURL, accessToken string) (string, error) {
+	jwk, err := d.publicJWK()
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(requestURL)
+	if err != nil {
+		return "", err
+	}
+	jti, err := utils.GenerateRandomString(16)
+	if err != nil {
+		return "", err
+	}
+
+	var claims = map[string]interface{}{
+		"jti": jti,
+		"htm": method,
+		"htu": htu(u),
+		"iat": time.Now().Unix(),
+	}
+	if accessToken != "" {
+		var sum = sha256.Sum256([]byte(accessToken))
+		claims["ath"] = jose.Encode(sum[:])
+	}
+	if nonce := d.nonce(u); nonce != "" {
+		claims["nonce"] = nonce
+	}
+
+	var header = map[string]interface{}{"typ": "dpop+jwt", "jwk": jwk}
+	return jose.Sign(d.Alg, d.Key, header, claims)
+}
+
+// htu is the request uri without query and fragment
+func htu(u *url.URL) string {
+	var target = *u
+	target.RawQuery, target.Fragment, target.RawFragment = "", "", ""
+	return target.String()
+}
+
+func (d *DPoP) nonce(u *url.URL) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.nonces[u.Scheme+"://"+u.Host]
+}
+
+func (d *DPoP) setNonce(u *url.URL, nonce string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.nonces == nil {
+		d.nonces = make(map[string]string)
+	}
+	d.nonces[u.Scheme+"://"+u.Host] = nonce
+}
+
+// do send the request with DPoP proof header.
+// Server provided nonce is remembered and the request is retried once on use_dpop_nonce
+func (d *DPoP) do(requestURL, method, accessToken string, header map[string]string, opts ...utils.RequestOption) (*http.Response, error) {
+	u, err := url.Parse(requestURL)
+	if err != nil {
+		return nil, err
+	}
+	// the caller's header is shared by the retries and concurrent requests, never write the proof into it
+	var h = make(map[string]string, len(header)+1)
+	for key, val := range header {
+		h[key] = val
+	}
+	var resp *http.Response
+	for attempt := 0; attempt < 2; attempt++ {
+		proof, err := d.Proof(method, requestURL, accessToken)
+		if err != nil {
+			return nil, err
+		}
+		h["DPoP"] = proof
+		resp, err = utils.DoRequest(requestURL, method, h, opts...)
+		if err != nil {
+			return nil, err
+		}
+		var nonce = resp.Header.Get("DPoP-Nonce")
+		if nonce == "" {
+			return resp, nil
+		}
+		d.setNonce(u, nonce)
+		if attempt > 0 || !useDPoPNonce(resp) {
+			return resp, nil
+		}
+		resp.Body.Close()
+	}
+	return resp, nil
+}
+
+// useDPoPNonce check token endpoint error body or resource server WWW-Authenticate header
+func useDPoPNonce(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return strings.Contains(resp.Header.Get("WWW-Authenticate"), "use_dpop_nonce")
+	case http.StatusBadRequest:
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		// keep the body readable for response handler
+		resp.Body = io.NopCloser(bytes.NewReader(data))
+		if err != nil {
+			return false
+		}
+		var body struct {
+			Error string `json:"error"`
+		}
+		return json.Unmarshal(data, &body) == nil && body.Error == "use_dpop_nonce"
+	}
+	return false
+}
+
+// NewDPoP return DPoP proof generator. A P-256 key pair is generated when no key is configured
+func NewDPoP(opts ...DPoPOption) (*DPoP, error) {
+	var d = &DPoP{}
+	for _, opt := range opts {
+		opt(d)
+	}
+	if d.Key == nil {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		d.Alg, d.Key = jose.ES256, key
+	}
+	if _, err := d.publicJWK(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
@@ -0,0 +1,129 @@
+package oauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/json"
+	"github.com/demo007x/oauth2-client/jose"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// verifyDPoPProof check the proof signature with the embedded jwk and return its claims
+func verifyDPoPProof(t *testing.T, proof string) (map[string]interface{}, string) {
+	parts := strings.Split(proof, ".")
+	if len(parts) != 3 {
+		t.Fatalf("malformed proof %s", proof)
+	}
+	headerJSON, _ := jose.Decode(parts[0])
+	var header struct {
+		Typ string          `json:"typ"`
+		JWK jose.JSONWebKey `json:"jwk"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil || header.Typ != "dpop+jwt" {
+		t.Fatalf("unexpected proof header %s", headerJSON)
+	}
+	pub, err := header.JWK.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, _ := jose.Decode(parts[2])
+	sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if !ecdsa.Verify(pub.(*ecdsa.PublicKey), sum[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])) {
+		t.Fatal("invalid proof signature")
+	}
+	payload, _ := jose.Decode(parts[1])
+	var claims map[string]interface{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatal(err)
+	}
+	thumbprint, _ := header.JWK.Thumbprint()
+	return claims, thumbprint
+}
+
+func TestDPoP(t *testing.T) {
+	dpop, err := NewDPoP()
+	if err != nil {
+		t.Fatal(err)
+	}
+	thumbprint, err := dpop.Thumbprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var server *httptest.Server
+	var tokenCalls int
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, jkt := verifyDPoPProof(t, r.Header.Get("DPoP"))
+		if jkt != thumbprint || claims["htm"] != r.Method || claims["htu"] != server.URL+r.URL.Path {
+			t.Errorf("unexpected proof claims %v", claims)
+		}
+		w.Header().Set("DPoP-Nonce", "server-nonce")
+		switch r.URL.Path {
+		case "/token":
+			tokenCalls++
+			if claims["nonce"] != "server-nonce" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"use_dpop_nonce"}`))
+				return
+			}
+			w.Write([]byte(`{"access_token":"at","token_type":"DPoP"}`))
+		case "/userinfo":
+			sum := sha256.Sum256([]byte("at"))
+			if r.Header.Get("Authorization") != "DPoP at" || claims["ath"] != jose.Encode(sum[:]) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"sub":"alice"}`))
+		}
+	}))
+	defer server.Close()
+
+	data, err := NewAccessToken(server.URL+"/token", "client", "secret", "code", AccessTokenWithDPoP(dpop)).DoRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tokenCalls != 2 || !strings.Contains(string(data), `"token_type":"DPoP"`) {
+		t.Errorf("expected retry with nonce, calls %d, body %s", tokenCalls, data)
+	}
+
+	data, err = NewUserInfo(server.URL+"/userinfo", "at", UserInfoWithDPoP(dpop)).DoRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"sub":"alice"}` {
+		t.Errorf("unexpected userinfo %s", data)
+	}
+	var header = map[string]string{"Authorization": "DPoP at"}
+	resp, err := dpop.do(server.URL+"/userinfo", http.MethodGet, "at", header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if _, ok := header["DPoP"]; ok || resp.StatusCode != http.StatusOK {
+		t.Errorf("proof written into the caller's header %v, status %d", header, resp.StatusCode)
+	}
+
+	authURL, err := NewOauth2Client("https://as.example.com/authorize", "client", WithDPoP(dpop)).AuthorizeURL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(authURL)
+	if u.Query().Get("dpop_jkt") != thumbprint {
+		t.Errorf("dpop_jkt not bound in %s", authURL)
+	}
+
+	// a struct literal derive the jwk and remember nonces on first use
+	literal := &DPoP{Alg: jose.ES256, Key: dpop.Key}
+	tokenCalls = 0
+	if _, err := NewAccessToken(server.URL+"/token", "client", "secret", "code", AccessTokenWithDPoP(literal)).DoRequest(); err != nil || tokenCalls != 2 {
+		t.Errorf("struct literal DPoP: calls %d, %v", tokenCalls, err)
+	}
+	if _, err := NewOauth2Client("https://as.example.com/authorize", "client", WithDPoP(&DPoP{})).AuthorizeURL(); err != jose.InvalidKeyError {
+		t.Errorf("expected invalid key without key pair, got %v", err)
+	}
+}
@@ -0,0 +1,262 @@
+package oauth
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/demo007x/oauth2-client/errorx"
+	"github.com/demo007x/oauth2-client/jose"
+	"github.com/demo007x/oauth2-client/types"
+	"github.com/demo007x/oauth2-client/utils"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type (
+	RemoteKeySetOption func(s *RemoteKeySet)
+	// RemoteKeySet json web key set fetched from jwks_uri.
+	// The set is fetched again when a token is signed by an unknown kid, so key rollover is picked up
+	RemoteKeySet struct {
+		URL string
+		// MinRefreshInterval limit how often unknown kids trigger a fetch
+		MinRefreshInterval time.Duration
+
+		// internal field
+		handler      types.OauthResponseHandler
+		retry        *RetryPolicy
+		interceptors []utils.RequestInterceptor
+		observer     Observer
+		mu           sync.Mutex
+		keys         *jose.JSONWebKeySet
+		// fetchedAt time of the last fetch, failed ones included so they are throttled as well
+		fetchedAt time.Time
+		fetchErr  error
+		inflight  *keySetFetch
+	}
+
+	// keySetFetch jwks request shared by the concurrent callers
+	keySetFetch struct {
+		done chan struct{}
+		err  error
+	}
+
+	IDTokenVerifierOption func(v *IDTokenVerifier)
+	// IDTokenVerifier verify signature and claims of OpenID Connect id tokens
+	IDTokenVerifier struct {
+		Issuer   string
+		ClientID string
+		KeySet   *RemoteKeySet
+		// Algorithms accepted signing algorithms, RS256 by default
+		Algorithms []string
+		// Leeway tolerated clock skew
+		Leeway time.Duration
+	}
+
+	// IDToken verified id token claims
+	IDToken struct {
+		jose.Claims
+		Nonce    string `json:"nonce,omitempty"`
+		AuthTime int64  `json:"auth_time,omitempty"`
+		// Raw all claims, include provider specific ones
+		Raw map[string]interface{} `json:"-"`
+	}
+)
+
+// RemoteKeySetWithResponseHandler custom jwks response handler
+func RemoteKeySetWithResponseHandler(handler types.OauthResponseHandler) RemoteKeySetOption {
+	return func(s *RemoteKeySet) {
+		s.handler = handler
+	}
+}
+
+// RemoteKeySetWithResponseMiddleware stack middlewares on the default response handler. eg: types.MaxBodySize(1<<20)
+func RemoteKeySetWithResponseMiddleware(middlewares ...types.ResponseMiddleware) RemoteKeySetOption {
+	return RemoteKeySetWithResponseHandler(types.ChainResponseHandler(middlewares...))
+}
+
+// RemoteKeySetWithRetryPolicy retry transient failures of the jwks request
+func RemoteKeySetWithRetryPolicy(policy *RetryPolicy) RemoteKeySetOption {
+	return func(s *RemoteKeySet) {
+		s.retry = policy
+	}
+}
+
+// RemoteKeySetWithInterceptors modify or audit the jwks request before it is sent
+func RemoteKeySetWithInterceptors(interceptors ...utils.RequestInterceptor) RemoteKeySetOption {
+	return func(s *RemoteKeySet) {
+		s.interceptors = append(s.interceptors, interceptors...)
+	}
+}
+
+// RemoteKeySetWithObserver report the jwks calls to observer
+func RemoteKeySetWithObserver(observer Observer) RemoteKeySetOption {
+	return func(s *RemoteKeySet) {
+		s.observer = observer
+	}
+}
+
+// Key find the signing key by kid, fetch the key set again if kid is unknown.
+// Empty kid match the only key of the set. Concurrent callers share one fetch
+func (s *RemoteKeySet) Key(kid string) (*jose.JSONWebKey, error) {
+	s.mu.Lock()
+	if key, ok := s.lookup(kid); ok {
+		s.mu.Unlock()
+		return key, nil
+	}
+	if s.inflight == nil && !s.fetchedAt.IsZero() && time.Since(s.fetchedAt) < s.MinRefreshInterval {
+		var err = s.fetchErr
+		s.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		return nil, errorx.SigningKeyNotFoundError
+	}
+	var f = s.inflight
+	if f == nil {
+		f = &keySetFetch{done: make(chan struct{})}
+		s.inflight = f
+		s.mu.Unlock()
+		keys, err := s.fetch()
+		s.mu.Lock()
+		if err == nil {
+			s.keys = keys
+		}
+		s.fetchedAt, s.fetchErr, s.inflight = time.Now(), err, nil
+		f.err = err
+		close(f.done)
+	} else {
+		s.mu.Unlock()
+		<-f.done
+		s.mu.Lock()
+	}
+	defer s.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	if key, ok := s.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, errorx.SigningKeyNotFoundError
+}
+
+func (s *RemoteKeySet) lookup(kid string) (*jose.JSONWebKey, bool) {
+	if s.keys == nil {
+		return nil, false
+	}
+	if kid == "" && len(s.keys.Keys) == 1 {
+		return &s.keys.Keys[0], true
+	}
+	return s.keys.Key(kid)
+}
+
+// fetch request the key set, it runs without s.mu held
+func (s *RemoteKeySet) fetch() (*jose.JSONWebKeySet, error) {
+	var handler = s.handler
+	if handler == nil {
+		handler = types.DefaultOauthResponseHandler
+	}
+	var c = &call{endpoint: EndpointJWKS, retry: s.retry, observer: s.observer}
+	resp, data, err := c.send(func(trace ...utils.RequestOption) (*http.Response, error) {
+		var opts = append([]utils.RequestOption{utils.RequestWithInterceptors(s.interceptors...)}, trace...)
+		return utils.DoRequest(s.URL, http.MethodGet, map[string]string{"Accept": "application/json"}, opts...)
+	}, handler)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errorx.ParseOauthError(resp.StatusCode, data)
+	}
+	var keys = &jose.JSONWebKeySet{}
+	if err := json.Unmarshal(data, keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// NewRemoteKeySet return key set of jwks uri. Unknown kids fetch at most once a minute
+func NewRemoteKeySet(jwksURI string, opts ...RemoteKeySetOption) *RemoteKeySet {
+	var s = &RemoteKeySet{URL: jwksURI, MinRefreshInterval: time.Minute}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// IDTokenVerifierWithAlgorithms set accepted signing algorithms
+func IDTokenVerifierWithAlgorithms(algs ...string) IDTokenVerifierOption {
+	return func(v *IDTokenVerifier) {
+		v.Algorithms = algs
+	}
+}
+
+// IDTokenVerifierWithLeeway set tolerated clock skew of exp, nbf and iat
+func IDTokenVerifierWithLeeway(leeway time.Duration) IDTokenVerifierOption {
+	return func(v *IDTokenVerifier) {
+		v.Leeway = leeway
+	}
+}
+
+// Verify check signature, iss, aud, exp and nonce of the id token.
+// Empty nonce is not checked
+func (v *IDTokenVerifier) Verify(rawIDToken, nonce string) (*IDToken, error) {
+	if v.Issuer == "" || v.ClientID == "" || v.KeySet == nil {
+		return nil, errorx.VerifierConfigError
+	}
+	jws, err := jose.Parse(rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+	var accepted bool
+	for _, alg := range v.Algorithms {
+		accepted = accepted || alg == jws.Algorithm()
+	}
+	if !accepted {
+		return nil, jose.UnsupportedAlgorithmError
+	}
+	key, err := v.KeySet.Key(jws.KeyID())
+	if err != nil {
+		return nil, err
+	}
+	if err := jws.Verify(key); err != nil {
+		return nil, err
+	}
+
+	var token = &IDToken{}
+	if err := jws.Claims(token); err != nil {
+		return nil, err
+	}
+	var decoder = json.NewDecoder(bytes.NewReader(jws.Payload))
+	decoder.UseNumber()
+	if err := decoder.Decode(&token.Raw); err != nil {
+		return nil, err
+	}
+	// exp, iat and sub are required. OIDC Core section 2
+	if token.Expiry == 0 || token.IssuedAt == 0 || token.Subject == "" {
+		return nil, jose.MissingClaimError
+	}
+	if err := token.Validate(jose.Expected{Issuer: v.Issuer, Audience: v.ClientID, Leeway: v.Leeway}); err != nil {
+		return nil, err
+	}
+	if nonce != "" && token.Nonce != nonce {
+		return nil, errorx.NonceMismatchError
+	}
+	return token, nil
+}
+
+// NewIDTokenVerifier return verifier of id tokens issued by issuer for client id.
+// All are required, the iss and aud checks are never skipped
+func NewIDTokenVerifier(issuer, clientID string, keySet *RemoteKeySet, opts ...IDTokenVerifierOption) (*IDTokenVerifier, error) {
+	if issuer == "" || clientID == "" || keySet == nil {
+		return nil, errorx.VerifierConfigError
+	}
+	var v = &IDTokenVerifier{
+		Issuer:     issuer,
+		ClientID:   clientID,
+		KeySet:     keySet,
+		Algorithms: []string{jose.RS256},
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v, nil
+}
@@ -0,0 +1,168 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"github.com/demo007x/oauth2-client/errorx"
+	"github.com/demo007x/oauth2-client/jose"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIDTokenVerifier(t *testing.T) {
+	var keys = map[string]*rsa.PrivateKey{}
+	var published = []string{"k1"}
+	for _, kid := range []string{"k1", "k2"} {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[kid] = key
+	}
+	var fetches int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		var set = jose.JSONWebKeySet{}
+		for _, kid := range published {
+			jwk, _ := jose.NewJSONWebKey(&keys[kid].PublicKey)
+			jwk.Kid, jwk.Use = kid, "sig"
+			set.Keys = append(set.Keys, *jwk)
+		}
+		json.NewEncoder(w).Encode(set)
+	}))
+	defer server.Close()
+
+	var sign = func(kid string, claims map[string]interface{}) string {
+		var c = map[string]interface{}{
+			"iss":   "https://as.example.com",
+			"aud":   "client",
+			"sub":   "user",
+			"nonce": "n-0S6",
+			"iat":   time.Now().Unix(),
+			"exp":   time.Now().Add(time.Hour).Unix(),
+		}
+		for k, v := range claims {
+			c[k] = v
+		}
+		token, err := jose.Sign(jose.RS256, keys[kid], map[string]interface{}{"kid": kid}, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	keySet := NewRemoteKeySet(server.URL)
+	verifier, err := NewIDTokenVerifier("https://as.example.com", "client", keySet, IDTokenVerifierWithLeeway(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := verifier.Verify(sign("k1", map[string]interface{}{"email": "u@example.com"}), "n-0S6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Subject != "user" || token.Raw["email"] != "u@example.com" {
+		t.Errorf("unexpected id token %+v", token)
+	}
+
+	cases := map[string]struct {
+		token string
+		nonce string
+		err   error
+	}{
+		"nonce":    {sign("k1", nil), "other", errorx.NonceMismatchError},
+		"audience": {sign("k1", map[string]interface{}{"aud": "other"}), "", jose.InvalidAudienceError},
+		"issuer":   {sign("k1", map[string]interface{}{"iss": "https://evil.example.com"}), "", jose.InvalidIssuerError},
+		"expired":  {sign("k1", map[string]interface{}{"exp": time.Now().Add(-2 * time.Minute).Unix()}), "", jose.TokenExpiredError},
+		"unknown":  {sign("k2", nil), "", errorx.SigningKeyNotFoundError},
+		"no exp":   {sign("k1", map[string]interface{}{"exp": nil}), "", jose.MissingClaimError},
+		"no iat":   {sign("k1", map[string]interface{}{"iat": nil}), "", jose.MissingClaimError},
+		"no sub":   {sign("k1", map[string]interface{}{"sub": nil}), "", jose.MissingClaimError},
+	}
+	for name, c := range cases {
+		if _, err := verifier.Verify(c.token, c.nonce); err != c.err {
+			t.Errorf("%s: expected %v, got %v", name, c.err, err)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("unknown kid should not refetch within min refresh interval, fetched %d times", fetches)
+	}
+
+	// key rollover
+	published = []string{"k1", "k2"}
+	keySet.MinRefreshInterval = 0
+	if _, err := verifier.Verify(sign("k2", nil), ""); err != nil {
+		t.Errorf("rotated key should be fetched, got %v", err)
+	}
+
+	if _, err := NewIDTokenVerifier("", "client", keySet); err != errorx.VerifierConfigError {
+		t.Errorf("expected verifier config error without issuer, got %v", err)
+	}
+	if _, err := (&IDTokenVerifier{Issuer: "https://as.example.com", KeySet: keySet}).Verify(sign("k1", nil), ""); err != errorx.VerifierConfigError {
+		t.Errorf("expected verifier config error without client id, got %v", err)
+	}
+	if _, err := NewIDTokenVerifier("https://as.example.com", "client", nil); err != errorx.VerifierConfigError {
+		t.Errorf("expected verifier config error without key set, got %v", err)
+	}
+
+	hs, _ := jose.Sign(jose.HS256, []byte("secret"), map[string]interface{}{"kid": "k1"}, map[string]interface{}{"sub": "user"})
+	if _, err := verifier.Verify(hs, ""); err != jose.UnsupportedAlgorithmError {
+		t.Errorf("expected unsupported algorithm, got %v", err)
+	}
+}
+
+func TestRemoteKeySetFetch(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fetches int32
+	var fail atomic.Value
+	fail.Store(false)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		if fail.Load().(bool) {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+		jwk, _ := jose.NewJSONWebKey(&key.PublicKey)
+		jwk.Kid = "k1"
+		json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{*jwk}})
+	}))
+	defer server.Close()
+
+	// concurrent callers share one fetch
+	keySet := NewRemoteKeySet(server.URL)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := keySet.Key("k1"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Errorf("expected one shared fetch, fetched %d times", n)
+	}
+
+	// failed fetches are throttled as well
+	fail.Store(true)
+	atomic.StoreInt32(&fetches, 0)
+	failing := NewRemoteKeySet(server.URL)
+	for i := 0; i < 3; i++ {
+		if _, err := failing.Key("k1"); err == nil {
+			t.Error("expected fetch error")
+		}
+	}
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Errorf("failed fetch should be throttled, fetched %d times", n)
+	}
+}
@@ -0,0 +1,204 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/demo007x/oauth2-client/errorx"
+	"github.com/demo007x/oauth2-client/types"
+	"github.com/demo007x/oauth2-client/utils"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type (
+	IntrospectTokenOption func(token *IntrospectToken)
+	// IntrospectToken query token state from introspection endpoint. RFC 7662
+	IntrospectToken struct {
+		ServerURL     string
+		ClientID      string
+		Secret        string
+		Token         string
+		TokenTypeHint string
+		// AuthMethod client_secret_basic by default
+		AuthMethod string
+
+		// internal field
+		header       map[string]string
+		interceptors []utils.RequestInterceptor
+		observer     Observer
+		handler      types.OauthResponseHandler
+		mtls         *MutualTLS
+		retry        *RetryPolicy
+		ctx          context.Context
+	}
+
+	// introspection params of introspection request. RFC 7662 section 2.1
+	introspection struct {
+		token         string
+		tokenTypeHint string
+	}
+
+	// Introspection introspection response
+	Introspection struct {
+		Active    bool        `json:"active"`
+		Scope     string      `json:"scope,omitempty"`
+		ClientID  string      `json:"client_id,omitempty"`
+		Username  string      `json:"username,omitempty"`
+		TokenType string      `json:"token_type,omitempty"`
+		Exp       int64       `json:"exp,omitempty"`
+		Iat       int64       `json:"iat,omitempty"`
+		Nbf       int64       `json:"nbf,omitempty"`
+		Sub       string      `json:"sub,omitempty"`
+		Aud       interface{} `json:"aud,omitempty"`
+		Iss       string      `json:"iss,omitempty"`
+		Jti       string      `json:"jti,omitempty"`
+		// Cnf confirmation of sender constrained token. x5t#S256 or jkt
+		Cnf map[string]string `json:"cnf,omitempty"`
+	}
+)
+
+func (i *introspection) GrantType() string {
+	return ""
+}
+
+func (i *introspection) Params() (url.Values, error) {
+	if strings.TrimSpace(i.token) == "" {
+		return nil, errorx.TokenEmptyError
+	}
+	var values = url.Values{"token": {i.token}}
+	if strings.TrimSpace(i.tokenTypeHint) != "" {
+		values.Set("token_type_hint", i.tokenTypeHint)
+	}
+	return values, nil
+}
+
+func IntrospectTokenWithServerURL(serverURL string) IntrospectTokenOption {
+	return func(token *IntrospectToken) {
+		token.ServerURL = serverURL
+	}
+}
+
+func IntrospectTokenWithKeyAndSecret(clientID, secret string) IntrospectTokenOption {
+	return func(token *IntrospectToken) {
+		token.ClientID = clientID
+		token.Secret = secret
+	}
+}
+
+func IntrospectTokenWithToken(t string) IntrospectTokenOption {
+	return func(token *IntrospectToken) {
+		token.Token = t
+	}
+}
+
+func IntrospectTokenWithTokenTypeHint(tokenTypeHint string) IntrospectTokenOption {
+	return func(token *IntrospectToken) {
+		token.TokenTypeHint = tokenTypeHint
+	}
+}
+
+func IntrospectTokenWithResponseHandler(handler types.OauthResponseHandler) IntrospectTokenOption {
+	return func(token *IntrospectToken) {
+		token.handler = handler
+	}
+}
+
+// IntrospectTokenWithResponseMiddleware stack middlewares on the default response handler. eg: types.CheckStatus(nil)
+func IntrospectTokenWithResponseMiddleware(middlewares ...types.ResponseMiddleware) IntrospectTokenOption {
+	return IntrospectTokenWithResponseHandler(types.ChainResponseHandler(middlewares...))
+}
+
+// IntrospectTokenWithAuthMethod set client authentication method. eg: client_secret_post
+func IntrospectTokenWithAuthMethod(authMethod string) IntrospectTokenOption {
+	return func(token *IntrospectToken) {
+		token.AuthMethod = authMethod
+	}
+}
+
+// IntrospectTokenWithContext send the introspection request with context
+func IntrospectTokenWithContext(ctx context.Context) IntrospectTokenOption {
+	return func(token *IntrospectToken) {
+		token.ctx = ctx
+	}
+}
+
+// IntrospectTokenWithMutualTLS authenticate with client certificate
+func IntrospectTokenWithMutualTLS(m *MutualTLS) IntrospectTokenOption {
+	return func(token *IntrospectToken) {
+		token.mtls = m
+	}
+}
+
+// IntrospectTokenWithRetryPolicy retry transient failures of the introspection request
+func IntrospectTokenWithRetryPolicy(policy *RetryPolicy) IntrospectTokenOption {
+	return func(token *IntrospectToken) {
+		token.retry = policy
+	}
+}
+
+// IntrospectTokenWithInterceptors modify or audit the request before it is sent. eg: custom headers, signing
+func IntrospectTokenWithInterceptors(interceptors ...utils.RequestInterceptor) IntrospectTokenOption {
+	return func(token *IntrospectToken) {
+		token.interceptors = append(token.interceptors, interceptors...)
+	}
+}
+
+// IntrospectTokenWithObserver report the call to observer. eg: PrometheusCollector
+func IntrospectTokenWithObserver(observer Observer) IntrospectTokenOption {
+	return func(token *IntrospectToken) {
+		token.observer = observer
+	}
+}
+
+// endpoint token endpoint of the request
+func (it *IntrospectToken) endpoint() *TokenEndpoint {
+	return &TokenEndpoint{
+		ServerURL:    it.ServerURL,
+		ClientID:     it.ClientID,
+		Secret:       it.Secret,
+		AuthMethod:   it.AuthMethod,
+		Header:       it.header,
+		handler:      rawResponseHandler(it.handler),
+		ctx:          it.ctx,
+		mtls:         it.mtls,
+		retry:        it.retry,
+		interceptors: it.interceptors,
+		observer:     it.observer,
+		kind:         EndpointIntrospection,
+	}
+}
+
+// DoRequest post the token to introspection endpoint
+func (it *IntrospectToken) DoRequest() ([]byte, error) {
+	return it.endpoint().Do(&introspection{token: it.Token, tokenTypeHint: it.TokenTypeHint})
+}
+
+// Introspect post the token and decode the introspection response
+func (it *IntrospectToken) Introspect() (*Introspection, error) {
+	data, err := it.DoRequest()
+	if err != nil {
+		return nil, err
+	}
+	if oe := errorx.ParseOauthError(http.StatusOK, data); oe.Code != "" {
+		return nil, oe
+	}
+	var introspection = &Introspection{}
+	if err := json.Unmarshal(data, introspection); err != nil {
+		return nil, err
+	}
+	return introspection, nil
+}
+
+func NewIntrospectToken(serverURL, key, secret, token string, opts ...IntrospectTokenOption) *IntrospectToken {
+	var it = &IntrospectToken{
+		header: map[string]string{
+			"Accept": "application/json",
+		},
+	}
+	opts = append(opts, IntrospectTokenWithServerURL(serverURL), IntrospectTokenWithKeyAndSecret(key, secret), IntrospectTokenWithToken(token))
+	for _, opt := range opts {
+		opt(it)
+	}
+	return it
+}
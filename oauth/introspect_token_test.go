@@ -0,0 +1,59 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"github.com/demo007x/oauth2-client/errorx"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewIntrospectToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.PostFormValue("client_id") == "client" && r.PostFormValue("client_secret") == "secret" {
+			w.Write([]byte(`{"active":true,"client_id":"post"}`))
+			return
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "client" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		if r.PostFormValue("token") == "at" && r.PostFormValue("token_type_hint") == "access_token" {
+			w.Write([]byte(`{"active":true,"scope":"read","client_id":"client","exp":1900000000}`))
+			return
+		}
+		w.Write([]byte(`{"active":false}`))
+	}))
+	defer server.Close()
+
+	introspection, err := NewIntrospectToken(server.URL, "client", "secret", "at", IntrospectTokenWithTokenTypeHint("access_token")).Introspect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !introspection.Active || introspection.Scope != "read" || introspection.Exp != 1900000000 {
+		t.Errorf("unexpected introspection %+v", introspection)
+	}
+
+	introspection, err = NewIntrospectToken(server.URL, "client", "secret", "unknown").Introspect()
+	if err != nil || introspection.Active {
+		t.Errorf("expected inactive token, got %+v %v", introspection, err)
+	}
+
+	_, err = NewIntrospectToken(server.URL, "client", "wrong", "at").Introspect()
+	if oe, ok := err.(*errorx.OauthError); !ok || oe.Code != "invalid_client" {
+		t.Errorf("expected invalid_client, got %v", err)
+	}
+
+	introspection, err = NewIntrospectToken(server.URL, "client", "secret", "at", IntrospectTokenWithAuthMethod(AuthMethodClientSecretPost)).Introspect()
+	if err != nil || introspection.ClientID != "post" {
+		t.Errorf("expected client_secret_post, got %+v %v", introspection, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewIntrospectToken(server.URL, "client", "secret", "at", IntrospectTokenWithContext(ctx)).DoRequest(); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected canceled request, got %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package oauth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"github.com/demo007x/oauth2-client/utils"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+type (
+	MutualTLSOption func(m *MutualTLS)
+	// MutualTLS client certificate authentication and certificate bound tokens. RFC 8705
+	MutualTLS struct {
+		Certificate tls.Certificate
+		RootCAs     *x509.CertPool
+		// AuthMethod tls_client_auth by default.
+		// Set client_secret_basic to only bind tokens to the certificate
+		AuthMethod string
+		// Metadata requests to the standard endpoints use mtls_endpoint_aliases
+		Metadata *ProviderMetadata
+
+		// internal field
+		once   sync.Once
+		client *http.Client
+	}
+)
+
+// MutualTLSWithRootCAs trust the given authorization server certificates
+func MutualTLSWithRootCAs(pool *x509.CertPool) MutualTLSOption {
+	return func(m *MutualTLS) {
+		m.RootCAs = pool
+	}
+}
+
+// MutualTLSWithAuthMethod set token endpoint auth method
+func MutualTLSWithAuthMethod(authMethod string) MutualTLSOption {
+	return func(m *MutualTLS) {
+		m.AuthMethod = authMethod
+	}
+}
+
+// MutualTLSWithMetadata use the server mtls endpoint aliases automatically
+func MutualTLSWithMetadata(md *ProviderMetadata) MutualTLSOption {
+	return func(m *MutualTLS) {
+		m.Metadata = md
+	}
+}
+
+// HTTPClient http client present the client certificate, built on first use
+func (m *MutualTLS) HTTPClient() *http.Client {
+	m.once.Do(func() {
+		m.client = &http.Client{
+			Transport: &http.Transport{
+				Proxy: http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{
+					Certificates: []tls.Certificate{m.Certificate},
+					RootCAs:      m.RootCAs,
+				},
+			},
+		}
+	})
+	return m.client
+}
+
+// requestOptions nil safe http client option
+func (m *MutualTLS) requestOptions() []utils.RequestOption {
+	if m == nil {
+		return nil
+	}
+	return []utils.RequestOption{utils.RequestWithHTTPClient(m.HTTPClient())}
+}
+
+// clientAuth whether client authenticate with certificate instead of secret
+func (m *MutualTLS) clientAuth() bool {
+	return m != nil && (m.AuthMethod == AuthMethodTLSClientAuth || m.AuthMethod == AuthMethodSelfSignedTLSClientAuth)
+}
+
+// endpoint replace the standard endpoint with its mtls alias
+func (m *MutualTLS) endpoint(serverURL string) string {
+	if m == nil || m.Metadata == nil || m.Metadata.MTLSEndpointAliases == nil {
+		return serverURL
+	}
+	var md, aliases = m.Metadata, m.Metadata.MTLSEndpointAliases
+	var pairs = [][2]string{
+		{md.TokenEndpoint, aliases.TokenEndpoint},
+		{md.RevocationEndpoint, aliases.RevocationEndpoint},
+		{md.IntrospectionEndpoint, aliases.IntrospectionEndpoint},
+		{md.UserinfoEndpoint, aliases.UserinfoEndpoint},
+		{md.PushedAuthorizationRequestEndpoint, aliases.PushedAuthorizationRequestEndpoint},
+		{md.DeviceAuthorizationEndpoint, aliases.DeviceAuthorizationEndpoint},
+		{md.RegistrationEndpoint, aliases.RegistrationEndpoint},
+	}
+	for _, pair := range pairs {
+		if pair[0] != "" && pair[1] != "" && strings.TrimSpace(serverURL) == pair[0] {
+			return pair[1]
+		}
+	}
+	return serverURL
+}
+
+// NewMutualTLS return MutualTLS with the client certificate
+func NewMutualTLS(cert tls.Certificate, opts ...MutualTLSOption) *MutualTLS {
+	var m = &MutualTLS{Certificate: cert, AuthMethod: AuthMethodTLSClientAuth}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// NewMutualTLSFromFile load pem encoded client certificate and key
+func NewMutualTLSFromFile(certFile, keyFile string, opts ...MutualTLSOption) (*MutualTLS, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	return NewMutualTLS(cert, opts...), nil
+}
@@ -0,0 +1,129 @@
+package oauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestClientCertificate(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestMutualTLS(t *testing.T) {
+	tlsServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 || r.TLS.PeerCertificates[0].Subject.CommonName != "client" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/mtls/userinfo" {
+			w.Write([]byte(`{"sub":"user"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("%s should not send client secret", r.URL.Path)
+		}
+		if err := r.ParseForm(); err != nil || r.Form.Get("client_id") != "client" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/mtls/token":
+			w.Write([]byte(`{"access_token":"at","token_type":"Bearer"}`))
+		case "/mtls/introspect":
+			w.Write([]byte(`{"active":true,"cnf":{"x5t#S256":"bwcK0esc3ACC3DB2Y5_lESsXE8o9ltc05O89jdN-dg2"}}`))
+		case "/mtls/revoke":
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	tlsServer.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	tlsServer.StartTLS()
+	defer tlsServer.Close()
+
+	metadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+			"issuer": "https://as.example.com",
+			"token_endpoint": "https://as.example.com/token",
+			"introspection_endpoint": "https://as.example.com/introspect",
+			"revocation_endpoint": "https://as.example.com/revoke",
+			"userinfo_endpoint": "https://as.example.com/userinfo",
+			"tls_client_certificate_bound_access_tokens": true,
+			"mtls_endpoint_aliases": {
+				"token_endpoint": "` + tlsServer.URL + `/mtls/token",
+				"introspection_endpoint": "` + tlsServer.URL + `/mtls/introspect",
+				"revocation_endpoint": "` + tlsServer.URL + `/mtls/revoke",
+				"userinfo_endpoint": "` + tlsServer.URL + `/mtls/userinfo"
+			}
+		}`))
+	}))
+	defer metadata.Close()
+
+	md, err := NewDiscovery(metadata.URL + "/.well-known/oauth-authorization-server").Metadata()
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(tlsServer.Certificate())
+	mtls := NewMutualTLS(newTestClientCertificate(t), MutualTLSWithRootCAs(roots), MutualTLSWithMetadata(md))
+
+	data, err := NewAccessToken(md.TokenEndpoint, "client", "", "code", AccessTokenWithMutualTLS(mtls)).DoRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"access_token":"at","token_type":"Bearer"}` {
+		t.Errorf("unexpected token response %s", data)
+	}
+
+	introspection, err := NewIntrospectToken(md.IntrospectionEndpoint, "client", "", "at", IntrospectTokenWithMutualTLS(mtls)).Introspect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !introspection.Active || introspection.Cnf["x5t#S256"] == "" {
+		t.Errorf("unexpected introspection %+v", introspection)
+	}
+
+	if _, err := NewOauthRevokeToken(md.RevocationEndpoint, "client", "", "at", RevokeTokenWithMutualTLS(mtls)).DoRequest(); err != nil {
+		t.Error(err)
+	}
+
+	// the alias is resolved per request, the configured url is kept
+	info := NewUserInfo(md.UserinfoEndpoint, "at", UserInfoWithMutualTLS(mtls))
+	if data, err := info.DoRequest(); err != nil || string(data) != `{"sub":"user"}` || info.ServerURL != md.UserinfoEndpoint {
+		t.Errorf("unexpected userinfo %s %v, server url %s", data, err, info.ServerURL)
+	}
+
+	// a struct literal build its http client on first use
+	literal := &MutualTLS{Certificate: newTestClientCertificate(t), RootCAs: roots, AuthMethod: AuthMethodTLSClientAuth}
+	if _, err := NewOauthRevokeToken(tlsServer.URL+"/mtls/revoke", "client", "", "at", RevokeTokenWithMutualTLS(literal)).DoRequest(); err != nil {
+		t.Errorf("struct literal MutualTLS: %v", err)
+	}
+
+	// without client certificate the handshake fails
+	if _, err := NewAccessToken(tlsServer.URL+"/mtls/token", "client", "secret", "code").DoRequest(); err == nil {
+		t.Error("expected tls error without client certificate")
+	}
+}
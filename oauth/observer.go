@@ -0,0 +1,190 @@
+package oauth
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"github.com/demo007x/oauth2-client/errorx"
+	"github.com/demo007x/oauth2-client/types"
+	"github.com/demo007x/oauth2-client/utils"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Endpoint kinds of observed calls
+const (
+	EndpointToken               = "token"
+	EndpointRevocation          = "revocation"
+	EndpointIntrospection       = "introspection"
+	EndpointUserInfo            = "userinfo"
+	EndpointDiscovery           = "discovery"
+	EndpointJWKS                = "jwks"
+	EndpointRegistration        = "registration"
+	EndpointPushedAuthorization = "pushed_authorization"
+	// EndpointAPI provider specific api. eg: APIRequest
+	EndpointAPI = "api"
+)
+
+type (
+	// Timing httptrace timings of the last attempt. Zero when the connection is reused
+	Timing struct {
+		DNS     time.Duration
+		Connect time.Duration
+		TLS     time.Duration
+		// TTFB time from sending the request to the first response byte
+		TTFB time.Duration
+	}
+
+	// Observation one finished call to the oauth server, retries included
+	Observation struct {
+		// Endpoint kind of endpoint. eg: EndpointToken
+		Endpoint string
+		// GrantType grant_type of token requests, empty for other endpoints
+		GrantType string
+		// StatusCode http status of the last attempt, zero when no response
+		StatusCode int
+		// ErrorCode oauth error code of error response. eg: invalid_grant
+		ErrorCode string
+		Err       error
+		Latency   time.Duration
+		// Retries attempts after the first one
+		Retries int
+		Timing  Timing
+	}
+
+	// Observer receive every finished call. eg: metrics, tracing.
+	// It is called from the goroutine of the call and must be safe for concurrent use
+	Observer interface {
+		Observe(o *Observation)
+	}
+
+	// ObserverFunc adapt a function to Observer
+	ObserverFunc func(o *Observation)
+
+	// call shared request path of the endpoints: retry, tracing and observation
+	call struct {
+		ctx       context.Context
+		endpoint  string
+		grantType string
+		retry     *RetryPolicy
+		// dialOnly retry only dial failures, for requests not safe to replay
+		dialOnly bool
+		observer Observer
+	}
+
+	// tracer collect httptrace timings, the callbacks may run on other goroutines
+	tracer struct {
+		mu                            sync.Mutex
+		start, dns, connect, tlsStart time.Time
+		timing                        Timing
+	}
+)
+
+func (f ObserverFunc) Observe(o *Observation) {
+	f(o)
+}
+
+func (t *tracer) trace() *httptrace.ClientTrace {
+	var set = func(fn func()) {
+		t.mu.Lock()
+		fn()
+		t.mu.Unlock()
+	}
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			set(func() { t.dns = time.Now() })
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			set(func() { t.timing.DNS = time.Since(t.dns) })
+		},
+		ConnectStart: func(string, string) {
+			set(func() { t.connect = time.Now() })
+		},
+		ConnectDone: func(string, string, error) {
+			set(func() { t.timing.Connect = time.Since(t.connect) })
+		},
+		TLSHandshakeStart: func() {
+			set(func() { t.tlsStart = time.Now() })
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			set(func() { t.timing.TLS = time.Since(t.tlsStart) })
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			set(func() { t.start = time.Now() })
+		},
+		GotFirstResponseByte: func() {
+			set(func() { t.timing.TTFB = time.Since(t.start) })
+		},
+	}
+}
+
+func (t *tracer) result() Timing {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.timing
+}
+
+// send the request with retry and run the handler on the response.
+// A nil handler keep the response body for the caller, only error bodies are read for the error code
+func (c *call) send(do func(opts ...utils.RequestOption) (*http.Response, error), handler types.OauthResponseHandler) (*http.Response, []byte, error) {
+	var ctx = c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if c.observer == nil {
+		resp, err := c.retry.do(ctx, c.dialOnly, func() (*http.Response, error) {
+			return do()
+		})
+		if err != nil || handler == nil {
+			return resp, nil, err
+		}
+		data, err := handler(resp)
+		return resp, data, err
+	}
+
+	var start = time.Now()
+	var attempts int
+	var t *tracer
+	resp, err := c.retry.do(ctx, c.dialOnly, func() (*http.Response, error) {
+		attempts++
+		t = &tracer{}
+		return do(utils.RequestWithTrace(t.trace()))
+	})
+	var data []byte
+	if err == nil && handler != nil {
+		data, err = handler(resp)
+	} else if err == nil && resp.StatusCode >= http.StatusBadRequest {
+		if data, err = io.ReadAll(resp.Body); err == nil {
+			resp.Body.Close()
+			resp.Body = io.NopCloser(bytes.NewReader(data))
+		}
+	}
+
+	var o = &Observation{
+		Endpoint:  c.endpoint,
+		GrantType: c.grantType,
+		Err:       err,
+		Latency:   time.Since(start),
+		Retries:   attempts - 1,
+		Timing:    t.result(),
+	}
+	if resp != nil {
+		o.StatusCode = resp.StatusCode
+	}
+	var oe *errorx.OauthError
+	if errors.As(err, &oe) {
+		o.ErrorCode = oe.Code
+	} else if o.StatusCode >= http.StatusBadRequest {
+		o.ErrorCode = errorx.ParseOauthError(o.StatusCode, data).Code
+	}
+	c.observer.Observe(o)
+
+	if handler == nil {
+		data = nil
+	}
+	return resp, data, err
+}
@@ -0,0 +1,81 @@
+package oauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestObserver(t *testing.T) {
+	var userinfoCalls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			if r.FormValue("grant_type") == "refresh_token" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"invalid_grant"}`))
+				return
+			}
+			w.Write([]byte(`{"access_token":"AT","token_type":"Bearer"}`))
+		case "/userinfo":
+			if userinfoCalls++; userinfoCalls == 1 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"sub":"user"}`))
+		case "/register":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_redirect_uri"}`))
+		}
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var observed []*Observation
+	observer := ObserverFunc(func(o *Observation) {
+		mu.Lock()
+		observed = append(observed, o)
+		mu.Unlock()
+	})
+
+	policy := NewRetryPolicy(RetryPolicyWithBackoff(time.Millisecond, time.Millisecond))
+	config := NewConfig(Endpoint{TokenURL: server.URL + "/token"}, "client", ConfigWithSecret("secret"), ConfigWithObserver(observer), ConfigWithRetryPolicy(policy))
+	if _, err := config.Exchange(context.Background(), "code"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.Refresh(context.Background(), "RT"); err == nil {
+		t.Fatal("expected invalid_grant")
+	}
+	if _, err := NewUserInfo(server.URL+"/userinfo", "AT", UserInfoWithObserver(observer), UserInfoWithRetryPolicy(policy)).DoRequest(); err != nil {
+		t.Fatal(err)
+	}
+	// the error body is read for the observer and kept for the caller
+	_, err := NewRegisterClient(server.URL+"/register", &ClientMetadata{}, RegisterClientWithObserver(observer)).Register()
+	if err == nil || err.Error() != "oauth server error: status 400, error invalid_redirect_uri" {
+		t.Errorf("unexpected registration error %v", err)
+	}
+
+	if len(observed) != 4 {
+		t.Fatalf("expected 4 observations, got %d", len(observed))
+	}
+	exchange, refresh, userinfo, register := observed[0], observed[1], observed[2], observed[3]
+	if exchange.Endpoint != EndpointToken || exchange.GrantType != "authorization_code" || exchange.StatusCode != http.StatusOK || exchange.Err != nil {
+		t.Errorf("unexpected exchange observation %+v", exchange)
+	}
+	if exchange.Latency <= 0 || exchange.Timing.TTFB <= 0 {
+		t.Errorf("latency and ttfb should be measured, got %+v", exchange)
+	}
+	if refresh.GrantType != "refresh_token" || refresh.Retries != 0 || refresh.StatusCode != http.StatusBadRequest || refresh.ErrorCode != "invalid_grant" {
+		t.Errorf("unexpected refresh observation %+v", refresh)
+	}
+	if userinfo.Endpoint != EndpointUserInfo || userinfo.GrantType != "" || userinfo.StatusCode != http.StatusOK || userinfo.Retries != 1 {
+		t.Errorf("unexpected userinfo observation %+v", userinfo)
+	}
+	if register.Endpoint != EndpointRegistration || register.ErrorCode != "invalid_redirect_uri" {
+		t.Errorf("unexpected registration observation %+v", register)
+	}
+}
@@ -0,0 +1,36 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"github.com/demo007x/oauth2-client/jose"
+	"github.com/demo007x/oauth2-client/utils"
+)
+
+// CodeChallengeMethodS256 PKCE code challenge method. RFC 7636 section 4.2
+const CodeChallengeMethodS256 = "S256"
+
+// GenerateCodeVerifier random PKCE code verifier of 43 characters. RFC 7636 section 4.1
+func GenerateCodeVerifier() (string, error) {
+	return utils.GenerateRandomString(32)
+}
+
+// CodeChallengeS256 S256 code challenge of the verifier
+func CodeChallengeS256(verifier string) string {
+	var sum = sha256.Sum256([]byte(verifier))
+	return jose.Encode(sum[:])
+}
+
+// WithPKCE send the S256 code challenge of verifier, exchange the code with AccessTokenWithCodeVerifier
+func WithPKCE(verifier string) WithOption {
+	return func(client *Client) {
+		client.CodeVerifier = verifier
+	}
+}
+
+func (client *Client) setCodeChallenge() *Client {
+	if client.err == nil && client.CodeVerifier != "" {
+		client.values.Set("code_challenge", CodeChallengeS256(client.CodeVerifier))
+		client.values.Set("code_challenge_method", CodeChallengeMethodS256)
+	}
+	return client
+}
@@ -0,0 +1,35 @@
+package oauth
+
+import (
+	"context"
+	"github.com/demo007x/oauth2-client/oauthtest"
+	"testing"
+)
+
+func TestPKCE(t *testing.T) {
+	srv := oauthtest.NewServer(oauthtest.ServerWithClient(oauthtest.Client{ID: "cli", RedirectURIs: []string{"http://127.0.0.1/callback"}}))
+	defer srv.Close()
+
+	if CodeChallengeS256("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk") != "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM" {
+		t.Error("unexpected code challenge of RFC 7636 appendix B")
+	}
+	verifier, err := GenerateCodeVerifier()
+	if err != nil || len(verifier) != 43 {
+		t.Fatalf("unexpected verifier %s %v", verifier, err)
+	}
+
+	var config = NewConfig(Endpoint{AuthorizeURL: srv.AuthorizeURL(), TokenURL: srv.TokenURL(), AuthMethod: AuthMethodNone}, "cli",
+		ConfigWithRedirectURI("http://127.0.0.1/callback"), ConfigWithScopes("openid"))
+	authURL, err := config.AuthCodeURL("state", WithPKCE(verifier))
+	if err != nil {
+		t.Fatal(err)
+	}
+	callback, err := srv.Authorize(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := config.Exchange(context.Background(), callback.Query().Get("code"), AccessTokenWithCodeVerifier(verifier))
+	if err != nil || token.AccessToken == "" {
+		t.Errorf("unexpected token %+v %v", token, err)
+	}
+}
@@ -0,0 +1,88 @@
+package oauth
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/demo007x/oauth2-client/errorx"
+	"net/http"
+)
+
+type (
+	// Profile normalized user profile, fields follow OpenID Connect standard claims
+	Profile struct {
+		// Subject stable user identifier at the provider
+		Subject           string `json:"sub"`
+		Email             string `json:"email,omitempty"`
+		EmailVerified     bool   `json:"email_verified"`
+		Name              string `json:"name,omitempty"`
+		PreferredUsername string `json:"preferred_username,omitempty"`
+		Picture           string `json:"picture,omitempty"`
+		Locale            string `json:"locale,omitempty"`
+		// Provider name of the identity provider. eg: github
+		Provider string `json:"provider,omitempty"`
+		// Raw all userinfo claims
+		Raw map[string]interface{} `json:"raw,omitempty"`
+	}
+
+	// ProfileMapper map raw userinfo claims of a provider to Profile
+	ProfileMapper func(raw map[string]interface{}) *Profile
+)
+
+// OIDCProfileMapper map OpenID Connect standard claims
+func OIDCProfileMapper(raw map[string]interface{}) *Profile {
+	return &Profile{
+		Subject:           StringClaim(raw, "sub"),
+		Email:             StringClaim(raw, "email"),
+		EmailVerified:     StringClaim(raw, "email_verified") == "true",
+		Name:              StringClaim(raw, "name"),
+		PreferredUsername: StringClaim(raw, "preferred_username"),
+		Picture:           StringClaim(raw, "picture"),
+		Locale:            StringClaim(raw, "locale"),
+		Raw:               raw,
+	}
+}
+
+// StringClaim read string, number or bool claim as string.
+// Nested claims are read by path. eg: "picture", "data", "url"
+func StringClaim(raw map[string]interface{}, path ...string) string {
+	for i, key := range path {
+		if i == len(path)-1 {
+			return stringClaim(raw, key)
+		}
+		next, ok := raw[key].(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		raw = next
+	}
+	return ""
+}
+
+// ParseProfile decode userinfo response and map it with mapper, OIDCProfileMapper when nil.
+// Error response is returned as *errorx.OauthError
+func ParseProfile(data []byte, mapper ProfileMapper) (*Profile, error) {
+	var raw = map[string]interface{}{}
+	var decoder = json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, err
+	}
+	if code := stringClaim(raw, "error"); code != "" {
+		return nil, &errorx.OauthError{
+			StatusCode:  http.StatusUnauthorized,
+			Code:        code,
+			Description: stringClaim(raw, "error_description"),
+		}
+	}
+	if mapper == nil {
+		mapper = OIDCProfileMapper
+	}
+	var profile = mapper(raw)
+	if profile == nil || profile.Subject == "" {
+		return nil, errorx.SubjectEmptyError
+	}
+	if profile.Raw == nil {
+		profile.Raw = raw
+	}
+	return profile, nil
+}
@@ -0,0 +1,55 @@
+package oauth
+
+import (
+	"github.com/demo007x/oauth2-client/errorx"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUserInfoProfile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer AT" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_token","error_description":"The access token expired"}`))
+			return
+		}
+		w.Write([]byte(`{"sub":"248289761001","name":"Jane Doe","preferred_username":"j.doe","email":"janedoe@example.com","email_verified":true,"picture":"http://example.com/janedoe/me.jpg","locale":"en-US","address":{"country":"US"}}`))
+	}))
+	defer server.Close()
+
+	profile, err := NewUserInfo(server.URL, "AT", UserInfoWithMethod(http.MethodGet)).Profile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want = Profile{Subject: "248289761001", Email: "janedoe@example.com", EmailVerified: true, Name: "Jane Doe", PreferredUsername: "j.doe", Picture: "http://example.com/janedoe/me.jpg", Locale: "en-US"}
+	if profile.Subject != want.Subject || profile.Email != want.Email || !profile.EmailVerified || profile.Name != want.Name ||
+		profile.PreferredUsername != want.PreferredUsername || profile.Picture != want.Picture || profile.Locale != want.Locale {
+		t.Errorf("unexpected profile %+v", profile)
+	}
+	if StringClaim(profile.Raw, "address", "country") != "US" {
+		t.Errorf("raw claims should be kept, got %v", profile.Raw)
+	}
+
+	var custom = func(raw map[string]interface{}) *Profile {
+		return &Profile{Subject: "custom:" + StringClaim(raw, "sub")}
+	}
+	profile, err = NewUserInfo(server.URL, "AT", UserInfoWithMethod(http.MethodGet), UserInfoWithProfileMapper(custom)).Profile()
+	if err != nil || profile.Subject != "custom:248289761001" || profile.Raw == nil {
+		t.Errorf("unexpected custom profile %+v %v", profile, err)
+	}
+
+	if _, err := NewUserInfo(server.URL, "expired").Profile(); err == nil {
+		t.Error("expected invalid_token error")
+	}
+	if _, err := ParseProfile([]byte(`{"name":"anonymous"}`), nil); err != errorx.SubjectEmptyError {
+		t.Errorf("expected subject empty error, got %v", err)
+	}
+	var unknown = func(raw map[string]interface{}) *Profile { return nil }
+	if _, err := ParseProfile([]byte(`{"sub":"alice"}`), unknown); err != errorx.SubjectEmptyError {
+		t.Errorf("expected subject empty error of nil profile, got %v", err)
+	}
+	if got := StringClaim(map[string]interface{}{"id": float64(12345678901)}, "id"); got != "12345678901" {
+		t.Errorf("unexpected float claim %s", got)
+	}
+}
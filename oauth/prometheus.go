@@ -0,0 +1,199 @@
+package oauth
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type (
+	PrometheusCollectorOption func(c *PrometheusCollector)
+	// PrometheusCollector Observer aggregating the calls in Prometheus text exposition format.
+	// Serve it on the metrics endpoint. eg: http.Handle("/metrics", collector)
+	PrometheusCollector struct {
+		// Namespace metric name prefix, oauth_client by default
+		Namespace string
+
+		// internal field
+		buckets   []float64
+		mu        sync.Mutex
+		requests  map[string]float64
+		retries   map[string]float64
+		durations map[string]*histogram
+		phases    map[string]*histogram
+	}
+
+	histogram struct {
+		counts []uint64
+		sum    float64
+		count  uint64
+	}
+)
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// PrometheusCollectorWithNamespace set metric name prefix
+func PrometheusCollectorWithNamespace(namespace string) PrometheusCollectorOption {
+	return func(c *PrometheusCollector) {
+		c.Namespace = namespace
+	}
+}
+
+// PrometheusCollectorWithBuckets set duration histogram buckets in seconds
+func PrometheusCollectorWithBuckets(buckets ...float64) PrometheusCollectorOption {
+	return func(c *PrometheusCollector) {
+		c.buckets = append([]float64(nil), buckets...)
+		sort.Float64s(c.buckets)
+	}
+}
+
+// labels render label pairs. eg: endpoint="token",grant_type="refresh_token"
+func labels(pairs ...string) string {
+	var parts = make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		parts = append(parts, pairs[i]+`="`+labelEscaper.Replace(pairs[i+1])+`"`)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (h *histogram) observe(buckets []float64, value float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(buckets))
+	}
+	for i, bound := range buckets {
+		if value <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += value
+	h.count++
+}
+
+// Observe implement Observer
+func (c *PrometheusCollector) Observe(o *Observation) {
+	var errorCode = o.ErrorCode
+	if errorCode == "" && o.Err != nil && o.StatusCode == 0 {
+		errorCode = "transport_error"
+	}
+	var series = labels("endpoint", o.Endpoint, "grant_type", o.GrantType)
+	var request = labels("endpoint", o.Endpoint, "grant_type", o.GrantType, "status", strconv.Itoa(o.StatusCode), "error", errorCode)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests[request]++
+	if o.Retries > 0 {
+		c.retries[series] += float64(o.Retries)
+	}
+	if c.durations[series] == nil {
+		c.durations[series] = &histogram{}
+	}
+	c.durations[series].observe(c.buckets, o.Latency.Seconds())
+
+	for phase, d := range map[string]float64{
+		"dns":     o.Timing.DNS.Seconds(),
+		"connect": o.Timing.Connect.Seconds(),
+		"tls":     o.Timing.TLS.Seconds(),
+		"ttfb":    o.Timing.TTFB.Seconds(),
+	} {
+		if d <= 0 {
+			continue
+		}
+		var key = labels("endpoint", o.Endpoint, "phase", phase)
+		if c.phases[key] == nil {
+			c.phases[key] = &histogram{}
+		}
+		c.phases[key].observe(nil, d)
+	}
+}
+
+// sortedKeys series of a metric in stable order
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch m := m.(type) {
+	case map[string]float64:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	case map[string]*histogram:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// WriteTo write all metrics in Prometheus text exposition format
+func (c *PrometheusCollector) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	var name = func(metric string) string {
+		return c.Namespace + "_" + metric
+	}
+	var float = func(v float64) string {
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+
+	c.mu.Lock()
+	fmt.Fprintf(&buf, "# HELP %s Calls to the oauth server by endpoint, grant type, status and error code.\n", name("requests_total"))
+	fmt.Fprintf(&buf, "# TYPE %s counter\n", name("requests_total"))
+	for _, key := range sortedKeys(c.requests) {
+		fmt.Fprintf(&buf, "%s{%s} %s\n", name("requests_total"), key, float(c.requests[key]))
+	}
+
+	fmt.Fprintf(&buf, "# HELP %s Retried attempts of the calls.\n", name("retries_total"))
+	fmt.Fprintf(&buf, "# TYPE %s counter\n", name("retries_total"))
+	for _, key := range sortedKeys(c.retries) {
+		fmt.Fprintf(&buf, "%s{%s} %s\n", name("retries_total"), key, float(c.retries[key]))
+	}
+
+	var duration = name("request_duration_seconds")
+	fmt.Fprintf(&buf, "# HELP %s Latency of the calls, retries included.\n", duration)
+	fmt.Fprintf(&buf, "# TYPE %s histogram\n", duration)
+	for _, key := range sortedKeys(c.durations) {
+		var h = c.durations[key]
+		for i, bound := range c.buckets {
+			fmt.Fprintf(&buf, "%s_bucket{%s,le=\"%s\"} %d\n", duration, key, float(bound), h.counts[i])
+		}
+		fmt.Fprintf(&buf, "%s_bucket{%s,le=\"+Inf\"} %d\n", duration, key, h.count)
+		fmt.Fprintf(&buf, "%s_sum{%s} %s\n", duration, key, float(h.sum))
+		fmt.Fprintf(&buf, "%s_count{%s} %d\n", duration, key, h.count)
+	}
+
+	var phase = name("http_phase_duration_seconds")
+	fmt.Fprintf(&buf, "# HELP %s httptrace timings of dns, connect, tls and time to first byte.\n", phase)
+	fmt.Fprintf(&buf, "# TYPE %s summary\n", phase)
+	for _, key := range sortedKeys(c.phases) {
+		fmt.Fprintf(&buf, "%s_sum{%s} %s\n", phase, key, float(c.phases[key].sum))
+		fmt.Fprintf(&buf, "%s_count{%s} %d\n", phase, key, c.phases[key].count)
+	}
+	c.mu.Unlock()
+
+	return buf.WriteTo(w)
+}
+
+// ServeHTTP serve the metrics to Prometheus scraper
+func (c *PrometheusCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.WriteTo(w)
+}
+
+// NewPrometheusCollector return PrometheusCollector with the default Prometheus buckets
+func NewPrometheusCollector(opts ...PrometheusCollectorOption) *PrometheusCollector {
+	var c = &PrometheusCollector{
+		Namespace: "oauth_client",
+		buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		requests:  make(map[string]float64),
+		retries:   make(map[string]float64),
+		durations: make(map[string]*histogram),
+		phases:    make(map[string]*histogram),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
@@ -0,0 +1,40 @@
+package oauth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrometheusCollector(t *testing.T) {
+	collector := NewPrometheusCollector(PrometheusCollectorWithBuckets(0.1, 1))
+	collector.Observe(&Observation{Endpoint: EndpointToken, GrantType: "refresh_token", StatusCode: 200, Latency: 50 * time.Millisecond, Retries: 2, Timing: Timing{TTFB: 20 * time.Millisecond}})
+	collector.Observe(&Observation{Endpoint: EndpointToken, GrantType: "refresh_token", StatusCode: 400, ErrorCode: "invalid_grant", Latency: 500 * time.Millisecond})
+	collector.Observe(&Observation{Endpoint: EndpointUserInfo, Err: errors.New("connection refused"), Latency: 2 * time.Second})
+
+	recorder := httptest.NewRecorder()
+	collector.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("unexpected content type %s", recorder.Header().Get("Content-Type"))
+	}
+	var body = recorder.Body.String()
+	for _, line := range []string{
+		"# TYPE oauth_client_requests_total counter",
+		`oauth_client_requests_total{endpoint="token",grant_type="refresh_token",status="200",error=""} 1`,
+		`oauth_client_requests_total{endpoint="token",grant_type="refresh_token",status="400",error="invalid_grant"} 1`,
+		`oauth_client_requests_total{endpoint="userinfo",grant_type="",status="0",error="transport_error"} 1`,
+		`oauth_client_retries_total{endpoint="token",grant_type="refresh_token"} 2`,
+		`oauth_client_request_duration_seconds_bucket{endpoint="token",grant_type="refresh_token",le="0.1"} 1`,
+		`oauth_client_request_duration_seconds_bucket{endpoint="token",grant_type="refresh_token",le="1"} 2`,
+		`oauth_client_request_duration_seconds_bucket{endpoint="userinfo",grant_type="",le="+Inf"} 1`,
+		`oauth_client_request_duration_seconds_count{endpoint="token",grant_type="refresh_token"} 2`,
+		`oauth_client_http_phase_duration_seconds_sum{endpoint="token",phase="ttfb"} 0.02`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("missing %s in\n%s", line, body)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package oauth
+
+import (
+	"encoding/json"
+	"github.com/demo007x/oauth2-client/errorx"
+	"github.com/demo007x/oauth2-client/types"
+	"github.com/demo007x/oauth2-client/utils"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// PushedAuthorization pushed authorization response. RFC 9126
+type PushedAuthorization struct {
+	RequestURI string `json:"request_uri"`
+	ExpiresIn  int64  `json:"expires_in"`
+	// AuthorizeURL short authorize url only with client_id and request_uri
+	AuthorizeURL string `json:"-"`
+}
+
+// WithPushedAuthorizationEndpoint enable PAR mode.
+// AuthorizeURL will push the authorization params to endpoint first
+func WithPushedAuthorizationEndpoint(endpoint string) WithOption {
+	return func(client *Client) {
+		client.PushedAuthorizationEndpoint = endpoint
+	}
+}
+
+// PushAuthorizationRequest post the authorization params to pushed authorization request endpoint
+// and build the authorize url with the returned request_uri
+func (client *Client) PushAuthorizationRequest() (*PushedAuthorization, error) {
+	var c = *client
+	if err := c.build().err; err != nil {
+		return nil, err
+	}
+
+	var header = map[string]string{
+		"Content-Type": "application/x-www-form-urlencoded",
+		"Accept":       "application/json",
+	}
+	// confidential client use basic authorization, public client only send client_id
+	if strings.TrimSpace(client.Secret) != "" {
+		header["Authorization"] = utils.GenerateBaseAuthorization(client.ClientID, client.Secret)
+	}
+
+	var body = []byte(c.values.Encode())
+	var call = &call{endpoint: EndpointPushedAuthorization, observer: client.observer}
+	resp, data, err := call.send(func(trace ...utils.RequestOption) (*http.Response, error) {
+		var opts = append([]utils.RequestOption{utils.RequestWithBodyBytes(body), utils.RequestWithInterceptors(client.interceptors...)}, trace...)
+		return utils.DoRequest(client.PushedAuthorizationEndpoint, http.MethodPost, header, opts...)
+	}, types.DefaultOauthResponseHandler)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return nil, errorx.ParseOauthError(resp.StatusCode, data)
+	}
+
+	var par = &PushedAuthorization{}
+	if err := json.Unmarshal(data, par); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(par.RequestURI) == "" {
+		return nil, errorx.RequestURIEmptyError
+	}
+
+	var values = url.Values{}
+	values.Set("client_id", client.ClientID)
+	values.Set("request_uri", par.RequestURI)
+	var u = *c.u
+	u.RawQuery = values.Encode()
+	par.AuthorizeURL = u.String()
+
+	return par, nil
+}
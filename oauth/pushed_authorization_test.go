@@ -0,0 +1,60 @@
+package oauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestPushAuthorizationRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "client" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			// t.Fatal must not be called from the handler goroutine
+			t.Error(err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.PostForm.Get("scope") != "openid profile" || r.PostForm.Get("state") != "xyz" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_request"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"request_uri":"urn:ietf:params:oauth:request_uri:abc","expires_in":60}`))
+	}))
+	defer server.Close()
+
+	client := NewOauth2Client(
+		"https://as.example.com/authorize",
+		"client",
+		WithSecret("secret"),
+		WithScope("openid profile"),
+		WithState("xyz"),
+		WithRedirectURI("https://app.example.com/callback"),
+		WithPushedAuthorizationEndpoint(server.URL+"/par"),
+	)
+	authURL, err := client.AuthorizeURL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := u.Query()
+	if len(query) != 2 || query.Get("client_id") != "client" || query.Get("request_uri") != "urn:ietf:params:oauth:request_uri:abc" {
+		t.Errorf("unexpected authorize url %s", authURL)
+	}
+
+	client.Secret = "wrong"
+	if _, err := client.PushAuthorizationRequest(); err == nil {
+		t.Error("expected invalid_client error")
+	}
+}
@@ -1,12 +1,9 @@
 package oauth
 
 import (
-	"github.com/demo007x/oauth2-client/errorx"
+	"context"
 	"github.com/demo007x/oauth2-client/types"
 	"github.com/demo007x/oauth2-client/utils"
-	"net/http"
-	"net/url"
-	"strings"
 )
 
 type (
@@ -18,12 +15,22 @@ type (
 		RefreshToken string
 		GrantType    string
 		ContentType  string
+		// AuthMethod client_secret_basic by default
+		AuthMethod string
+		// Method http method of refresh request, POST by default
+		Method string
+		Accept string
+		// Query custom params of refresh request
+		Query map[string]string
 		// internal field
-		respHandler types.OauthResponseHandler
-		u           *url.URL
-		header      map[string]string
-		values      url.Values
-		err         error
+		respHandler  types.OauthResponseHandler
+		ctx          context.Context
+		dpop         *DPoP
+		mtls         *MutualTLS
+		retry        *RetryPolicy
+		header       map[string]string
+		interceptors []utils.RequestInterceptor
+		observer     Observer
 	}
 )
 
@@ -33,6 +40,11 @@ func RefreshTokenWithResponseHandler(handler types.OauthResponseHandler) Refresh
 	}
 }
 
+// RefreshTokenWithResponseMiddleware stack middlewares on the default response handler. eg: types.CheckStatus(nil), types.DecodeBody()
+func RefreshTokenWithResponseMiddleware(middlewares ...types.ResponseMiddleware) RefreshTokenOption {
+	return RefreshTokenWithResponseHandler(types.ChainResponseHandler(middlewares...))
+}
+
 func RefreshTokenWithGrantType(grantType string) RefreshTokenOption {
 	return func(token *RefreshToken) {
 		token.GrantType = grantType
@@ -66,78 +78,104 @@ func RefreshTokenWithServerURL(serverURL string) RefreshTokenOption {
 func RefreshTokenWithContentType(contentType string) RefreshTokenOption {
 	return func(token *RefreshToken) {
 		token.ContentType = contentType
-		if strings.TrimSpace(contentType) == "" {
-			token.ContentType = "application/json"
-		}
-		token.header["Content-Type"] = contentType
 	}
 }
 
-// setServerURI
-// todo 统一处理 Oauth 服务的校验
-func (ort *RefreshToken) setServerURI() *RefreshToken {
-	if ort.err == nil {
-		ort.u, ort.err = url.Parse(ort.ServerURL)
-		if ort.err == nil {
-			ort.values = ort.u.Query()
-		}
+// RefreshTokenWithDPoP refresh a DPoP bound token with the same key
+func RefreshTokenWithDPoP(d *DPoP) RefreshTokenOption {
+	return func(token *RefreshToken) {
+		token.dpop = d
 	}
+}
 
-	return ort
+// RefreshTokenWithMutualTLS authenticate with client certificate
+func RefreshTokenWithMutualTLS(m *MutualTLS) RefreshTokenOption {
+	return func(token *RefreshToken) {
+		token.mtls = m
+	}
 }
 
-func (ort *RefreshToken) setRefreshToken() *RefreshToken {
-	if ort.err == nil {
-		if strings.TrimSpace(ort.RefreshToken) == "" {
-			ort.err = errorx.RefreshTokenNotEmpty
-			return ort
-		}
-		ort.values.Set("refresh_token", ort.RefreshToken)
+// RefreshTokenWithRetryPolicy retry transient failures of the refresh request
+func RefreshTokenWithRetryPolicy(policy *RetryPolicy) RefreshTokenOption {
+	return func(token *RefreshToken) {
+		token.retry = policy
 	}
+}
 
-	return ort
+// RefreshTokenWithAuthMethod set client authentication method. eg: client_secret_post
+func RefreshTokenWithAuthMethod(authMethod string) RefreshTokenOption {
+	return func(token *RefreshToken) {
+		token.AuthMethod = authMethod
+	}
 }
 
-func (ort *RefreshToken) setGrantType() *RefreshToken {
-	if ort.err == nil {
-		if strings.TrimSpace(ort.GrantType) == "" {
-			ort.GrantType = "refresh_token"
-		}
-		ort.values.Set("grant_type", ort.GrantType)
+// RefreshTokenWithMethod set http method of refresh request
+func RefreshTokenWithMethod(method string) RefreshTokenOption {
+	return func(token *RefreshToken) {
+		token.Method = method
 	}
+}
 
-	return ort
+// RefreshTokenWithAccept set accept header. eg: application/json
+func RefreshTokenWithAccept(accept string) RefreshTokenOption {
+	return func(token *RefreshToken) {
+		token.Accept = accept
+		token.header["Accept"] = accept
+	}
 }
 
-func (ort *RefreshToken) setKeyAndSecret() *RefreshToken {
-	if ort.err == nil {
-		if strings.TrimSpace(ort.ClientID) == "" || strings.TrimSpace(ort.Secret) == "" {
-			ort.err = errorx.SecretKeyError
-			return ort
-		}
-		ort.header["Authorization"] = utils.GenerateBaseAuthorization(ort.ClientID, ort.Secret)
+// RefreshTokenWithQuery custom params of refresh request
+func RefreshTokenWithQuery(query map[string]string) RefreshTokenOption {
+	return func(token *RefreshToken) {
+		token.Query = query
 	}
+}
+
+// RefreshTokenWithContext send the refresh request with context
+func RefreshTokenWithContext(ctx context.Context) RefreshTokenOption {
+	return func(token *RefreshToken) {
+		token.ctx = ctx
+	}
+}
+
+// RefreshTokenWithInterceptors modify or audit the request before it is sent. eg: custom headers, signing
+func RefreshTokenWithInterceptors(interceptors ...utils.RequestInterceptor) RefreshTokenOption {
+	return func(token *RefreshToken) {
+		token.interceptors = append(token.interceptors, interceptors...)
+	}
+}
 
-	return ort
+// RefreshTokenWithObserver report the call to observer. eg: PrometheusCollector
+func RefreshTokenWithObserver(observer Observer) RefreshTokenOption {
+	return func(token *RefreshToken) {
+		token.observer = observer
+	}
+}
+
+// endpoint token endpoint of the request
+func (ort *RefreshToken) endpoint() *TokenEndpoint {
+	return &TokenEndpoint{
+		ServerURL:    ort.ServerURL,
+		ClientID:     ort.ClientID,
+		Secret:       ort.Secret,
+		AuthMethod:   ort.AuthMethod,
+		Method:       ort.Method,
+		ContentType:  ort.ContentType,
+		Header:       ort.header,
+		Query:        ort.Query,
+		handler:      rawResponseHandler(ort.respHandler),
+		ctx:          ort.ctx,
+		dpop:         ort.dpop,
+		mtls:         ort.mtls,
+		retry:        ort.retry,
+		interceptors: ort.interceptors,
+		observer:     ort.observer,
+	}
 }
 
 func (ort *RefreshToken) DoRequest() ([]byte, error) {
-	if err := ort.setServerURI().
-		setRefreshToken().
-		setKeyAndSecret().
-		err; err != nil {
-		return nil, err
-	}
-	ort.u.RawQuery = ort.values.Encode()
-	var requestHost = ort.u.String()
-	resp, err := utils.DoRequest(requestHost, http.MethodPost, ort.header)
-	if err != nil {
-		return nil, err
-	}
-	if ort.respHandler == nil {
-		return types.DefaultOauthResponseHandler(resp)
-	}
-	return ort.respHandler(resp)
+	var grant = &RefreshTokenGrant{RefreshToken: ort.RefreshToken}
+	return ort.endpoint().Do(withGrantType(grant, ort.GrantType))
 }
 
 func NewRefreshToken(serverURL, key, secret, refreshToken string, opts ...RefreshTokenOption) *RefreshToken {
@@ -0,0 +1,211 @@
+package oauth
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/demo007x/oauth2-client/errorx"
+	"github.com/demo007x/oauth2-client/jose"
+	"github.com/demo007x/oauth2-client/types"
+	"github.com/demo007x/oauth2-client/utils"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type (
+	// ClientMetadata registered client metadata. RFC 7591 section 2
+	ClientMetadata struct {
+		RedirectURIs            []string            `json:"redirect_uris,omitempty"`
+		TokenEndpointAuthMethod string              `json:"token_endpoint_auth_method,omitempty"`
+		GrantTypes              []string            `json:"grant_types,omitempty"`
+		ResponseTypes           []string            `json:"response_types,omitempty"`
+		ClientName              string              `json:"client_name,omitempty"`
+		ClientURI               string              `json:"client_uri,omitempty"`
+		LogoURI                 string              `json:"logo_uri,omitempty"`
+		Scope                   string              `json:"scope,omitempty"`
+		Contacts                []string            `json:"contacts,omitempty"`
+		TosURI                  string              `json:"tos_uri,omitempty"`
+		PolicyURI               string              `json:"policy_uri,omitempty"`
+		JwksURI                 string              `json:"jwks_uri,omitempty"`
+		Jwks                    *jose.JSONWebKeySet `json:"jwks,omitempty"`
+		SoftwareID              string              `json:"software_id,omitempty"`
+		SoftwareVersion         string              `json:"software_version,omitempty"`
+		SoftwareStatement       string              `json:"software_statement,omitempty"`
+	}
+
+	// ClientCredentials client information response. RFC 7591 section 3.2.1
+	ClientCredentials struct {
+		ClientMetadata
+		ClientID                string `json:"client_id"`
+		ClientSecret            string `json:"client_secret,omitempty"`
+		ClientIDIssuedAt        int64  `json:"client_id_issued_at,omitempty"`
+		ClientSecretExpiresAt   int64  `json:"client_secret_expires_at,omitempty"`
+		RegistrationAccessToken string `json:"registration_access_token,omitempty"`
+		RegistrationClientURI   string `json:"registration_client_uri,omitempty"`
+	}
+
+	RegisterClientOption func(rc *RegisterClient)
+	// RegisterClient dynamic client registration. RFC 7591
+	RegisterClient struct {
+		ServerURL          string
+		InitialAccessToken string
+		Metadata           *ClientMetadata
+
+		// internal field
+		header       map[string]string
+		handler      types.OauthResponseHandler
+		err          error
+		interceptors []utils.RequestInterceptor
+		observer     Observer
+	}
+)
+
+// RegisterClientWithInitialAccessToken authorize registration with initial access token
+func RegisterClientWithInitialAccessToken(token string) RegisterClientOption {
+	return func(rc *RegisterClient) {
+		rc.InitialAccessToken = token
+	}
+}
+
+func RegisterClientWithResponseHandler(handler types.OauthResponseHandler) RegisterClientOption {
+	return func(rc *RegisterClient) {
+		rc.handler = handler
+	}
+}
+
+// RegisterClientWithResponseMiddleware stack middlewares on the default response handler. eg: types.CheckStatus(nil)
+func RegisterClientWithResponseMiddleware(middlewares ...types.ResponseMiddleware) RegisterClientOption {
+	return RegisterClientWithResponseHandler(types.ChainResponseHandler(middlewares...))
+}
+
+// RegisterClientWithInterceptors modify or audit the registration request before it is sent
+func RegisterClientWithInterceptors(interceptors ...utils.RequestInterceptor) RegisterClientOption {
+	return func(rc *RegisterClient) {
+		rc.interceptors = append(rc.interceptors, interceptors...)
+	}
+}
+
+// RegisterClientWithObserver report the registration call to observer
+func RegisterClientWithObserver(observer Observer) RegisterClientOption {
+	return func(rc *RegisterClient) {
+		rc.observer = observer
+	}
+}
+
+func (rc *RegisterClient) setServerURL() *RegisterClient {
+	if rc.err == nil {
+		_, rc.err = url.Parse(rc.ServerURL)
+	}
+	return rc
+}
+
+func (rc *RegisterClient) setInitialAccessToken() *RegisterClient {
+	if rc.err == nil && strings.TrimSpace(rc.InitialAccessToken) != "" {
+		rc.header["Authorization"] = utils.GenerateBearAuthorization(rc.InitialAccessToken)
+	}
+	return rc
+}
+
+func (rc *RegisterClient) send() (*http.Response, error) {
+	if err := rc.setServerURL().setInitialAccessToken().err; err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(rc.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	var c = &call{endpoint: EndpointRegistration, observer: rc.observer}
+	resp, _, err := c.send(func(trace ...utils.RequestOption) (*http.Response, error) {
+		var opts = append([]utils.RequestOption{utils.RequestWithBody(bytes.NewReader(body)), utils.RequestWithInterceptors(rc.interceptors...)}, trace...)
+		return utils.DoRequest(rc.ServerURL, http.MethodPost, rc.header, opts...)
+	}, nil)
+	return resp, err
+}
+
+// DoRequest post client metadata to registration endpoint
+func (rc *RegisterClient) DoRequest() ([]byte, error) {
+	resp, err := rc.send()
+	if err != nil {
+		return nil, err
+	}
+	if rc.handler == nil {
+		return types.DefaultOauthResponseHandler(resp)
+	}
+	return rc.handler(resp)
+}
+
+// Register post client metadata and decode the issued credentials
+func (rc *RegisterClient) Register() (*ClientCredentials, error) {
+	resp, err := rc.send()
+	if err != nil {
+		return nil, err
+	}
+	return decodeClientCredentials(resp)
+}
+
+// decodeClientCredentials decode client information response or registration error
+func decodeClientCredentials(resp *http.Response) (*ClientCredentials, error) {
+	data, err := types.DefaultOauthResponseHandler(resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return nil, errorx.ParseOauthError(resp.StatusCode, data)
+	}
+	var credentials = &ClientCredentials{}
+	if err := json.Unmarshal(data, credentials); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(credentials.ClientID) == "" {
+		return nil, errorx.ClientKeyError
+	}
+	return credentials, nil
+}
+
+// NewOauth2Client return Client of the registered client. The first redirect uri is used
+func (c *ClientCredentials) NewOauth2Client(serverURL string, opts ...WithOption) *Client {
+	var defaults = []WithOption{WithSecret(c.ClientSecret)}
+	if len(c.RedirectURIs) > 0 {
+		defaults = append(defaults, WithRedirectURI(c.RedirectURIs[0]))
+	}
+	return NewOauth2Client(serverURL, c.ClientID, append(defaults, opts...)...)
+}
+
+// NewAccessToken return AccessToken authenticated as the registered client
+func (c *ClientCredentials) NewAccessToken(serverURL, code string, opts ...AccessTokenOption) *AccessToken {
+	if len(c.RedirectURIs) > 0 {
+		opts = append([]AccessTokenOption{AccessTokenWithRedirectURI(c.RedirectURIs[0])}, opts...)
+	}
+	return NewAccessToken(serverURL, c.ClientID, c.ClientSecret, code, opts...)
+}
+
+// NewRefreshToken return RefreshToken authenticated as the registered client
+func (c *ClientCredentials) NewRefreshToken(serverURL, refreshToken string, opts ...RefreshTokenOption) *RefreshToken {
+	return NewRefreshToken(serverURL, c.ClientID, c.ClientSecret, refreshToken, opts...)
+}
+
+// NewRevokeToken return RevokeToken authenticated as the registered client
+func (c *ClientCredentials) NewRevokeToken(serverURL, accessToken string, opts ...RevokeTokenOption) *RevokeToken {
+	return NewOauthRevokeToken(serverURL, c.ClientID, c.ClientSecret, accessToken, opts...)
+}
+
+// NewIntrospectToken return IntrospectToken authenticated as the registered client
+func (c *ClientCredentials) NewIntrospectToken(serverURL, token string, opts ...IntrospectTokenOption) *IntrospectToken {
+	return NewIntrospectToken(serverURL, c.ClientID, c.ClientSecret, token, opts...)
+}
+
+// NewRegisterClient return RegisterClient post metadata to registration endpoint
+func NewRegisterClient(serverURL string, metadata *ClientMetadata, opts ...RegisterClientOption) *RegisterClient {
+	var rc = &RegisterClient{
+		ServerURL: serverURL,
+		Metadata:  metadata,
+		header: map[string]string{
+			"Content-Type": "application/json",
+			"Accept":       "application/json",
+		},
+	}
+	for _, opt := range opts {
+		opt(rc)
+	}
+	return rc
+}
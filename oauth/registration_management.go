@@ -0,0 +1,193 @@
+package oauth
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/demo007x/oauth2-client/errorx"
+	"github.com/demo007x/oauth2-client/types"
+	"github.com/demo007x/oauth2-client/utils"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type (
+	ManageClientOption func(m *ManageClient)
+	// ManageClient read, update and delete a registered client. RFC 7592
+	ManageClient struct {
+		RegistrationClientURI   string
+		RegistrationAccessToken string
+
+		// internal field
+		header       map[string]string
+		mtls         *MutualTLS
+		err          error
+		interceptors []utils.RequestInterceptor
+		observer     Observer
+	}
+
+	// clientUpdateRequest must carry client_id and must not carry the server managed fields
+	clientUpdateRequest struct {
+		ClientMetadata
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret,omitempty"`
+	}
+)
+
+// ManageClientWithMutualTLS present the client certificate to the registration endpoint
+func ManageClientWithMutualTLS(mtls *MutualTLS) ManageClientOption {
+	return func(m *ManageClient) {
+		m.mtls = mtls
+	}
+}
+
+// ManageClientWithInterceptors modify or audit the management requests before they are sent
+func ManageClientWithInterceptors(interceptors ...utils.RequestInterceptor) ManageClientOption {
+	return func(m *ManageClient) {
+		m.interceptors = append(m.interceptors, interceptors...)
+	}
+}
+
+// ManageClientWithObserver report the management calls to observer
+func ManageClientWithObserver(observer Observer) ManageClientOption {
+	return func(m *ManageClient) {
+		m.observer = observer
+	}
+}
+
+func (m *ManageClient) setRegistrationClientURI() *ManageClient {
+	if m.err == nil {
+		if strings.TrimSpace(m.RegistrationClientURI) == "" {
+			m.err = errorx.ServerURLError
+			return m
+		}
+		_, m.err = url.Parse(m.RegistrationClientURI)
+	}
+	return m
+}
+
+func (m *ManageClient) setRegistrationAccessToken() *ManageClient {
+	if m.err == nil {
+		if strings.TrimSpace(m.RegistrationAccessToken) == "" {
+			m.err = errorx.TokenEmptyError
+			return m
+		}
+		m.header["Authorization"] = utils.GenerateBearAuthorization(m.RegistrationAccessToken)
+	}
+	return m
+}
+
+func (m *ManageClient) do(method string, body io.Reader) (*http.Response, error) {
+	m.err = nil
+	if err := m.setRegistrationClientURI().setRegistrationAccessToken().err; err != nil {
+		return nil, err
+	}
+	var header = make(map[string]string, len(m.header)+1)
+	for key, val := range m.header {
+		header[key] = val
+	}
+	if body != nil {
+		header["Content-Type"] = "application/json"
+	}
+	var opts = append(m.mtls.requestOptions(), utils.RequestWithBody(body), utils.RequestWithInterceptors(m.interceptors...))
+	var c = &call{endpoint: EndpointRegistration, observer: m.observer}
+	resp, _, err := c.send(func(trace ...utils.RequestOption) (*http.Response, error) {
+		return utils.DoRequest(m.RegistrationClientURI, method, header, append(opts[:len(opts):len(opts)], trace...)...)
+	}, nil)
+	return resp, err
+}
+
+// remember the rotated registration access token and client uri
+func (m *ManageClient) update(credentials *ClientCredentials) *ClientCredentials {
+	if credentials.RegistrationAccessToken != "" {
+		m.RegistrationAccessToken = credentials.RegistrationAccessToken
+	}
+	if credentials.RegistrationClientURI != "" {
+		m.RegistrationClientURI = credentials.RegistrationClientURI
+	}
+	return credentials
+}
+
+// Read get the current client configuration
+func (m *ManageClient) Read() (*ClientCredentials, error) {
+	resp, err := m.do(http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+	credentials, err := decodeClientCredentials(resp)
+	if err != nil {
+		return nil, err
+	}
+	return m.update(credentials), nil
+}
+
+// Update replace the client metadata. The server may issue a new client secret
+func (m *ManageClient) Update(credentials *ClientCredentials) (*ClientCredentials, error) {
+	body, err := json.Marshal(clientUpdateRequest{
+		ClientMetadata: credentials.ClientMetadata,
+		ClientID:       credentials.ClientID,
+		ClientSecret:   credentials.ClientSecret,
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := m.do(http.MethodPut, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	updated, err := decodeClientCredentials(resp)
+	if err != nil {
+		return nil, err
+	}
+	return m.update(updated), nil
+}
+
+// RotateSecret update the client without its current secret and ask the server for a new one.
+// Return SecretRotationUnsupportedError when the server keep the old secret
+func (m *ManageClient) RotateSecret(credentials *ClientCredentials) (*ClientCredentials, error) {
+	var request = *credentials
+	request.ClientSecret = ""
+	updated, err := m.Update(&request)
+	if err != nil {
+		return nil, err
+	}
+	if updated.ClientSecret == "" || updated.ClientSecret == credentials.ClientSecret {
+		return nil, errorx.SecretRotationUnsupportedError
+	}
+	return updated, nil
+}
+
+// Delete deprovision the client
+func (m *ManageClient) Delete() error {
+	resp, err := m.do(http.MethodDelete, nil)
+	if err != nil {
+		return err
+	}
+	data, err := types.DefaultOauthResponseHandler(resp)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return errorx.ParseOauthError(resp.StatusCode, data)
+	}
+	return nil
+}
+
+// NewManageClient return ManageClient of the registered client
+func (c *ClientCredentials) NewManageClient(opts ...ManageClientOption) *ManageClient {
+	return NewManageClient(c.RegistrationClientURI, c.RegistrationAccessToken, opts...)
+}
+
+// NewManageClient return ManageClient with registration_client_uri and registration_access_token
+func NewManageClient(registrationClientURI, registrationAccessToken string, opts ...ManageClientOption) *ManageClient {
+	var m = &ManageClient{
+		RegistrationClientURI:   registrationClientURI,
+		RegistrationAccessToken: registrationAccessToken,
+		header:                  map[string]string{"Accept": "application/json"},
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
@@ -0,0 +1,95 @@
+package oauth
+
+import (
+	"encoding/json"
+	"github.com/demo007x/oauth2-client/errorx"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestManageClient(t *testing.T) {
+	var registered = map[string]interface{}{
+		"client_id":     "s6BhdRkqt3",
+		"client_secret": "secret-1",
+		"client_name":   "preview-42",
+		"redirect_uris": []string{"https://client.example.org/callback"},
+	}
+	var accessToken = "rat-1"
+	var deleted bool
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if deleted {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_token"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer "+accessToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_token"}`))
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			var update map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&update); err != nil || update["client_id"] != "s6BhdRkqt3" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"invalid_client_metadata"}`))
+				return
+			}
+			if _, ok := update["registration_access_token"]; ok {
+				t.Error("update must not send registration_access_token")
+			}
+			registered["client_name"] = update["client_name"]
+			if _, ok := update["client_secret"]; !ok {
+				registered["client_secret"] = "secret-2"
+			}
+		case http.MethodDelete:
+			deleted = true
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		// rotate registration access token on every response
+		accessToken += "+"
+		registered["registration_access_token"] = accessToken
+		registered["registration_client_uri"] = server.URL + "/register/s6BhdRkqt3"
+		json.NewEncoder(w).Encode(registered)
+	}))
+	defer server.Close()
+
+	manager := NewManageClient(server.URL+"/register/s6BhdRkqt3", "rat-1")
+	credentials, err := manager.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if credentials.ClientName != "preview-42" || manager.RegistrationAccessToken != "rat-1+" {
+		t.Errorf("unexpected read result %+v", credentials)
+	}
+
+	credentials.ClientName = "preview-43"
+	updated, err := manager.Update(credentials)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.ClientName != "preview-43" || updated.ClientSecret != "secret-1" {
+		t.Errorf("unexpected update result %+v", updated)
+	}
+
+	rotated, err := manager.RotateSecret(updated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated.ClientSecret != "secret-2" {
+		t.Errorf("client secret not rotated %+v", rotated)
+	}
+
+	if err := rotated.NewManageClient().Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := manager.Read(); err == nil {
+		t.Error("read after delete should fail")
+	} else if oe, ok := err.(*errorx.OauthError); !ok || oe.Code != "invalid_token" {
+		t.Errorf("expected invalid_token, got %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package oauth
+
+import (
+	"encoding/json"
+	"github.com/demo007x/oauth2-client/errorx"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRegisterClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/register":
+			if r.Header.Get("Authorization") != "Bearer initial" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			var metadata map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&metadata); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := metadata["redirect_uris"]; !ok {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"invalid_redirect_uri","error_description":"redirect_uris is required"}`))
+				return
+			}
+			metadata["client_id"] = "s6BhdRkqt3"
+			metadata["client_secret"] = "cf136dc3c1fc93f31185e5885805d"
+			metadata["registration_access_token"] = "this.is.an.access.token"
+			metadata["registration_client_uri"] = "https://server.example.com/register/s6BhdRkqt3"
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(metadata)
+		case "/token":
+			if user, pass, _ := r.BasicAuth(); user != "s6BhdRkqt3" || pass != "cf136dc3c1fc93f31185e5885805d" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"access_token":"at"}`))
+		}
+	}))
+	defer server.Close()
+
+	metadata := &ClientMetadata{
+		RedirectURIs:            []string{"https://client.example.org/callback"},
+		TokenEndpointAuthMethod: AuthMethodClientSecretBasic,
+		GrantTypes:              []string{"authorization_code", "refresh_token"},
+		ClientName:              "preview-42",
+	}
+	credentials, err := NewRegisterClient(server.URL+"/register", metadata, RegisterClientWithInitialAccessToken("initial")).Register()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if credentials.ClientID != "s6BhdRkqt3" || credentials.ClientName != "preview-42" || credentials.RegistrationClientURI == "" {
+		t.Errorf("unexpected credentials %+v", credentials)
+	}
+
+	data, err := credentials.NewAccessToken(server.URL+"/token", "code").DoRequest()
+	if err != nil || string(data) != `{"access_token":"at"}` {
+		t.Errorf("registered client should authenticate, %s %v", data, err)
+	}
+	authURL, err := credentials.NewOauth2Client("https://server.example.com/authorize").AuthorizeURL()
+	if err != nil || authURL == "" {
+		t.Error(err)
+	}
+
+	_, err = NewRegisterClient(server.URL+"/register", &ClientMetadata{}, RegisterClientWithInitialAccessToken("initial")).Register()
+	if oe, ok := err.(*errorx.OauthError); !ok || oe.Code != "invalid_redirect_uri" {
+		t.Errorf("expected invalid_redirect_uri, got %v", err)
+	}
+}
@@ -0,0 +1,226 @@
+package oauth
+
+import (
+	"crypto/rsa"
+	"encoding/json"
+	"github.com/demo007x/oauth2-client/jose"
+	"github.com/demo007x/oauth2-client/utils"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	RequestObjectOption func(ro *RequestObject)
+	// RequestObject build jwt secured authorization request. RFC 9101
+	RequestObject struct {
+		SigningAlg string
+		// SigningKey crypto.Signer for asymmetric alg or []byte for HMAC alg
+		SigningKey interface{}
+		KeyID      string
+		// Audience issuer identifier of authorization server
+		Audience string
+		Lifetime time.Duration
+		// optional encryption of the signed request object
+		EncryptionAlg   string
+		EncryptionEnc   string
+		EncryptionKey   *rsa.PublicKey
+		EncryptionKeyID string
+		// Publisher store the request object and return its request_uri.
+		// When set the request object is passed by reference
+		Publisher func(requestObject string) (string, error)
+	}
+)
+
+// RequestObjectWithKeyID set kid header of the request object
+func RequestObjectWithKeyID(keyID string) RequestObjectOption {
+	return func(ro *RequestObject) {
+		ro.KeyID = keyID
+	}
+}
+
+// RequestObjectWithAudience set aud claim, should be the issuer of authorization server
+func RequestObjectWithAudience(audience string) RequestObjectOption {
+	return func(ro *RequestObject) {
+		ro.Audience = audience
+	}
+}
+
+// RequestObjectWithLifetime set request object lifetime. default five minutes
+func RequestObjectWithLifetime(lifetime time.Duration) RequestObjectOption {
+	return func(ro *RequestObject) {
+		ro.Lifetime = lifetime
+	}
+}
+
+// RequestObjectWithEncryption encrypt the signed request object with the server public key
+func RequestObjectWithEncryption(alg, enc string, key *rsa.PublicKey, keyID string) RequestObjectOption {
+	return func(ro *RequestObject) {
+		ro.EncryptionAlg, ro.EncryptionEnc = alg, enc
+		ro.EncryptionKey, ro.EncryptionKeyID = key, keyID
+	}
+}
+
+// RequestObjectWithPublisher pass request object by reference with request_uri
+func RequestObjectWithPublisher(publisher func(requestObject string) (string, error)) RequestObjectOption {
+	return func(ro *RequestObject) {
+		ro.Publisher = publisher
+	}
+}
+
+// WithRequestObject AuthorizeURL emit client_id and the signed request object only
+func WithRequestObject(ro *RequestObject) WithOption {
+	return func(client *Client) {
+		client.RequestObject = ro
+	}
+}
+
+// Build sign the authorization params into a request object
+func (ro *RequestObject) Build(clientID string, params url.Values) (string, error) {
+	var claims = make(map[string]interface{}, len(params)+6)
+	for key, val := range params {
+		if len(val) == 1 {
+			claims[key] = val[0]
+		} else {
+			claims[key] = val
+		}
+	}
+	// claims parameter is a json object inside the request object
+	if raw := params.Get("claims"); raw != "" {
+		claims["claims"] = json.RawMessage(raw)
+	}
+
+	var now = time.Now()
+	var lifetime = ro.Lifetime
+	if lifetime <= 0 {
+		lifetime = 5 * time.Minute
+	}
+	jti, err := utils.GenerateRandomString(16)
+	if err != nil {
+		return "", err
+	}
+	claims["iss"] = clientID
+	claims["client_id"] = clientID
+	claims["iat"] = now.Unix()
+	claims["nbf"] = now.Unix()
+	claims["exp"] = now.Add(lifetime).Unix()
+	claims["jti"] = jti
+	if strings.TrimSpace(ro.Audience) != "" {
+		claims["aud"] = ro.Audience
+	}
+
+	var header = map[string]interface{}{"typ": "oauth-authz-req+jwt"}
+	if ro.KeyID != "" {
+		header["kid"] = ro.KeyID
+	}
+	token, err := jose.Sign(ro.SigningAlg, ro.SigningKey, header, claims)
+	if err != nil {
+		return "", err
+	}
+	if ro.EncryptionKey == nil {
+		return token, nil
+	}
+
+	var encHeader = map[string]interface{}{"cty": "JWT"}
+	if ro.EncryptionKeyID != "" {
+		encHeader["kid"] = ro.EncryptionKeyID
+	}
+	return jose.Encrypt(ro.EncryptionAlg, ro.EncryptionEnc, ro.EncryptionKey, encHeader, []byte(token))
+}
+
+// replace the authorization params with client_id and request object
+func (client *Client) setRequestObject() *Client {
+	if client.err == nil && client.RequestObject != nil {
+		token, err := client.RequestObject.Build(client.ClientID, client.values)
+		if err != nil {
+			client.err = err
+			return client
+		}
+		var values = url.Values{}
+		values.Set("client_id", client.ClientID)
+		if client.RequestObject.Publisher == nil {
+			values.Set("request", token)
+		} else {
+			requestURI, err := client.RequestObject.Publisher(token)
+			if err != nil {
+				client.err = err
+				return client
+			}
+			values.Set("request_uri", requestURI)
+		}
+		client.values = values
+	}
+	return client
+}
+
+// NewRequestObject return RequestObject signed with alg and key
+func NewRequestObject(alg string, key interface{}, opts ...RequestObjectOption) *RequestObject {
+	var ro = &RequestObject{SigningAlg: alg, SigningKey: key}
+	for _, opt := range opts {
+		opt(ro)
+	}
+	return ro
+}
+
+type (
+	// RequestObjectStore in memory store serve request objects by reference.
+	// Mount it on BaseURL and use Publish as RequestObject Publisher, the zero value is ready to use
+	RequestObjectStore struct {
+		BaseURL  string
+		Lifetime time.Duration
+
+		mu      sync.Mutex
+		objects map[string]storedRequestObject
+	}
+
+	storedRequestObject struct {
+		token     string
+		expiresAt time.Time
+	}
+)
+
+// Publish store the request object and return its request_uri
+func (store *RequestObjectStore) Publish(requestObject string) (string, error) {
+	id, err := utils.GenerateRandomString(24)
+	if err != nil {
+		return "", err
+	}
+	var lifetime = store.Lifetime
+	if lifetime <= 0 {
+		lifetime = 5 * time.Minute
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.objects == nil {
+		store.objects = make(map[string]storedRequestObject)
+	}
+	var now = time.Now()
+	for key, obj := range store.objects {
+		if now.After(obj.expiresAt) {
+			delete(store.objects, key)
+		}
+	}
+	store.objects[id] = storedRequestObject{token: requestObject, expiresAt: now.Add(lifetime)}
+	return strings.TrimRight(store.BaseURL, "/") + "/" + id, nil
+}
+
+func (store *RequestObjectStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var id = r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	store.mu.Lock()
+	obj, ok := store.objects[id]
+	store.mu.Unlock()
+	if !ok || time.Now().After(obj.expiresAt) {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/oauth-authz-req+jwt")
+	w.Write([]byte(obj.token))
+}
+
+// NewRequestObjectStore return RequestObjectStore serve on baseURL
+func NewRequestObjectStore(baseURL string) *RequestObjectStore {
+	return &RequestObjectStore{BaseURL: baseURL}
+}
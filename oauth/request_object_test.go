@@ -0,0 +1,126 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"github.com/demo007x/oauth2-client/jose"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func decodeRequestObject(t *testing.T, token string) map[string]interface{} {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("malformed request object %s", token)
+	}
+	payload, err := jose.Decode(parts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var claims map[string]interface{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatal(err)
+	}
+	return claims
+}
+
+func TestRequestObject(t *testing.T) {
+	signingKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newClient := func(opts ...RequestObjectOption) *Client {
+		opts = append([]RequestObjectOption{RequestObjectWithAudience("https://as.example.com"), RequestObjectWithKeyID("k1")}, opts...)
+		return NewOauth2Client(
+			"https://as.example.com/authorize",
+			"client",
+			WithScope("openid"),
+			WithState("xyz"),
+			WithNonce("n-0S6"),
+			WithRedirectURI("https://app.example.com/callback"),
+			WithClaims(map[string]interface{}{"userinfo": map[string]interface{}{"email": nil}}),
+			WithRequestObject(NewRequestObject(jose.RS256, signingKey, opts...)),
+		)
+	}
+
+	authURL, err := newClient().AuthorizeURL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(authURL)
+	query := u.Query()
+	if len(query) != 2 || query.Get("client_id") != "client" {
+		t.Fatalf("unexpected authorize url %s", authURL)
+	}
+	claims := decodeRequestObject(t, query.Get("request"))
+	for key, want := range map[string]string{"iss": "client", "aud": "https://as.example.com", "scope": "openid", "state": "xyz", "nonce": "n-0S6", "redirect_uri": "https://app.example.com/callback", "response_type": "code"} {
+		if claims[key] != want {
+			t.Errorf("claim %s = %v, want %s", key, claims[key], want)
+		}
+	}
+	if _, ok := claims["claims"].(map[string]interface{}); !ok {
+		t.Errorf("claims should be a json object, got %v", claims["claims"])
+	}
+
+	// encrypted request object
+	authURL, err = newClient(RequestObjectWithEncryption(jose.RSAOAEP256, jose.A256GCM, &serverKey.PublicKey, "enc1")).AuthorizeURL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ = url.Parse(authURL)
+	nested, err := jose.Decrypt(u.Query().Get("request"), serverKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decodeRequestObject(t, string(nested))["state"] != "xyz" {
+		t.Error("unexpected nested request object")
+	}
+
+	// request object by reference
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	store := NewRequestObjectStore(server.URL + "/request")
+	mux.Handle("/request/", store)
+
+	authURL, err = newClient(RequestObjectWithPublisher(store.Publish)).AuthorizeURL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ = url.Parse(authURL)
+	resp, err := http.Get(u.Query().Get("request_uri"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || decodeRequestObject(t, string(data))["client_id"] != "client" {
+		t.Errorf("unexpected request object response %d %s", resp.StatusCode, data)
+	}
+}
+
+func TestRequestObjectStoreZeroValue(t *testing.T) {
+	var store = &RequestObjectStore{BaseURL: "https://client.example.com/request/"}
+	requestURI, err := store.Publish("eyJhbGciOiJub25lIn0.e30.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(requestURI, "https://client.example.com/request/") {
+		t.Errorf("unexpected request_uri %s", requestURI)
+	}
+	var recorder = httptest.NewRecorder()
+	store.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, requestURI, nil))
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "eyJhbGciOiJub25lIn0.e30." {
+		t.Errorf("unexpected request object response %d %s", recorder.Code, recorder.Body.String())
+	}
+}
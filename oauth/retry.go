@@ -0,0 +1,172 @@
+package oauth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type (
+	RetryPolicyOption func(p *RetryPolicy)
+	// RetryPolicy retry transient failures with jittered exponential backoff.
+	// Introspection, revocation, userinfo and discovery retry dial failures, 429 and 5xx.
+	// An authorization code is consumed by the first request and is never retried.
+	// Other grants, eg: refresh with rotated refresh tokens, only retry dial failures,
+	// the server may have processed a request answered with 5xx
+	RetryPolicy struct {
+		// MaxAttempts include the first request
+		MaxAttempts int
+		BaseDelay   time.Duration
+		MaxDelay    time.Duration
+		// MaxRetryAfter give up when server ask to wait longer than it
+		MaxRetryAfter time.Duration
+		// Jitter randomly reduce each backoff by up to this fraction. 0 ~ 1
+		Jitter float64
+	}
+)
+
+func RetryPolicyWithMaxAttempts(maxAttempts int) RetryPolicyOption {
+	return func(p *RetryPolicy) {
+		p.MaxAttempts = maxAttempts
+	}
+}
+
+func RetryPolicyWithBackoff(baseDelay, maxDelay time.Duration) RetryPolicyOption {
+	return func(p *RetryPolicy) {
+		p.BaseDelay, p.MaxDelay = baseDelay, maxDelay
+	}
+}
+
+func RetryPolicyWithMaxRetryAfter(maxRetryAfter time.Duration) RetryPolicyOption {
+	return func(p *RetryPolicy) {
+		p.MaxRetryAfter = maxRetryAfter
+	}
+}
+
+func RetryPolicyWithJitter(jitter float64) RetryPolicyOption {
+	return func(p *RetryPolicy) {
+		p.Jitter = jitter
+	}
+}
+
+// Backoff delay before the next attempt. attempt start from 1
+func (p *RetryPolicy) Backoff(attempt int) time.Duration {
+	var delay = float64(p.BaseDelay) * math.Pow(2, float64(attempt-1))
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		delay -= delay * p.Jitter * rand.Float64()
+	}
+	return time.Duration(delay)
+}
+
+// retryable dial errors and temporary server status
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return dialFailed(err)
+	}
+	switch resp.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// dialFailed whether the connection failed before the request was written. Canceled requests,
+// certificate errors and failures after the request may have reached the server are excluded
+func dialFailed(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var (
+		unknownAuthority x509.UnknownAuthorityError
+		invalidCert      x509.CertificateInvalidError
+		hostname         x509.HostnameError
+		recordHeader     tls.RecordHeaderError
+	)
+	if errors.As(err, &unknownAuthority) || errors.As(err, &invalidCert) || errors.As(err, &hostname) || errors.As(err, &recordHeader) {
+		return false
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// retryAfter parse Retry-After seconds or http date
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	var value = resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date), true
+	}
+	return 0, false
+}
+
+// do call send until it succeed, fail permanently, attempts are used up or ctx is done.
+// nil policy send once. dialOnly retry only requests that never reached the server
+func (p *RetryPolicy) do(ctx context.Context, dialOnly bool, send func() (*http.Response, error)) (*http.Response, error) {
+	if p == nil {
+		return send()
+	}
+	var check = retryable
+	if dialOnly {
+		check = func(_ *http.Response, err error) bool { return dialFailed(err) }
+	}
+	var attempt = 1
+	for {
+		resp, err := send()
+		if attempt >= p.MaxAttempts || !check(resp, err) {
+			return resp, err
+		}
+
+		var delay = p.Backoff(attempt)
+		if wait, ok := retryAfter(resp); ok {
+			if p.MaxRetryAfter > 0 && wait > p.MaxRetryAfter {
+				return resp, err
+			}
+			delay = wait
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		attempt++
+	}
+}
+
+// NewRetryPolicy return RetryPolicy with three attempts by default
+func NewRetryPolicy(opts ...RetryPolicyOption) *RetryPolicy {
+	var p = &RetryPolicy{
+		MaxAttempts:   3,
+		BaseDelay:     200 * time.Millisecond,
+		MaxDelay:      5 * time.Second,
+		MaxRetryAfter: 30 * time.Second,
+		Jitter:        0.5,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
@@ -0,0 +1,141 @@
+package oauth
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	var calls = map[string]*int32{"/refresh": new(int32), "/token": new(int32), "/introspect": new(int32), "/discovery": new(int32), "/userinfo": new(int32)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(calls[r.URL.Path], 1)
+		switch r.URL.Path {
+		case "/refresh":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/userinfo":
+			if n < 3 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"access_token":"at"}`))
+		case "/token":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/introspect":
+			w.Header().Set("Retry-After", "120")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "/discovery":
+			if n == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.Write([]byte(`{"issuer":"https://as.example.com"}`))
+		}
+	}))
+	defer server.Close()
+
+	policy := NewRetryPolicy(RetryPolicyWithMaxAttempts(4), RetryPolicyWithBackoff(time.Millisecond, 5*time.Millisecond), RetryPolicyWithMaxRetryAfter(time.Second))
+
+	data, err := NewUserInfo(server.URL+"/userinfo", "at", UserInfoWithRetryPolicy(policy)).DoRequest()
+	if err != nil || string(data) != `{"access_token":"at"}` || *calls["/userinfo"] != 3 {
+		t.Errorf("userinfo should succeed on third attempt, calls %d, %s %v", *calls["/userinfo"], data, err)
+	}
+
+	// the server may have rotated the refresh token before answering 503
+	if _, err := NewRefreshToken(server.URL+"/refresh", "client", "secret", "rt", RefreshTokenWithRetryPolicy(policy)).DoRequest(); err != nil {
+		t.Error(err)
+	}
+	if *calls["/refresh"] != 1 {
+		t.Errorf("refresh answered with 503 retried, calls %d", *calls["/refresh"])
+	}
+
+	// authorization code is never retried, even with a retry policy
+	_, err = NewTokenEndpoint(server.URL+"/token", "client", "secret", TokenEndpointWithRetryPolicy(policy)).Do(&AuthorizationCodeGrant{Code: "code"})
+	if err == nil || *calls["/token"] != 1 {
+		t.Errorf("authorization code request attempted %d times: %v", *calls["/token"], err)
+	}
+
+	// Retry-After beyond the cap give up immediately
+	if _, err := NewIntrospectToken(server.URL+"/introspect", "client", "secret", "at", IntrospectTokenWithRetryPolicy(policy)).DoRequest(); err != nil {
+		t.Error(err)
+	}
+	if *calls["/introspect"] != 1 {
+		t.Errorf("introspection retried %d times beyond Retry-After cap", *calls["/introspect"])
+	}
+
+	md, err := NewDiscovery(server.URL+"/discovery", DiscoveryWithRetryPolicy(policy)).Metadata()
+	if err != nil || md.Issuer != "https://as.example.com" {
+		t.Errorf("discovery should succeed after bad gateway, %v", err)
+	}
+
+	jittered := NewRetryPolicy(RetryPolicyWithBackoff(100*time.Millisecond, 300*time.Millisecond), RetryPolicyWithJitter(0.5))
+	for attempt, max := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 5: 300 * time.Millisecond} {
+		if delay := jittered.Backoff(attempt); delay > max || delay < max/2 {
+			t.Errorf("attempt %d backoff %s out of range", attempt, delay)
+		}
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	for name, c := range map[string]struct {
+		err  error
+		want bool
+	}{
+		"connection refused": {&url.Error{Op: "Post", Err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}}, true},
+		"reset after write":  {&url.Error{Op: "Post", Err: &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}}, false},
+		"eof after write":    {&url.Error{Op: "Post", Err: io.EOF}, false},
+		"canceled":           {&url.Error{Op: "Post", Err: context.Canceled}, false},
+		"deadline":           {&url.Error{Op: "Post", Err: &net.OpError{Op: "dial", Err: context.DeadlineExceeded}}, false},
+		"unknown authority":  {&url.Error{Op: "Post", Err: x509.UnknownAuthorityError{}}, false},
+		"other":              {errors.New("boom"), false},
+	} {
+		if got := retryable(nil, c.err); got != c.want {
+			t.Errorf("%s: retryable = %v, want %v", name, got, c.want)
+		}
+	}
+}
+
+func TestRetryPolicyContext(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	policy := NewRetryPolicy(RetryPolicyWithMaxAttempts(5), RetryPolicyWithBackoff(time.Minute, time.Minute))
+	var start = time.Now()
+	_, err := NewIntrospectToken(server.URL, "client", "secret", "at", IntrospectTokenWithRetryPolicy(policy), IntrospectTokenWithContext(ctx)).DoRequest()
+	if !errors.Is(err, context.DeadlineExceeded) || time.Since(start) > 5*time.Second || atomic.LoadInt32(&calls) != 1 {
+		t.Errorf("backoff should stop on context deadline, calls %d after %s: %v", calls, time.Since(start), err)
+	}
+
+	// a refused connection never reached the server and is retried
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var addr = listener.Addr().String()
+	listener.Close()
+	var attempts int32
+	policy = NewRetryPolicy(RetryPolicyWithMaxAttempts(3), RetryPolicyWithBackoff(time.Millisecond, time.Millisecond))
+	NewDiscovery("http://"+addr, DiscoveryWithRetryPolicy(policy), DiscoveryWithInterceptors(func(*http.Request) error {
+		atomic.AddInt32(&attempts, 1)
+		return nil
+	})).Metadata()
+	if attempts != 3 {
+		t.Errorf("refused connection should be retried, attempts %d", attempts)
+	}
+}
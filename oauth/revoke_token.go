@@ -1,10 +1,10 @@
 package oauth
 
 import (
+	"context"
 	"github.com/demo007x/oauth2-client/errorx"
 	"github.com/demo007x/oauth2-client/types"
 	"github.com/demo007x/oauth2-client/utils"
-	"net/http"
 	"net/url"
 	"strings"
 )
@@ -18,16 +18,41 @@ type (
 		Secret        string
 		AccessToken   string
 		TokenTypeHint string
+		// AuthMethod client_secret_basic by default
+		AuthMethod  string
+		ContentType string
 
 		// internal field
-		u       *url.URL
-		values  url.Values
-		header  map[string]string
-		handler types.OauthResponseHandler
-		err     error
+		header       map[string]string
+		interceptors []utils.RequestInterceptor
+		observer     Observer
+		handler      types.OauthResponseHandler
+		ctx          context.Context
+		mtls         *MutualTLS
+	}
+
+	// revocation params of revocation request, it has no grant_type. RFC 7009 section 2.1
+	revocation struct {
+		token         string
+		tokenTypeHint string
 	}
 )
 
+func (r *revocation) GrantType() string {
+	return ""
+}
+
+func (r *revocation) Params() (url.Values, error) {
+	if strings.TrimSpace(r.token) == "" {
+		return nil, errorx.TokenEmptyError
+	}
+	var values = url.Values{"token": {r.token}, "token_type_hint": {r.tokenTypeHint}}
+	if strings.TrimSpace(r.tokenTypeHint) == "" {
+		values.Set("token_type_hint", "access_token")
+	}
+	return values, nil
+}
+
 func RevokeTokenWithServerURL(serverURL string) RevokeTokenOption {
 	return func(token *RevokeToken) {
 		token.ServerURL = serverURL
@@ -59,88 +84,80 @@ func RevokeTokenWithResponseHandler(handler types.OauthResponseHandler) RevokeTo
 	}
 }
 
+// RevokeTokenWithResponseMiddleware stack middlewares on the default response handler. eg: types.CheckStatus(nil)
+func RevokeTokenWithResponseMiddleware(middlewares ...types.ResponseMiddleware) RevokeTokenOption {
+	return RevokeTokenWithResponseHandler(types.ChainResponseHandler(middlewares...))
+}
+
+// RevokeTokenWithContentType set encoding of the params, form by default
 func RevokeTokenWithContentType(contentType string) RevokeTokenOption {
 	return func(token *RevokeToken) {
-		token.header["Content-Type"] = contentType
-		if strings.TrimSpace(contentType) == "" {
-			token.header["Content-Type"] = "application/json"
-		}
+		token.ContentType = contentType
 	}
 }
 
-func (ort *RevokeToken) setServerURL() *RevokeToken {
-	if ort.err == nil {
-		ort.u, ort.err = url.Parse(ort.ServerURL)
-		if ort.err == nil {
-			ort.values = ort.u.Query()
-		}
+// RevokeTokenWithAuthMethod set client authentication method. eg: client_secret_post
+func RevokeTokenWithAuthMethod(authMethod string) RevokeTokenOption {
+	return func(token *RevokeToken) {
+		token.AuthMethod = authMethod
 	}
-	return ort
 }
 
-func (ort *RevokeToken) setKeyAndSecret() *RevokeToken {
-	if ort.err == nil {
-		if strings.TrimSpace(ort.ClientID) == "" {
-			ort.err = errorx.ClientKeyError
-			return ort
-		}
-		if strings.TrimSpace(ort.Secret) == "" {
-			ort.err = errorx.SecretKeyError
-			return ort
-		}
-		// set header
-		ort.header["Authorization"] = utils.GenerateBaseAuthorization(ort.ClientID, ort.Secret)
+// RevokeTokenWithMutualTLS authenticate with client certificate
+func RevokeTokenWithMutualTLS(m *MutualTLS) RevokeTokenOption {
+	return func(token *RevokeToken) {
+		token.mtls = m
 	}
-	return ort
 }
 
-func (ort *RevokeToken) setAccessToken() *RevokeToken {
-	if ort.err == nil {
-		if strings.TrimSpace(ort.ClientID) == "" {
-			ort.err = errorx.ClientKeyError
-			return ort
-		}
-		ort.values.Set("token", ort.AccessToken)
+// RevokeTokenWithContext send the revocation request with context
+func RevokeTokenWithContext(ctx context.Context) RevokeTokenOption {
+	return func(token *RevokeToken) {
+		token.ctx = ctx
 	}
-	return ort
 }
 
-func (ort *RevokeToken) setTokenTypeHint() *RevokeToken {
-	if ort.err == nil {
-		ort.values.Set("token_type_hint", ort.TokenTypeHint)
-		if strings.TrimSpace(ort.TokenTypeHint) == "" {
-			ort.values.Set("token_type_hint", "access_token")
-		}
+// RevokeTokenWithInterceptors modify or audit the request before it is sent. eg: custom headers, signing
+func RevokeTokenWithInterceptors(interceptors ...utils.RequestInterceptor) RevokeTokenOption {
+	return func(token *RevokeToken) {
+		token.interceptors = append(token.interceptors, interceptors...)
 	}
-	return ort
 }
 
-func (ort *RevokeToken) DoRequest() ([]byte, error) {
-	if err := ort.setServerURL().
-		setKeyAndSecret().
-		setAccessToken().
-		setTokenTypeHint().
-		err; err != nil {
-		return nil, err
-	}
-	ort.u.RawQuery = ort.values.Encode()
-	var requestURL = ort.u.String()
-	resp, err := utils.DoRequest(requestURL, http.MethodPost, ort.header)
-	if err != nil {
-		return nil, err
+// RevokeTokenWithObserver report the call to observer. eg: PrometheusCollector
+func RevokeTokenWithObserver(observer Observer) RevokeTokenOption {
+	return func(token *RevokeToken) {
+		token.observer = observer
 	}
-	if ort.handler == nil {
-		return types.DefaultOauthResponseHandler(resp)
+}
+
+// endpoint token endpoint of the request
+func (ort *RevokeToken) endpoint() *TokenEndpoint {
+	return &TokenEndpoint{
+		ServerURL:    ort.ServerURL,
+		ClientID:     ort.ClientID,
+		Secret:       ort.Secret,
+		AuthMethod:   ort.AuthMethod,
+		ContentType:  ort.ContentType,
+		Header:       ort.header,
+		handler:      rawResponseHandler(ort.handler),
+		ctx:          ort.ctx,
+		mtls:         ort.mtls,
+		interceptors: ort.interceptors,
+		observer:     ort.observer,
+		kind:         EndpointRevocation,
 	}
-	return ort.handler(resp)
+}
+
+func (ort *RevokeToken) DoRequest() ([]byte, error) {
+	return ort.endpoint().Do(&revocation{token: ort.AccessToken, tokenTypeHint: ort.TokenTypeHint})
 }
 
 func NewOauthRevokeToken(serverURL, key, secret, accessToken string, opts ...RevokeTokenOption) *RevokeToken {
 	var token = &RevokeToken{
 		header: make(map[string]string),
-		values: url.Values{},
 	}
-	opts = append(opts, RevokeTokenWithServerURL(serverURL), RevokeTokenWithKeyAndSecret(key, secret), RevokeTokenWithAccessToken(accessToken), RevokeTokenWithContentType("application/json"))
+	opts = append(opts, RevokeTokenWithServerURL(serverURL), RevokeTokenWithKeyAndSecret(key, secret), RevokeTokenWithAccessToken(accessToken))
 	for _, opt := range opts {
 		opt(token)
 	}
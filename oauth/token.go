@@ -0,0 +1,97 @@
+package oauth
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/demo007x/oauth2-client/errorx"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Token access token response. RFC 6749 section 5.1
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int64  `json:"expires_in,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	// Expiry computed from expires_in when the token is parsed, zero when the lifetime is unknown.
+	// omitempty does not apply to structs, the zero time is encoded as 0001-01-01T00:00:00Z
+	Expiry time.Time `json:"expiry"`
+	// Raw all response fields, include provider specific ones
+	Raw map[string]interface{} `json:"-"`
+}
+
+// ParseToken parse json or form encoded token response.
+// Error response is returned as *errorx.OauthError
+func ParseToken(data []byte) (*Token, error) {
+	var raw = map[string]interface{}{}
+	var trimmed = bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		var decoder = json.NewDecoder(bytes.NewReader(trimmed))
+		decoder.UseNumber()
+		if err := decoder.Decode(&raw); err != nil {
+			return nil, err
+		}
+	} else {
+		values, err := url.ParseQuery(string(trimmed))
+		if err != nil {
+			return nil, err
+		}
+		for key := range values {
+			raw[key] = values.Get(key)
+		}
+	}
+
+	if code := stringClaim(raw, "error"); code != "" {
+		return nil, &errorx.OauthError{
+			StatusCode:  http.StatusBadRequest,
+			Code:        code,
+			Description: stringClaim(raw, "error_description"),
+			URI:         stringClaim(raw, "error_uri"),
+		}
+	}
+
+	var token = &Token{
+		AccessToken:  stringClaim(raw, "access_token"),
+		TokenType:    stringClaim(raw, "token_type"),
+		RefreshToken: stringClaim(raw, "refresh_token"),
+		Scope:        stringClaim(raw, "scope"),
+		IDToken:      stringClaim(raw, "id_token"),
+		Raw:          raw,
+	}
+	if strings.TrimSpace(token.AccessToken) == "" {
+		return nil, errorx.TokenEmptyError
+	}
+	// some providers return expires_in as string
+	if expiresIn, err := strconv.ParseInt(stringClaim(raw, "expires_in"), 10, 64); err == nil && expiresIn > 0 {
+		token.ExpiresIn = expiresIn
+		token.Expiry = time.Now().Add(time.Duration(expiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// Valid whether the access token is set and not expired
+func (t *Token) Valid() bool {
+	return t != nil && t.AccessToken != "" && (t.Expiry.IsZero() || time.Now().Before(t.Expiry))
+}
+
+// stringClaim read json string, number or bool as string
+func stringClaim(raw map[string]interface{}, key string) string {
+	switch val := raw[key].(type) {
+	case string:
+		return val
+	case json.Number:
+		return val.String()
+	case float64:
+		// maps decoded without UseNumber
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	}
+	return ""
+}
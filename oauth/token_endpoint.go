@@ -0,0 +1,374 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/demo007x/oauth2-client/errorx"
+	"github.com/demo007x/oauth2-client/types"
+	"github.com/demo007x/oauth2-client/utils"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Grant type values. RFC 6749 section 4
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeRefreshToken      = "refresh_token"
+	GrantTypeClientCredentials = "client_credentials"
+)
+
+type (
+	// Grant params of a token endpoint request.
+	// Implement it to send grants not provided by this package. eg: token exchange, device code
+	Grant interface {
+		// GrantType value of grant_type param, empty for requests without it. eg: revocation
+		GrantType() string
+		// Params grant specific params, return error when a required one is missing
+		Params() (url.Values, error)
+	}
+
+	// AuthorizationCodeGrant exchange authorization code for token. RFC 6749 section 4.1.3
+	AuthorizationCodeGrant struct {
+		Code        string
+		RedirectURI string
+		// CodeVerifier PKCE code verifier. RFC 7636
+		CodeVerifier string
+	}
+
+	// RefreshTokenGrant renew token with refresh token. RFC 6749 section 6
+	RefreshTokenGrant struct {
+		RefreshToken string
+		Scope        string
+	}
+
+	// ClientCredentialsGrant request token for the client itself. RFC 6749 section 4.4
+	ClientCredentialsGrant struct {
+		Scope string
+	}
+
+	TokenEndpointOption func(e *TokenEndpoint)
+	// TokenEndpoint engine shared by token, revocation and introspection requests.
+	// It authenticates the client, encodes the grant params, sends the request and parses error responses
+	TokenEndpoint struct {
+		ServerURL string
+		ClientID  string
+		Secret    string
+		// AuthMethod client_secret_basic by default
+		AuthMethod string
+		// Method http method, POST by default. GET sends the params as query
+		Method string
+		// ContentType form encoded by default, application/json sends the params as json object
+		ContentType string
+		Header      map[string]string
+		// Query custom params added after the grant params
+		Query map[string]string
+
+		// internal field
+		handler types.OauthResponseHandler
+		ctx     context.Context
+		dpop    *DPoP
+		mtls    *MutualTLS
+		retry   *RetryPolicy
+		// interceptors run on the built request before it is sent
+		interceptors []utils.RequestInterceptor
+		observer     Observer
+		// kind endpoint kind reported to observer, EndpointToken by default
+		kind string
+	}
+
+	// grantWithType send a grant with custom grant_type value
+	grantWithType struct {
+		Grant
+		grantType string
+	}
+)
+
+func (g *AuthorizationCodeGrant) GrantType() string {
+	return GrantTypeAuthorizationCode
+}
+
+func (g *AuthorizationCodeGrant) Params() (url.Values, error) {
+	if strings.TrimSpace(g.Code) == "" {
+		return nil, errorx.CodeEmptyError
+	}
+	var values = url.Values{"code": {g.Code}}
+	if strings.TrimSpace(g.RedirectURI) != "" {
+		values.Set("redirect_uri", g.RedirectURI)
+	}
+	if g.CodeVerifier != "" {
+		values.Set("code_verifier", g.CodeVerifier)
+	}
+	return values, nil
+}
+
+func (g *RefreshTokenGrant) GrantType() string {
+	return GrantTypeRefreshToken
+}
+
+func (g *RefreshTokenGrant) Params() (url.Values, error) {
+	if strings.TrimSpace(g.RefreshToken) == "" {
+		return nil, errorx.RefreshTokenNotEmpty
+	}
+	var values = url.Values{"refresh_token": {g.RefreshToken}}
+	if strings.TrimSpace(g.Scope) != "" {
+		values.Set("scope", g.Scope)
+	}
+	return values, nil
+}
+
+func (g *ClientCredentialsGrant) GrantType() string {
+	return GrantTypeClientCredentials
+}
+
+func (g *ClientCredentialsGrant) Params() (url.Values, error) {
+	var values = url.Values{}
+	if strings.TrimSpace(g.Scope) != "" {
+		values.Set("scope", g.Scope)
+	}
+	return values, nil
+}
+
+func (g *grantWithType) GrantType() string {
+	return g.grantType
+}
+
+// withGrantType override grant_type of the grant, keep it when empty
+func withGrantType(grant Grant, grantType string) Grant {
+	if strings.TrimSpace(grantType) == "" || grantType == grant.GrantType() {
+		return grant
+	}
+	return &grantWithType{Grant: grant, grantType: grantType}
+}
+
+// TokenEndpointWithAuthMethod set client authentication method. eg: client_secret_post
+func TokenEndpointWithAuthMethod(authMethod string) TokenEndpointOption {
+	return func(e *TokenEndpoint) {
+		e.AuthMethod = authMethod
+	}
+}
+
+// TokenEndpointWithMethod set http method of the request
+func TokenEndpointWithMethod(method string) TokenEndpointOption {
+	return func(e *TokenEndpoint) {
+		e.Method = method
+	}
+}
+
+// TokenEndpointWithContentType set encoding of the params. eg: application/json
+func TokenEndpointWithContentType(contentType string) TokenEndpointOption {
+	return func(e *TokenEndpoint) {
+		e.ContentType = contentType
+	}
+}
+
+// TokenEndpointWithHeader add custom request headers. eg: Accept
+func TokenEndpointWithHeader(header map[string]string) TokenEndpointOption {
+	return func(e *TokenEndpoint) {
+		for key, val := range header {
+			e.Header[key] = val
+		}
+	}
+}
+
+// TokenEndpointWithQuery custom params of the request. eg: appid=x&secret=y
+func TokenEndpointWithQuery(query map[string]string) TokenEndpointOption {
+	return func(e *TokenEndpoint) {
+		e.Query = query
+	}
+}
+
+// TokenEndpointWithResponseHandler custom response handler, error status is returned as *errorx.OauthError by default
+func TokenEndpointWithResponseHandler(handler types.OauthResponseHandler) TokenEndpointOption {
+	return func(e *TokenEndpoint) {
+		e.handler = handler
+	}
+}
+
+// TokenEndpointWithResponseMiddleware stack middlewares on the default response handler. eg: types.CheckStatus(nil), types.Gzip()
+func TokenEndpointWithResponseMiddleware(middlewares ...types.ResponseMiddleware) TokenEndpointOption {
+	return TokenEndpointWithResponseHandler(types.ChainResponseHandler(middlewares...))
+}
+
+// TokenEndpointWithContext send the request with context
+func TokenEndpointWithContext(ctx context.Context) TokenEndpointOption {
+	return func(e *TokenEndpoint) {
+		e.ctx = ctx
+	}
+}
+
+// TokenEndpointWithDPoP request a DPoP bound token
+func TokenEndpointWithDPoP(d *DPoP) TokenEndpointOption {
+	return func(e *TokenEndpoint) {
+		e.dpop = d
+	}
+}
+
+// TokenEndpointWithMutualTLS authenticate with client certificate
+func TokenEndpointWithMutualTLS(m *MutualTLS) TokenEndpointOption {
+	return func(e *TokenEndpoint) {
+		e.mtls = m
+	}
+}
+
+// TokenEndpointWithRetryPolicy retry transient failures of the request.
+// authorization_code is never retried, other grants only retry dial failures
+func TokenEndpointWithRetryPolicy(policy *RetryPolicy) TokenEndpointOption {
+	return func(e *TokenEndpoint) {
+		e.retry = policy
+	}
+}
+
+// TokenEndpointWithInterceptors modify or audit the request before it is sent. eg: utils.SetHeader("User-Agent", "app/1.0")
+func TokenEndpointWithInterceptors(interceptors ...utils.RequestInterceptor) TokenEndpointOption {
+	return func(e *TokenEndpoint) {
+		e.interceptors = append(e.interceptors, interceptors...)
+	}
+}
+
+// TokenEndpointWithObserver report every call to observer. eg: PrometheusCollector
+func TokenEndpointWithObserver(observer Observer) TokenEndpointOption {
+	return func(e *TokenEndpoint) {
+		e.observer = observer
+	}
+}
+
+// params client credentials, grant params and custom params of the request
+func (e *TokenEndpoint) params(grant Grant, header map[string]string) (url.Values, error) {
+	var values = url.Values{}
+	if err := authenticate(authMethod(e.AuthMethod, e.mtls), e.ClientID, e.Secret, values, header); err != nil {
+		return nil, err
+	}
+	if grantType := grant.GrantType(); grantType != "" {
+		values.Set("grant_type", grantType)
+	}
+	params, err := grant.Params()
+	if err != nil {
+		return nil, err
+	}
+	for key := range params {
+		values[key] = params[key]
+	}
+	for key, val := range e.Query {
+		values.Set(key, val)
+	}
+	return values, nil
+}
+
+// encode params as form or json body by content type
+func (e *TokenEndpoint) encode(values url.Values) (string, []byte, error) {
+	var contentType = strings.TrimSpace(e.ContentType)
+	if contentType == "" {
+		return "application/x-www-form-urlencoded", []byte(values.Encode()), nil
+	}
+	if !strings.HasPrefix(contentType, "application/json") {
+		return contentType, []byte(values.Encode()), nil
+	}
+	var object = make(map[string]string, len(values))
+	for key := range values {
+		object[key] = values.Get(key)
+	}
+	data, err := json.Marshal(object)
+	return contentType, data, err
+}
+
+// Do send the grant and return response body
+func (e *TokenEndpoint) Do(grant Grant) ([]byte, error) {
+	if grant == nil {
+		return nil, errorx.GrantEmptyError
+	}
+	u, err := url.Parse(e.mtls.endpoint(e.ServerURL))
+	if err != nil {
+		return nil, err
+	}
+	// copy header, so the endpoint can be shared between goroutines
+	var header = make(map[string]string, len(e.Header)+2)
+	for key, val := range e.Header {
+		header[key] = val
+	}
+	values, err := e.params(grant, header)
+	if err != nil {
+		return nil, err
+	}
+
+	var method = http.MethodPost
+	if strings.TrimSpace(e.Method) != "" {
+		method = e.Method
+	}
+	var opts = append(e.mtls.requestOptions(), utils.RequestWithContext(e.ctx), utils.RequestWithInterceptors(e.interceptors...))
+	if method == http.MethodGet {
+		var query = u.Query()
+		for key := range values {
+			query[key] = values[key]
+		}
+		u.RawQuery = query.Encode()
+	} else {
+		contentType, body, err := e.encode(values)
+		if err != nil {
+			return nil, err
+		}
+		header["Content-Type"] = contentType
+		opts = append(opts, utils.RequestWithBodyBytes(body))
+	}
+
+	var requestURL = u.String()
+	var handler = e.handler
+	if handler == nil {
+		handler = statusResponseHandler
+	}
+	var c = &call{ctx: e.ctx, endpoint: e.kind, grantType: grant.GrantType(), retry: e.retry, observer: e.observer}
+	switch c.grantType {
+	case "":
+		// introspection and revocation are safe to replay
+	case GrantTypeAuthorizationCode:
+		// the code may be consumed by a request answered with 5xx, never replay it
+		c.retry = nil
+	default:
+		c.dialOnly = true
+	}
+	if c.endpoint == "" {
+		c.endpoint = EndpointToken
+	}
+	_, data, err := c.send(func(trace ...utils.RequestOption) (*http.Response, error) {
+		var opts = append(opts[:len(opts):len(opts)], trace...)
+		if e.dpop != nil {
+			return e.dpop.do(requestURL, method, "", header, opts...)
+		}
+		return utils.DoRequest(requestURL, method, header, opts...)
+	}, handler)
+	return data, err
+}
+
+// Token send the grant and parse the token response
+func (e *TokenEndpoint) Token(grant Grant) (*Token, error) {
+	data, err := e.Do(grant)
+	if err != nil {
+		return nil, err
+	}
+	return ParseToken(data)
+}
+
+// statusResponseHandler read body and return error status as *errorx.OauthError
+var statusResponseHandler = types.ChainResponseHandler(types.CheckStatus(nil))
+
+// rawResponseHandler DoRequest of the request types return body of any status unless a handler is set
+func rawResponseHandler(handler types.OauthResponseHandler) types.OauthResponseHandler {
+	if handler == nil {
+		return types.DefaultOauthResponseHandler
+	}
+	return handler
+}
+
+// NewTokenEndpoint return TokenEndpoint of the server url
+func NewTokenEndpoint(serverURL, clientID, secret string, opts ...TokenEndpointOption) *TokenEndpoint {
+	var e = &TokenEndpoint{
+		ServerURL: serverURL,
+		ClientID:  clientID,
+		Secret:    secret,
+		Header:    make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
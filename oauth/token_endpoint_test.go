@@ -0,0 +1,108 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/demo007x/oauth2-client/errorx"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// deviceCodeGrant grant implemented outside the built-in ones. RFC 8628 section 3.4
+type deviceCodeGrant struct {
+	deviceCode string
+}
+
+func (g *deviceCodeGrant) GrantType() string {
+	return "urn:ietf:params:oauth:grant-type:device_code"
+}
+
+func (g *deviceCodeGrant) Params() (url.Values, error) {
+	return url.Values{"device_code": {g.deviceCode}}, nil
+}
+
+func TestTokenEndpoint(t *testing.T) {
+	var last url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		last = url.Values{}
+		switch {
+		case r.Header.Get("Content-Type") == "application/json":
+			var object map[string]string
+			json.NewDecoder(r.Body).Decode(&object)
+			for key, val := range object {
+				last.Set(key, val)
+			}
+		case r.Method == http.MethodGet:
+			last = r.URL.Query()
+		default:
+			r.ParseForm()
+			last = r.PostForm
+			if len(r.URL.Query()) > 0 {
+				t.Errorf("params must be sent in body, got query %s", r.URL.RawQuery)
+			}
+		}
+		if user, _, ok := r.BasicAuth(); ok {
+			last.Set("basic", user)
+		}
+		switch last.Get("grant_type") {
+		case "urn:ietf:params:oauth:grant-type:device_code":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"authorization_pending"}`))
+		default:
+			w.Write([]byte(`{"access_token":"AT","token_type":"Bearer"}`))
+		}
+	}))
+	defer server.Close()
+
+	// custom grant type is kept
+	if _, err := NewAccessToken(server.URL, "client", "secret", "code", AccessTokenWithGrantType("urn:example:custom")).DoRequest(); err != nil {
+		t.Fatal(err)
+	}
+	if last.Get("grant_type") != "urn:example:custom" || last.Get("code") != "code" || last.Get("basic") != "client" {
+		t.Errorf("unexpected access token params %v", last)
+	}
+	// grant type is authorization_code by default
+	if _, err := NewAccessToken(server.URL, "client", "secret", "code").DoRequest(); err != nil || last.Get("grant_type") != "authorization_code" {
+		t.Errorf("unexpected default grant type %v %v", last, err)
+	}
+
+	// refresh honors content type and auth method
+	if _, err := NewRefreshToken(server.URL, "client", "secret", "rt", RefreshTokenWithContentType("application/json"), RefreshTokenWithAuthMethod(AuthMethodClientSecretPost)).DoRequest(); err != nil {
+		t.Fatal(err)
+	}
+	if last.Get("grant_type") != "refresh_token" || last.Get("refresh_token") != "rt" || last.Get("client_secret") != "secret" {
+		t.Errorf("unexpected refresh params %v", last)
+	}
+
+	// GET sends params as query
+	if _, err := NewRefreshToken(server.URL+"?appid=app", "client", "secret", "rt", RefreshTokenWithMethod(http.MethodGet)).DoRequest(); err != nil || last.Get("appid") != "app" || last.Get("refresh_token") != "rt" {
+		t.Errorf("unexpected query params %v %v", last, err)
+	}
+
+	// revocation validates the token, not the client id
+	if _, err := NewOauthRevokeToken(server.URL, "client", "secret", "").DoRequest(); err != errorx.TokenEmptyError {
+		t.Errorf("expected TokenEmptyError, got %v", err)
+	}
+	if _, err := NewOauthRevokeToken(server.URL, "client", "secret", "at").DoRequest(); err != nil || last.Get("token") != "at" || last.Get("grant_type") != "" {
+		t.Errorf("unexpected revocation params %v %v", last, err)
+	}
+
+	config := NewConfig(Endpoint{TokenURL: server.URL}, "client", ConfigWithSecret("secret"))
+	token, err := config.Grant(context.Background(), &ClientCredentialsGrant{Scope: "read"})
+	if err != nil || token.AccessToken != "AT" || last.Get("scope") != "read" {
+		t.Errorf("unexpected client credentials token %+v %v", token, err)
+	}
+	var oe *errorx.OauthError
+	if _, err := config.Grant(context.Background(), &deviceCodeGrant{deviceCode: "dc"}); !errors.As(err, &oe) || oe.Code != "authorization_pending" {
+		t.Errorf("expected authorization_pending, got %v", err)
+	}
+	if last.Get("device_code") != "dc" {
+		t.Errorf("unexpected device code params %v", last)
+	}
+	if _, err := config.Grant(context.Background(), nil); err != errorx.GrantEmptyError {
+		t.Errorf("expected GrantEmptyError, got %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package oauth
+
+import (
+	"encoding/json"
+	"github.com/demo007x/oauth2-client/errorx"
+	"testing"
+)
+
+func TestParseToken(t *testing.T) {
+	token, err := ParseToken([]byte(`{"access_token":"at","token_type":"Bearer","expires_in":3600,"refresh_token":"rt","openid":"o1"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "at" || token.RefreshToken != "rt" || token.ExpiresIn != 3600 || !token.Valid() || stringClaim(token.Raw, "openid") != "o1" {
+		t.Errorf("unexpected json token %+v", token)
+	}
+
+	// github form encoded response
+	token, err = ParseToken([]byte("access_token=gho_abc&scope=user&token_type=bearer&expires_in=\"7200\""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "gho_abc" || token.Scope != "user" {
+		t.Errorf("unexpected form token %+v", token)
+	}
+
+	_, err = ParseToken([]byte(`{"error":"invalid_grant","error_description":"code expired"}`))
+	if oe, ok := err.(*errorx.OauthError); !ok || oe.Code != "invalid_grant" {
+		t.Errorf("expected invalid_grant, got %v", err)
+	}
+	_, err = ParseToken([]byte("error=bad_verification_code"))
+	if oe, ok := err.(*errorx.OauthError); !ok || oe.Code != "bad_verification_code" {
+		t.Errorf("expected bad_verification_code, got %v", err)
+	}
+}
+
+func TestTokenJSON(t *testing.T) {
+	data, err := json.Marshal(&Token{AccessToken: "at"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var token = &Token{}
+	if err := json.Unmarshal(data, token); err != nil || !token.Expiry.IsZero() || !token.Valid() {
+		t.Errorf("token without lifetime should stay valid after a round trip: %s %v", data, err)
+	}
+}
@@ -1,11 +1,13 @@
 package oauth
 
 import (
+	"context"
 	"github.com/demo007x/oauth2-client/errorx"
 	"github.com/demo007x/oauth2-client/types"
 	"github.com/demo007x/oauth2-client/utils"
 	"net/http"
 	"net/url"
+	"strings"
 )
 
 type (
@@ -13,11 +15,21 @@ type (
 	UserInfo           struct {
 		AccessToken string
 		ServerURL   string
+		// Method http method of userinfo request, POST by default
+		Method string
 
 		// internal field
 		handler types.OauthResponseHandler
+		mapper  ProfileMapper
 		header  map[string]string
+		dpop    *DPoP
+		mtls    *MutualTLS
+		retry   *RetryPolicy
+		ctx     context.Context
 		err     error
+		// interceptors run on the built request before it is sent
+		interceptors []utils.RequestInterceptor
+		observer     Observer
 	}
 )
 
@@ -39,11 +51,81 @@ func UserInfoWithResponseHandler(handler types.OauthResponseHandler) WithUserInf
 	}
 }
 
+// UserInfoWithResponseMiddleware stack middlewares on the default response handler. eg: types.CheckStatus(nil), types.DecodeBody()
+func UserInfoWithResponseMiddleware(middlewares ...types.ResponseMiddleware) WithUserInfoOption {
+	return UserInfoWithResponseHandler(types.ChainResponseHandler(middlewares...))
+}
+
+// UserInfoWithDPoP call userinfo with DPoP bound access token
+func UserInfoWithDPoP(d *DPoP) WithUserInfoOption {
+	return func(info *UserInfo) {
+		info.dpop = d
+	}
+}
+
+// UserInfoWithMutualTLS call userinfo with certificate bound access token
+func UserInfoWithMutualTLS(m *MutualTLS) WithUserInfoOption {
+	return func(info *UserInfo) {
+		info.mtls = m
+	}
+}
+
+// UserInfoWithRetryPolicy retry transient failures of the userinfo request
+func UserInfoWithRetryPolicy(policy *RetryPolicy) WithUserInfoOption {
+	return func(info *UserInfo) {
+		info.retry = policy
+	}
+}
+
+// UserInfoWithContext send the userinfo request with context
+func UserInfoWithContext(ctx context.Context) WithUserInfoOption {
+	return func(info *UserInfo) {
+		info.ctx = ctx
+	}
+}
+
+// UserInfoWithMethod set http method of userinfo request. eg: GET
+func UserInfoWithMethod(method string) WithUserInfoOption {
+	return func(info *UserInfo) {
+		info.Method = method
+	}
+}
+
+// UserInfoWithHeader add custom request headers. eg: provider specific token header
+func UserInfoWithHeader(header map[string]string) WithUserInfoOption {
+	return func(info *UserInfo) {
+		for key, val := range header {
+			info.header[key] = val
+		}
+	}
+}
+
+// UserInfoWithProfileMapper map userinfo response to Profile, OIDCProfileMapper by default
+func UserInfoWithProfileMapper(mapper ProfileMapper) WithUserInfoOption {
+	return func(info *UserInfo) {
+		info.mapper = mapper
+	}
+}
+
+// UserInfoWithInterceptors modify or audit the userinfo request before it is sent. eg: tenant header
+func UserInfoWithInterceptors(interceptors ...utils.RequestInterceptor) WithUserInfoOption {
+	return func(info *UserInfo) {
+		info.interceptors = append(info.interceptors, interceptors...)
+	}
+}
+
+// UserInfoWithObserver report the userinfo call to observer
+func UserInfoWithObserver(observer Observer) WithUserInfoOption {
+	return func(info *UserInfo) {
+		info.observer = observer
+	}
+}
+
 // setServerURL set server url invalid
 // todo 统一url的验证函数
 func (info *UserInfo) setServerURL() *UserInfo {
 	if info.err == nil {
-		_, err := url.Parse(info.ServerURL)
+		_, err := url.Parse(info.mtls.endpoint(info.ServerURL))
 		info.err = err
 	}
 	return info
@@ -51,6 +133,10 @@ func (info *UserInfo) setServerURL() *UserInfo {
 
 func (info *UserInfo) setToken() *UserInfo {
 	if info.err == nil {
+		if info.dpop != nil {
+			info.header["Authorization"] = "DPoP " + info.AccessToken
+			return info
+		}
 		info.header["Authorization"] = utils.GenerateBearAuthorization(info.AccessToken)
 	}
 	return info
@@ -61,14 +147,40 @@ func (info *UserInfo) DoRequest() ([]byte, error) {
 	if err := info.setServerURL().setToken().err; err != nil {
 		return nil, err
 	}
-	resp, err := utils.DoRequest(info.ServerURL, http.MethodPost, info.header)
-	if err != nil {
+	var serverURL = info.mtls.endpoint(info.ServerURL)
+	var method = http.MethodPost
+	if strings.TrimSpace(info.Method) != "" {
+		method = info.Method
+	}
+	var opts = append(info.mtls.requestOptions(), utils.RequestWithContext(info.ctx), utils.RequestWithInterceptors(info.interceptors...))
+	var handler = info.handler
+	if handler == nil {
+		handler = types.DefaultOauthResponseHandler
+	}
+	var c = &call{ctx: info.ctx, endpoint: EndpointUserInfo, retry: info.retry, observer: info.observer}
+	resp, data, err := c.send(func(trace ...utils.RequestOption) (*http.Response, error) {
+		var opts = append(opts[:len(opts):len(opts)], trace...)
+		if info.dpop != nil {
+			return info.dpop.do(serverURL, method, info.AccessToken, info.header, opts...)
+		}
+		return utils.DoRequest(serverURL, method, info.header, opts...)
+	}, handler)
+	if err != nil && resp == nil {
+		if info.ctx != nil && info.ctx.Err() != nil {
+			return nil, info.ctx.Err()
+		}
 		return nil, errorx.RequestServerURLError
 	}
-	if info.handler == nil {
-		return types.DefaultOauthResponseHandler(resp)
+	return data, err
+}
+
+// Profile request user info and map it to normalized Profile
+func (info *UserInfo) Profile() (*Profile, error) {
+	data, err := info.DoRequest()
+	if err != nil {
+		return nil, err
 	}
-	return info.handler(resp)
+	return ParseProfile(data, info.mapper)
 }
 
 func NewUserInfo(serverURL, accessToken string, opts ...WithUserInfoOption) *UserInfo {
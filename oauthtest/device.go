@@ -0,0 +1,119 @@
+package oauthtest
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// GrantTypeDeviceCode device authorization grant type. RFC 8628
+const GrantTypeDeviceCode = "urn:ietf:params:oauth:grant-type:device_code"
+
+const (
+	deviceCodeLifetime = 10 * time.Minute
+	devicePollInterval = 5 * time.Second
+)
+
+// deviceAuthorization pending device authorization, the user approve or deny it with the user code
+type deviceAuthorization struct {
+	clientID string
+	userCode string
+	scope    string
+	subject  string
+	denied   bool
+	expiry   time.Time
+	interval time.Duration
+	lastPoll time.Time
+}
+
+// ApproveDevice approve the device authorization of the user code for the user.
+// It report false when the user code is unknown
+func (s *Server) ApproveDevice(userCode, subject string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range s.devices {
+		if d.userCode == userCode {
+			d.subject = subject
+			return true
+		}
+	}
+	return false
+}
+
+// DenyDevice deny the device authorization of the user code
+func (s *Server) DenyDevice(userCode string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range s.devices {
+		if d.userCode == userCode {
+			d.denied = true
+			return true
+		}
+	}
+	return false
+}
+
+// deviceAuthorization issue device code and user code. RFC 8628 section 3.1
+func (s *Server) deviceAuthorization(w http.ResponseWriter, r *http.Request) {
+	params, err := readParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	client, ok := s.authenticate(w, r, params)
+	if !ok {
+		return
+	}
+	var deviceCode = randomString()
+	var userCode = strings.ToUpper(randomString()[:8])
+	s.devices[deviceCode] = &deviceAuthorization{
+		clientID: client.ID,
+		userCode: userCode,
+		scope:    params.Get("scope"),
+		expiry:   s.now().Add(deviceCodeLifetime),
+		interval: devicePollInterval,
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"device_code":               deviceCode,
+		"user_code":                 userCode,
+		"verification_uri":          s.URL + "/device",
+		"verification_uri_complete": s.URL + "/device?user_code=" + userCode,
+		"expires_in":                int64(deviceCodeLifetime / time.Second),
+		"interval":                  int64(devicePollInterval / time.Second),
+	})
+}
+
+// pollDevice answer the device code grant. Polling faster than the interval
+// get slow_down and a 5 seconds longer interval. RFC 8628 section 3.5
+func (s *Server) pollDevice(w http.ResponseWriter, client *Client, deviceCode string) (map[string]interface{}, bool) {
+	d, found := s.devices[deviceCode]
+	if !found || d.clientID != client.ID {
+		writeError(w, http.StatusBadRequest, "invalid_grant", "device code is invalid")
+		return nil, false
+	}
+	var now = s.now()
+	if now.After(d.expiry) {
+		delete(s.devices, deviceCode)
+		writeError(w, http.StatusBadRequest, "expired_token", "device code is expired")
+		return nil, false
+	}
+	if d.denied {
+		delete(s.devices, deviceCode)
+		writeError(w, http.StatusBadRequest, "access_denied", "")
+		return nil, false
+	}
+	var tooFast = !d.lastPoll.IsZero() && now.Sub(d.lastPoll) < d.interval
+	d.lastPoll = now
+	if tooFast {
+		d.interval += devicePollInterval
+		writeError(w, http.StatusBadRequest, "slow_down", "")
+		return nil, false
+	}
+	if d.subject == "" {
+		writeError(w, http.StatusBadRequest, "authorization_pending", "")
+		return nil, false
+	}
+	delete(s.devices, deviceCode)
+	var refreshToken = randomString()
+	return s.issue(grant{clientID: client.ID, subject: d.subject, scope: d.scope, family: refreshToken}, "", refreshToken), true
+}
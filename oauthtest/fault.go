@@ -0,0 +1,118 @@
+package oauthtest
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+type (
+	// Fault scripted misbehavior of one request. Fields combine, eg: a slow 503
+	Fault struct {
+		// Delay wait before answering, canceled with the request context. eg: exceed the client timeout
+		Delay time.Duration
+		// Error answer with the oauth error response, the authorize endpoint redirect it
+		Error *ErrorResponse
+		// StatusCode and Body answer with the raw response instead of the endpoint.
+		// eg: 502 html page, malformed json with status 200
+		StatusCode int
+		Body       string
+		// ContentType override Content-Type of the response. eg: text/html
+		ContentType string
+		// InvalidSignature sign the issued id token with a key missing from the jwks
+		InvalidSignature bool
+	}
+
+	// contentTypeWriter override Content-Type of the response
+	contentTypeWriter struct {
+		http.ResponseWriter
+		contentType string
+		wroteHeader bool
+	}
+)
+
+func (w *contentTypeWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.Header().Set("Content-Type", w.contentType)
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *contentTypeWriter) Write(data []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// InjectFault apply the fault to the next request of the endpoint.
+// Faults of the same endpoint are used in order. eg: InjectFault(TokenPath, Fault{StatusCode: 503})
+func (s *Server) InjectFault(path string, f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[path] = append(s.faults[path], f)
+}
+
+// popFault next injected fault of the endpoint
+func (s *Server) popFault(path string) (Fault, bool) {
+	var queue = s.faults[path]
+	if len(queue) == 0 {
+		return Fault{}, false
+	}
+	s.faults[path] = queue[1:]
+	return queue[0], true
+}
+
+// SetClockSkew shift the server clock, issued tokens carry skewed iat and exp. eg: -5 * time.Minute
+func (s *Server) SetClockSkew(skew time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.skew = skew
+}
+
+// RotateKey sign with a new key from now on. The previous keys stay in the jwks
+// when keepPrevious is set, otherwise tokens signed by them no longer verify
+func (s *Server) RotateKey(keepPrevious bool) {
+	var key = newSigningKey()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !keepPrevious {
+		s.keys = nil
+	}
+	s.keys = append(s.keys, key)
+}
+
+// signingKey key of the issued id token, a rogue key with the published kid for InvalidSignature faults
+func (s *Server) signingKey() *signingKey {
+	var current = s.keys[len(s.keys)-1]
+	if !s.fault.InvalidSignature {
+		return current
+	}
+	if s.rogueKey == nil {
+		s.rogueKey = newSigningKey()
+	}
+	return &signingKey{key: s.rogueKey.key, kid: current.kid}
+}
+
+// writeFault answer with the raw response of the fault
+func writeFault(w http.ResponseWriter, f Fault) {
+	var statusCode = f.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+	var contentType = f.ContentType
+	if contentType == "" {
+		contentType = "text/plain; charset=utf-8"
+		if strings.HasPrefix(strings.TrimSpace(f.Body), "{") {
+			contentType = "application/json"
+		}
+	}
+	var body = f.Body
+	if body == "" {
+		body = http.StatusText(statusCode)
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(statusCode)
+	w.Write([]byte(body))
+}
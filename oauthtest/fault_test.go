@@ -0,0 +1,251 @@
+package oauthtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/demo007x/oauth2-client/errorx"
+	"github.com/demo007x/oauth2-client/jose"
+	"github.com/demo007x/oauth2-client/oauth"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+type deviceCodeGrant string
+
+func (g deviceCodeGrant) GrantType() string {
+	return GrantTypeDeviceCode
+}
+
+func (g deviceCodeGrant) Params() (url.Values, error) {
+	return url.Values{"device_code": {string(g)}}, nil
+}
+
+func oauthErrorCode(err error) string {
+	var oe *errorx.OauthError
+	if errors.As(err, &oe) {
+		return oe.Code
+	}
+	return ""
+}
+
+// exchange run the authorize step and exchange the code
+func exchange(t *testing.T, srv *Server, config *oauth.Config) (string, *oauth.Token) {
+	authURL, err := config.AuthCodeURL("state", oauth.WithNonce("nonce"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	callback, err := srv.Authorize(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var code = callback.Query().Get("code")
+	token, err := config.Exchange(context.Background(), code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code, token
+}
+
+func TestFaultTransport(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	var ctx = context.Background()
+	accessToken, refreshToken := srv.IssueToken(DefaultClientID, DefaultSubject, "openid")
+	var policy = oauth.NewRetryPolicy(oauth.RetryPolicyWithMaxAttempts(3), oauth.RetryPolicyWithBackoff(time.Millisecond, time.Millisecond))
+	var config = oauth.NewConfig(oauth.Endpoint{TokenURL: srv.TokenURL()}, DefaultClientID,
+		oauth.ConfigWithSecret(DefaultClientSecret),
+		oauth.ConfigWithRetryPolicy(policy),
+	)
+	var introspect = func() (*oauth.Introspection, error) {
+		return oauth.NewIntrospectToken(srv.IntrospectionURL(), DefaultClientID, DefaultClientSecret, accessToken,
+			oauth.IntrospectTokenWithRetryPolicy(policy)).Introspect()
+	}
+
+	srv.InjectFault(IntrospectionPath, Fault{StatusCode: http.StatusServiceUnavailable})
+	srv.InjectFault(IntrospectionPath, Fault{StatusCode: http.StatusBadGateway, ContentType: "text/html", Body: "<html>bad gateway</html>"})
+	if introspection, err := introspect(); err != nil || !introspection.Active {
+		t.Errorf("expected retry to recover from 5xx, got %v", err)
+	}
+
+	// a refresh answered with 5xx may have been processed, it is not replayed
+	srv.InjectFault(TokenPath, Fault{StatusCode: http.StatusServiceUnavailable})
+	if _, err := config.Refresh(ctx, refreshToken); err == nil {
+		t.Error("expected refresh 5xx not retried")
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	srv.InjectFault(TokenPath, Fault{Delay: time.Second})
+	if _, err := config.Refresh(timeout, refreshToken); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+
+	srv.InjectFault(TokenPath, Fault{Body: `{"access_token":"at",`})
+	if _, err := config.Refresh(ctx, refreshToken); err == nil {
+		t.Error("expected malformed json error")
+	}
+
+	srv.InjectFault(TokenPath, Fault{ContentType: "text/html"})
+	if token, err := config.Refresh(ctx, refreshToken); err != nil || token.AccessToken == "" {
+		t.Errorf("expected json body with wrong content type to parse, got %v", err)
+	}
+
+	srv.InjectError(IntrospectionPath, ErrorResponse{StatusCode: http.StatusTooManyRequests, Code: "slow_down"})
+	srv.InjectError(IntrospectionPath, ErrorResponse{StatusCode: http.StatusTooManyRequests, Code: "slow_down"})
+	srv.InjectError(IntrospectionPath, ErrorResponse{StatusCode: http.StatusTooManyRequests, Code: "slow_down"})
+	if _, err := introspect(); oauthErrorCode(err) != "slow_down" {
+		t.Errorf("expected slow_down after the retries, got %v", err)
+	}
+}
+
+func TestFaultCodesAndRefreshTokens(t *testing.T) {
+	srv := NewServer(ServerWithRefreshTokenRotation())
+	defer srv.Close()
+	var ctx = context.Background()
+	var config = newConfig(srv)
+	var introspect = func(token string) bool {
+		introspection, err := oauth.NewIntrospectToken(srv.IntrospectionURL(), DefaultClientID, DefaultClientSecret, token).Introspect()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return introspection.Active
+	}
+
+	code, token := exchange(t, srv, config)
+	if _, err := config.Exchange(ctx, code); oauthErrorCode(err) != "invalid_grant" {
+		t.Errorf("expected reused code rejected, got %v", err)
+	}
+	if introspect(token.AccessToken) {
+		t.Error("expected tokens of the reused code revoked")
+	}
+
+	_, token = exchange(t, srv, config)
+	rotated, err := config.Refresh(ctx, token.RefreshToken)
+	if err != nil || rotated.RefreshToken == "" || rotated.RefreshToken == token.RefreshToken {
+		t.Fatalf("expected rotated refresh token, got %+v %v", rotated, err)
+	}
+	if introspect(token.RefreshToken) || !introspect(rotated.RefreshToken) {
+		t.Error("expected only the new refresh token active")
+	}
+	if _, err := config.Refresh(ctx, token.RefreshToken); oauthErrorCode(err) != "invalid_grant" {
+		t.Errorf("expected rotated refresh token rejected, got %v", err)
+	}
+	if introspect(rotated.RefreshToken) || introspect(rotated.AccessToken) {
+		t.Error("expected token family revoked after refresh token reuse")
+	}
+}
+
+func TestFaultSignatures(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	var config = newConfig(srv)
+	var keySet = oauth.NewRemoteKeySet(srv.JWKSURL())
+	keySet.MinRefreshInterval = 0
+	verifier, err := oauth.NewIDTokenVerifier(srv.Issuer(), DefaultClientID, keySet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, token := exchange(t, srv, config)
+	if _, err := verifier.Verify(token.IDToken, "nonce"); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.InjectFault(TokenPath, Fault{InvalidSignature: true})
+	_, forged := exchange(t, srv, config)
+	if _, err := verifier.Verify(forged.IDToken, "nonce"); err != jose.InvalidSignatureError {
+		t.Errorf("expected invalid signature, got %v", err)
+	}
+
+	srv.SetClockSkew(5 * time.Minute)
+	_, skewed := exchange(t, srv, config)
+	if _, err := verifier.Verify(skewed.IDToken, "nonce"); err != jose.TokenNotValidYetError {
+		t.Errorf("expected token from the future, got %v", err)
+	}
+	lenient, err := oauth.NewIDTokenVerifier(srv.Issuer(), DefaultClientID, keySet, oauth.IDTokenVerifierWithLeeway(10*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lenient.Verify(skewed.IDToken, "nonce"); err != nil {
+		t.Errorf("expected leeway to tolerate the skew, got %v", err)
+	}
+	srv.SetClockSkew(0)
+
+	// graceful rollover publish both keys, then the old key is retired
+	srv.RotateKey(true)
+	_, rolled := exchange(t, srv, config)
+	if _, err := verifier.Verify(rolled.IDToken, "nonce"); err != nil {
+		t.Errorf("expected new key fetched, got %v", err)
+	}
+	if _, err := verifier.Verify(token.IDToken, "nonce"); err != nil {
+		t.Errorf("expected previous key still published, got %v", err)
+	}
+	srv.RotateKey(false)
+	_, retired := exchange(t, srv, config)
+	if _, err := verifier.Verify(retired.IDToken, "nonce"); err != nil {
+		t.Errorf("expected new key fetched, got %v", err)
+	}
+	if _, err := verifier.Verify(rolled.IDToken, "nonce"); err != errorx.SigningKeyNotFoundError {
+		t.Errorf("expected retired key, got %v", err)
+	}
+}
+
+func TestFaultDevicePolling(t *testing.T) {
+	var mu sync.Mutex
+	var now = time.Unix(1700000000, 0)
+	var advance = func(d time.Duration) {
+		mu.Lock()
+		now = now.Add(d)
+		mu.Unlock()
+	}
+	srv := NewServer(ServerWithClock(func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}))
+	defer srv.Close()
+
+	resp, err := http.PostForm(srv.DeviceAuthorizationURL(), url.Values{"client_id": {DefaultClientID}, "client_secret": {DefaultClientSecret}, "scope": {"openid"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var device struct {
+		DeviceCode string `json:"device_code"`
+		UserCode   string `json:"user_code"`
+		Interval   int    `json:"interval"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&device)
+	resp.Body.Close()
+	if err != nil || device.DeviceCode == "" || device.Interval != 5 {
+		t.Fatalf("unexpected device authorization %+v %v", device, err)
+	}
+	var deviceCode, userCode = device.DeviceCode, device.UserCode
+
+	var endpoint = oauth.NewTokenEndpoint(srv.TokenURL(), DefaultClientID, DefaultClientSecret)
+	var poll = func() string {
+		_, err := endpoint.Token(deviceCodeGrant(deviceCode))
+		return oauthErrorCode(err)
+	}
+	if code := poll(); code != "authorization_pending" {
+		t.Errorf("expected authorization_pending, got %s", code)
+	}
+	if code := poll(); code != "slow_down" {
+		t.Errorf("expected slow_down when polling too fast, got %s", code)
+	}
+	// the interval grew to 10 seconds
+	advance(6 * time.Second)
+	if code := poll(); code != "slow_down" {
+		t.Errorf("expected slow_down with the longer interval, got %s", code)
+	}
+	advance(20 * time.Second)
+	if !srv.ApproveDevice(userCode, DefaultSubject) {
+		t.Fatal("unknown user code")
+	}
+	if token, err := endpoint.Token(deviceCodeGrant(deviceCode)); err != nil || token.AccessToken == "" {
+		t.Errorf("expected token after approval, got %v", err)
+	}
+}
@@ -0,0 +1,540 @@
+package oauthtest
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"github.com/demo007x/oauth2-client/jose"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// handle apply the injected fault before the endpoint, the server state is locked during the endpoint
+func (s *Server) handle(path string, fn http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		f, _ := s.popFault(path)
+		s.mu.Unlock()
+
+		if f.Delay > 0 {
+			select {
+			case <-time.After(f.Delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if f.StatusCode != 0 || f.Body != "" {
+			writeFault(w, f)
+			return
+		}
+		if f.ContentType != "" {
+			w = &contentTypeWriter{ResponseWriter: w, contentType: f.ContentType}
+		}
+		if f.Error != nil && path != AuthorizePath {
+			writeError(w, f.Error.StatusCode, f.Error.Code, f.Error.Description)
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.fault = f
+		defer func() { s.fault = Fault{} }()
+		fn(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, statusCode int, code, description string) {
+	if statusCode == 0 {
+		statusCode = http.StatusBadRequest
+	}
+	var body = map[string]string{"error": code}
+	if description != "" {
+		body["error_description"] = description
+	}
+	writeJSON(w, statusCode, body)
+}
+
+// readParams form or json encoded request params, query included
+func readParams(r *http.Request) (url.Values, error) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return nil, err
+		}
+		var values = r.URL.Query()
+		for key, val := range body {
+			values.Set(key, fmt.Sprint(val))
+		}
+		return values, nil
+	}
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	return r.Form, nil
+}
+
+// authenticate client with basic auth or client_secret_post params, public clients only send client_id
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request, params url.Values) (*Client, bool) {
+	id, secret, ok := r.BasicAuth()
+	if ok {
+		if unescaped, err := url.QueryUnescape(id); err == nil {
+			id = unescaped
+		}
+		if unescaped, err := url.QueryUnescape(secret); err == nil {
+			secret = unescaped
+		}
+	} else {
+		id, secret = params.Get("client_id"), params.Get("client_secret")
+	}
+	client, found := s.clients[id]
+	if !found || client.Secret != secret {
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauthtest"`)
+		writeError(w, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+		return nil, false
+	}
+	return client, true
+}
+
+func (c *Client) allowRedirect(redirectURI string) bool {
+	if len(c.RedirectURIs) == 0 {
+		return redirectURI != ""
+	}
+	for _, uri := range c.RedirectURIs {
+		if uri == redirectURI {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) userBySubject(subject string) *User {
+	for _, user := range s.users {
+		if user.Subject == subject {
+			return user
+		}
+	}
+	return nil
+}
+
+func (s *Server) userByName(username string) *User {
+	for _, user := range s.users {
+		if user.Username == username {
+			return user
+		}
+	}
+	return nil
+}
+
+func hasScope(scope, want string) bool {
+	for _, s := range strings.Fields(scope) {
+		if s == want {
+			return true
+		}
+	}
+	return false
+}
+
+// scopeSubset whether every requested scope was granted
+func scopeSubset(requested, granted string) bool {
+	for _, s := range strings.Fields(requested) {
+		if !hasScope(granted, s) {
+			return false
+		}
+	}
+	return true
+}
+
+func verifyPKCE(a *authorization, verifier string) bool {
+	if a.codeChallenge == "" {
+		return verifier == ""
+	}
+	if a.codeChallengeMethod == "S256" {
+		var sum = sha256.Sum256([]byte(verifier))
+		return jose.Encode(sum[:]) == a.codeChallenge
+	}
+	return verifier == a.codeChallenge
+}
+
+// issue access token of the grant, returned with refreshToken when it is not empty.
+// A new refresh token joins the grant family. id token is issued for openid scope
+func (s *Server) issue(g grant, nonce, refreshToken string) map[string]interface{} {
+	var now = s.now()
+	var accessToken = randomString()
+	g.issuedAt, g.expiry, g.rotated = now, now.Add(s.accessTokenLifetime), false
+	s.accessTokens[accessToken] = &g
+
+	var resp = map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   int64(s.accessTokenLifetime / time.Second),
+	}
+	if g.scope != "" {
+		resp["scope"] = g.scope
+	}
+	if refreshToken != "" {
+		if _, ok := s.refreshTokens[refreshToken]; !ok {
+			var rg = g
+			rg.expiry = time.Time{}
+			if s.refreshTokenLifetime > 0 {
+				rg.expiry = now.Add(s.refreshTokenLifetime)
+			}
+			s.refreshTokens[refreshToken] = &rg
+		}
+		resp["refresh_token"] = refreshToken
+	}
+	if g.subject != "" && hasScope(g.scope, "openid") {
+		if idToken, err := s.idToken(g.clientID, g.subject, nonce, now); err == nil {
+			resp["id_token"] = idToken
+		}
+	}
+	return resp
+}
+
+// idToken RS256 signed id token with the user claims
+func (s *Server) idToken(clientID, subject, nonce string, now time.Time) (string, error) {
+	var claims = map[string]interface{}{}
+	if user := s.userBySubject(subject); user != nil {
+		for key, val := range user.Claims {
+			claims[key] = val
+		}
+	}
+	claims["iss"] = s.Issuer()
+	claims["sub"] = subject
+	claims["aud"] = clientID
+	claims["iat"] = now.Unix()
+	claims["auth_time"] = now.Unix()
+	claims["exp"] = now.Add(s.accessTokenLifetime).Unix()
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+	var key = s.signingKey()
+	return jose.Sign(jose.RS256, key.key, map[string]interface{}{"kid": key.kid}, claims)
+}
+
+// revokeFamily revoke the refresh and access tokens of one authorization
+func (s *Server) revokeFamily(family string) {
+	if family == "" {
+		return
+	}
+	for token, g := range s.refreshTokens {
+		if g.family == family {
+			delete(s.refreshTokens, token)
+		}
+	}
+	for token, g := range s.accessTokens {
+		if g.family == family {
+			delete(s.accessTokens, token)
+		}
+	}
+}
+
+// authorize sign in the login_hint user or the first user and consent automatically
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	var query = r.URL.Query()
+	client, ok := s.clients[query.Get("client_id")]
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid_client", "unknown client")
+		return
+	}
+	var redirectURI = query.Get("redirect_uri")
+	if redirectURI == "" && len(client.RedirectURIs) == 1 {
+		redirectURI = client.RedirectURIs[0]
+	}
+	if !client.allowRedirect(redirectURI) {
+		writeError(w, http.StatusBadRequest, "invalid_request", "redirect_uri is not registered")
+		return
+	}
+	var callback = func(params url.Values) {
+		u, err := url.Parse(redirectURI)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+			return
+		}
+		var values = u.Query()
+		for key := range params {
+			values.Set(key, params.Get(key))
+		}
+		if state := query.Get("state"); state != "" {
+			values.Set("state", state)
+		}
+		u.RawQuery = values.Encode()
+		http.Redirect(w, r, u.String(), http.StatusFound)
+	}
+	var fail = func(code, description string) {
+		callback(url.Values{"error": {code}, "error_description": {description}})
+	}
+
+	if e := s.fault.Error; e != nil {
+		fail(e.Code, e.Description)
+		return
+	}
+	if query.Get("response_type") != "code" {
+		fail("unsupported_response_type", "only code is supported")
+		return
+	}
+	var user = s.users[0]
+	if hint := query.Get("login_hint"); hint != "" {
+		if user = s.userByName(hint); user == nil {
+			user = s.userBySubject(hint)
+		}
+	}
+	if user == nil {
+		fail("access_denied", "unknown user")
+		return
+	}
+	var method = query.Get("code_challenge_method")
+	if method == "" {
+		method = "plain"
+	}
+	if method != "plain" && method != "S256" {
+		fail("invalid_request", "unsupported code_challenge_method")
+		return
+	}
+	if client.Secret == "" && query.Get("code_challenge") == "" {
+		fail("invalid_request", "public client must use PKCE")
+		return
+	}
+
+	var code = randomString()
+	s.codes[code] = &authorization{
+		clientID:            client.ID,
+		redirectURI:         query.Get("redirect_uri"),
+		subject:             user.Subject,
+		scope:               query.Get("scope"),
+		nonce:               query.Get("nonce"),
+		codeChallenge:       query.Get("code_challenge"),
+		codeChallengeMethod: method,
+		expiry:              s.now().Add(s.codeLifetime),
+	}
+	callback(url.Values{"code": {code}})
+}
+
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	params, err := readParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	client, ok := s.authenticate(w, r, params)
+	if !ok {
+		return
+	}
+
+	var resp map[string]interface{}
+	switch params.Get("grant_type") {
+	case "authorization_code":
+		a, found := s.codes[params.Get("code")]
+		if found && a.used {
+			// codes are single use, tokens issued from a reused code are revoked. RFC 6749 section 4.1.2
+			s.revokeFamily(a.family)
+			writeError(w, http.StatusBadRequest, "invalid_grant", "code was already used")
+			return
+		}
+		if !found || a.clientID != client.ID || s.now().After(a.expiry) {
+			writeError(w, http.StatusBadRequest, "invalid_grant", "code is invalid or expired")
+			return
+		}
+		a.used = true
+		if a.redirectURI != "" && a.redirectURI != params.Get("redirect_uri") {
+			writeError(w, http.StatusBadRequest, "invalid_grant", "redirect_uri mismatch")
+			return
+		}
+		if !verifyPKCE(a, params.Get("code_verifier")) {
+			writeError(w, http.StatusBadRequest, "invalid_grant", "code_verifier mismatch")
+			return
+		}
+		a.family = randomString()
+		resp = s.issue(grant{clientID: client.ID, subject: a.subject, scope: a.scope, family: a.family}, a.nonce, a.family)
+	case "refresh_token":
+		var refreshToken = params.Get("refresh_token")
+		g, found := s.refreshTokens[refreshToken]
+		if found && g.rotated {
+			// reuse of a rotated refresh token mean it leaked
+			s.revokeFamily(g.family)
+			writeError(w, http.StatusBadRequest, "invalid_grant", "refresh token was rotated")
+			return
+		}
+		if !found || g.clientID != client.ID || (!g.expiry.IsZero() && s.now().After(g.expiry)) {
+			writeError(w, http.StatusBadRequest, "invalid_grant", "refresh token is invalid or expired")
+			return
+		}
+		var scope = g.scope
+		if requested := params.Get("scope"); requested != "" {
+			if !scopeSubset(requested, g.scope) {
+				writeError(w, http.StatusBadRequest, "invalid_scope", "scope exceeds the granted scope")
+				return
+			}
+			scope = requested
+		}
+		if s.rotateRefreshTokens {
+			g.rotated = true
+			refreshToken = randomString()
+		}
+		resp = s.issue(grant{clientID: client.ID, subject: g.subject, scope: scope, family: g.family}, "", refreshToken)
+	case "password":
+		var user = s.userByName(params.Get("username"))
+		if user == nil || user.Password != params.Get("password") {
+			writeError(w, http.StatusBadRequest, "invalid_grant", "invalid username or password")
+			return
+		}
+		var refreshToken = randomString()
+		resp = s.issue(grant{clientID: client.ID, subject: user.Subject, scope: params.Get("scope"), family: refreshToken}, "", refreshToken)
+	case "client_credentials":
+		if client.Secret == "" {
+			writeError(w, http.StatusBadRequest, "unauthorized_client", "public client can not use client_credentials")
+			return
+		}
+		resp = s.issue(grant{clientID: client.ID, scope: params.Get("scope")}, "", "")
+	case GrantTypeDeviceCode:
+		var ok bool
+		if resp, ok = s.pollDevice(w, client, params.Get("device_code")); !ok {
+			return
+		}
+	default:
+		writeError(w, http.StatusBadRequest, "unsupported_grant_type", "")
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// revoke access or refresh token of the client, a refresh token revoke its token family. RFC 7009
+func (s *Server) revoke(w http.ResponseWriter, r *http.Request) {
+	params, err := readParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	client, ok := s.authenticate(w, r, params)
+	if !ok {
+		return
+	}
+	var token = params.Get("token")
+	if g, found := s.accessTokens[token]; found && g.clientID == client.ID {
+		delete(s.accessTokens, token)
+	}
+	if g, found := s.refreshTokens[token]; found && g.clientID == client.ID {
+		s.revokeFamily(g.family)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// introspect token state. RFC 7662
+func (s *Server) introspect(w http.ResponseWriter, r *http.Request) {
+	params, err := readParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if _, ok := s.authenticate(w, r, params); !ok {
+		return
+	}
+	var token = params.Get("token")
+	var tokenType = "Bearer"
+	g, found := s.accessTokens[token]
+	if !found {
+		g, found = s.refreshTokens[token]
+		tokenType = "refresh_token"
+	}
+	if !found || g.rotated || (!g.expiry.IsZero() && s.now().After(g.expiry)) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"active": false})
+		return
+	}
+	var resp = map[string]interface{}{
+		"active":     true,
+		"client_id":  g.clientID,
+		"token_type": tokenType,
+		"iat":        g.issuedAt.Unix(),
+		"iss":        s.Issuer(),
+	}
+	if g.scope != "" {
+		resp["scope"] = g.scope
+	}
+	if !g.expiry.IsZero() {
+		resp["exp"] = g.expiry.Unix()
+	}
+	if g.subject != "" {
+		resp["sub"] = g.subject
+		if user := s.userBySubject(g.subject); user != nil && user.Username != "" {
+			resp["username"] = user.Username
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// userinfo claims of the user the bearer token was issued to
+func (s *Server) userinfo(w http.ResponseWriter, r *http.Request) {
+	var token string
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		if i := strings.IndexByte(auth, ' '); i > 0 {
+			token = strings.TrimSpace(auth[i+1:])
+		}
+	} else if r.ParseForm() == nil {
+		token = r.Form.Get("access_token")
+	}
+	if token == "" {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="oauthtest"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	g, found := s.accessTokens[token]
+	if !found || s.now().After(g.expiry) || g.subject == "" {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		writeError(w, http.StatusUnauthorized, "invalid_token", "access token is invalid or expired")
+		return
+	}
+	var claims = map[string]interface{}{}
+	if user := s.userBySubject(g.subject); user != nil {
+		for key, val := range user.Claims {
+			claims[key] = val
+		}
+		if user.Username != "" {
+			claims["preferred_username"] = user.Username
+		}
+	}
+	claims["sub"] = g.subject
+	writeJSON(w, http.StatusOK, claims)
+}
+
+// discovery OpenID Connect and RFC 8414 metadata
+func (s *Server) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                s.Issuer(),
+		"authorization_endpoint":                s.AuthorizeURL(),
+		"token_endpoint":                        s.TokenURL(),
+		"userinfo_endpoint":                     s.UserinfoURL(),
+		"jwks_uri":                              s.JWKSURL(),
+		"revocation_endpoint":                   s.RevocationURL(),
+		"introspection_endpoint":                s.IntrospectionURL(),
+		"device_authorization_endpoint":         s.DeviceAuthorizationURL(),
+		"scopes_supported":                      []string{"openid", "profile", "email", "offline_access"},
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{"authorization_code", "refresh_token", "password", "client_credentials", GrantTypeDeviceCode},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+		"code_challenge_methods_supported":      []string{"S256", "plain"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{jose.RS256},
+	})
+}
+
+func (s *Server) jwks(w http.ResponseWriter, r *http.Request) {
+	var set = jose.JSONWebKeySet{Keys: []jose.JSONWebKey{}}
+	for _, key := range s.keys {
+		jwk, err := jose.NewJSONWebKey(&key.key.PublicKey)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "server_error", err.Error())
+			return
+		}
+		jwk.Kid, jwk.Use, jwk.Alg = key.kid, "sig", jose.RS256
+		set.Keys = append(set.Keys, *jwk)
+	}
+	writeJSON(w, http.StatusOK, set)
+}
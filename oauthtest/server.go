@@ -0,0 +1,312 @@
+package oauthtest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"github.com/demo007x/oauth2-client/jose"
+	"github.com/demo007x/oauth2-client/utils"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Endpoint paths of the mock server, also the keys of injected errors
+const (
+	AuthorizePath     = "/authorize"
+	TokenPath         = "/token"
+	RevocationPath    = "/revoke"
+	IntrospectionPath = "/introspect"
+	DevicePath        = "/device_authorization"
+	UserinfoPath      = "/userinfo"
+	DiscoveryPath     = "/.well-known/openid-configuration"
+	MetadataPath      = "/.well-known/oauth-authorization-server"
+	JWKSPath          = "/jwks"
+)
+
+// Default client and user registered when none is configured
+const (
+	DefaultClientID     = "test-client"
+	DefaultClientSecret = "test-secret"
+	DefaultSubject      = "user-1"
+	DefaultUsername     = "alice"
+	DefaultPassword     = "password"
+)
+
+type (
+	// User resource owner. Claims are returned by userinfo and id token. eg: name, email
+	User struct {
+		Subject  string
+		Username string
+		Password string
+		Claims   map[string]interface{}
+	}
+
+	// Client registered client. A public client without Secret must use PKCE
+	Client struct {
+		ID     string
+		Secret string
+		// RedirectURIs allowed redirect uris, any is allowed when empty
+		RedirectURIs []string
+	}
+
+	// ErrorResponse oauth error response. eg: Fault.Error
+	ErrorResponse struct {
+		StatusCode  int
+		Code        string
+		Description string
+	}
+
+	ServerOption func(s *Server)
+	// Server in-process authorization server for hermetic tests.
+	// It implements authorize, token, refresh, revoke, introspect, userinfo, device authorization, discovery and jwks endpoints
+	Server struct {
+		*httptest.Server
+
+		// internal field
+		mu                   sync.Mutex
+		clock                func() time.Time
+		accessTokenLifetime  time.Duration
+		refreshTokenLifetime time.Duration
+		codeLifetime         time.Duration
+		rotateRefreshTokens  bool
+		skew                 time.Duration
+		users                []*User
+		clients              map[string]*Client
+		codes                map[string]*authorization
+		devices              map[string]*deviceAuthorization
+		accessTokens         map[string]*grant
+		refreshTokens        map[string]*grant
+		faults               map[string][]Fault
+		// fault of the request being served
+		fault Fault
+		// keys published in jwks, the last one signs
+		keys     []*signingKey
+		rogueKey *signingKey
+	}
+
+	// authorization issued code waiting for the exchange
+	authorization struct {
+		clientID            string
+		redirectURI         string
+		subject             string
+		scope               string
+		nonce               string
+		codeChallenge       string
+		codeChallengeMethod string
+		expiry              time.Time
+		// used code is kept to revoke the tokens issued from it when it is reused
+		used   bool
+		family string
+	}
+
+	// grant issued token. family links the refresh tokens and access tokens of one authorization
+	grant struct {
+		clientID string
+		subject  string
+		scope    string
+		family   string
+		issuedAt time.Time
+		expiry   time.Time
+		// rotated refresh token replaced by a new one
+		rotated bool
+	}
+
+	signingKey struct {
+		key *rsa.PrivateKey
+		kid string
+	}
+)
+
+// ServerWithUser register a user, the first one is signed in when authorize has no login_hint
+func ServerWithUser(user User) ServerOption {
+	return func(s *Server) {
+		s.users = append(s.users, &user)
+	}
+}
+
+// ServerWithClient register a client
+func ServerWithClient(client Client) ServerOption {
+	return func(s *Server) {
+		s.clients[client.ID] = &client
+	}
+}
+
+// ServerWithTokenLifetime set access and refresh token lifetime, zero refresh lifetime never expire
+func ServerWithTokenLifetime(accessToken, refreshToken time.Duration) ServerOption {
+	return func(s *Server) {
+		s.accessTokenLifetime = accessToken
+		s.refreshTokenLifetime = refreshToken
+	}
+}
+
+// ServerWithCodeLifetime set authorization code lifetime
+func ServerWithCodeLifetime(lifetime time.Duration) ServerOption {
+	return func(s *Server) {
+		s.codeLifetime = lifetime
+	}
+}
+
+// ServerWithRefreshTokenRotation issue a new refresh token on every refresh.
+// Reusing a rotated refresh token revoke the whole token family
+func ServerWithRefreshTokenRotation() ServerOption {
+	return func(s *Server) {
+		s.rotateRefreshTokens = true
+	}
+}
+
+// ServerWithClock set the time source of issued and validated tokens
+func ServerWithClock(clock func() time.Time) ServerOption {
+	return func(s *Server) {
+		s.clock = clock
+	}
+}
+
+// Issuer issuer identifier, the server url
+func (s *Server) Issuer() string {
+	return s.URL
+}
+
+func (s *Server) AuthorizeURL() string {
+	return s.URL + AuthorizePath
+}
+
+func (s *Server) TokenURL() string {
+	return s.URL + TokenPath
+}
+
+func (s *Server) RevocationURL() string {
+	return s.URL + RevocationPath
+}
+
+func (s *Server) IntrospectionURL() string {
+	return s.URL + IntrospectionPath
+}
+
+func (s *Server) UserinfoURL() string {
+	return s.URL + UserinfoPath
+}
+
+func (s *Server) DiscoveryURL() string {
+	return s.URL + DiscoveryPath
+}
+
+func (s *Server) JWKSURL() string {
+	return s.URL + JWKSPath
+}
+
+func (s *Server) DeviceAuthorizationURL() string {
+	return s.URL + DevicePath
+}
+
+// InjectError answer the next request of the endpoint with the error response.
+// eg: InjectError(TokenPath, ErrorResponse{StatusCode: 400, Code: "invalid_grant"})
+func (s *Server) InjectError(path string, e ErrorResponse) {
+	s.InjectFault(path, Fault{Error: &e})
+}
+
+// IssueToken issue access and refresh token without the authorize step.
+// Empty subject issue a client token like client_credentials
+func (s *Server) IssueToken(clientID, subject, scope string) (accessToken, refreshToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	refreshToken = randomString()
+	var resp = s.issue(grant{clientID: clientID, subject: subject, scope: scope, family: refreshToken}, "", refreshToken)
+	return resp["access_token"].(string), refreshToken
+}
+
+// Authorize follow the authorize url like a browser with the user consent,
+// return the redirect uri with code and state or the error
+func (s *Server) Authorize(authorizeURL string) (*url.URL, error) {
+	var client = &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Get(authorizeURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		return nil, errors.New("oauthtest: authorize not redirected: " + resp.Status)
+	}
+	return resp.Location()
+}
+
+// now server time, clock skew included
+func (s *Server) now() time.Time {
+	return s.clock().Add(s.skew)
+}
+
+// newSigningKey RS256 key identified by its jwk thumbprint
+func newSigningKey() *signingKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	jwk, err := jose.NewJSONWebKey(&key.PublicKey)
+	if err != nil {
+		panic(err)
+	}
+	kid, err := jwk.Thumbprint()
+	if err != nil {
+		panic(err)
+	}
+	return &signingKey{key: key, kid: kid}
+}
+
+// randomString opaque codes and tokens
+func randomString() string {
+	s, err := utils.GenerateRandomString(32)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// NewServer start the mock server, close it when the test is done
+func NewServer(opts ...ServerOption) *Server {
+	var s = &Server{
+		clock:               time.Now,
+		accessTokenLifetime: time.Hour,
+		codeLifetime:        time.Minute,
+		clients:             make(map[string]*Client),
+		codes:               make(map[string]*authorization),
+		devices:             make(map[string]*deviceAuthorization),
+		accessTokens:        make(map[string]*grant),
+		refreshTokens:       make(map[string]*grant),
+		faults:              make(map[string][]Fault),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if len(s.clients) == 0 {
+		s.clients[DefaultClientID] = &Client{ID: DefaultClientID, Secret: DefaultClientSecret}
+	}
+	if len(s.users) == 0 {
+		s.users = append(s.users, &User{
+			Subject:  DefaultSubject,
+			Username: DefaultUsername,
+			Password: DefaultPassword,
+			Claims:   map[string]interface{}{"name": "Alice", "email": "alice@example.com", "email_verified": true},
+		})
+	}
+
+	s.keys = append(s.keys, newSigningKey())
+
+	var mux = http.NewServeMux()
+	mux.HandleFunc(AuthorizePath, s.handle(AuthorizePath, s.authorize))
+	mux.HandleFunc(TokenPath, s.handle(TokenPath, s.token))
+	mux.HandleFunc(RevocationPath, s.handle(RevocationPath, s.revoke))
+	mux.HandleFunc(IntrospectionPath, s.handle(IntrospectionPath, s.introspect))
+	mux.HandleFunc(DevicePath, s.handle(DevicePath, s.deviceAuthorization))
+	mux.HandleFunc(UserinfoPath, s.handle(UserinfoPath, s.userinfo))
+	mux.HandleFunc(DiscoveryPath, s.handle(DiscoveryPath, s.discovery))
+	mux.HandleFunc(MetadataPath, s.handle(MetadataPath, s.discovery))
+	mux.HandleFunc(JWKSPath, s.handle(JWKSPath, s.jwks))
+	s.Server = httptest.NewServer(mux)
+	return s
+}
@@ -0,0 +1,175 @@
+package oauthtest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"github.com/demo007x/oauth2-client/errorx"
+	"github.com/demo007x/oauth2-client/jose"
+	"github.com/demo007x/oauth2-client/oauth"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newConfig(srv *Server) *oauth.Config {
+	return oauth.NewConfig(oauth.Endpoint{AuthorizeURL: srv.AuthorizeURL(), TokenURL: srv.TokenURL(), RevokeURL: srv.RevocationURL()},
+		DefaultClientID,
+		oauth.ConfigWithSecret(DefaultClientSecret),
+		oauth.ConfigWithRedirectURI("http://127.0.0.1/callback"),
+		oauth.ConfigWithScopes("openid", "profile"),
+	)
+}
+
+func TestServer(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	var ctx = context.Background()
+	var config = newConfig(srv)
+
+	md, err := oauth.NewDiscovery(srv.DiscoveryURL()).Metadata()
+	if err != nil || md.Issuer != srv.Issuer() || md.TokenEndpoint != srv.TokenURL() || md.JwksURI != srv.JWKSURL() {
+		t.Fatalf("unexpected metadata %+v %v", md, err)
+	}
+
+	authURL, err := config.AuthCodeURL("state-1", oauth.WithNonce("nonce-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	callback, err := srv.Authorize(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if callback.Query().Get("state") != "state-1" {
+		t.Errorf("unexpected callback %s", callback)
+	}
+	token, err := config.Exchange(ctx, callback.Query().Get("code"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := oauth.NewIDTokenVerifier(srv.Issuer(), DefaultClientID, oauth.NewRemoteKeySet(md.JwksURI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	idToken, err := verifier.Verify(token.IDToken, "nonce-1")
+	if err != nil || idToken.Subject != DefaultSubject || idToken.Raw["email"] != "alice@example.com" {
+		t.Errorf("unexpected id token %+v %v", idToken, err)
+	}
+
+	profile, err := oauth.NewUserInfo(srv.UserinfoURL(), token.AccessToken).Profile()
+	if err != nil || profile.Subject != DefaultSubject {
+		t.Errorf("unexpected profile %+v %v", profile, err)
+	}
+
+	introspection, err := oauth.NewIntrospectToken(srv.IntrospectionURL(), DefaultClientID, DefaultClientSecret, token.AccessToken).Introspect()
+	if err != nil || !introspection.Active || introspection.Sub != DefaultSubject || introspection.Scope != "openid profile" {
+		t.Errorf("unexpected introspection %+v %v", introspection, err)
+	}
+
+	// revoking the refresh token revoke its access tokens
+	if err := config.Revoke(ctx, token.RefreshToken); err != nil {
+		t.Fatal(err)
+	}
+	introspection, err = oauth.NewIntrospectToken(srv.IntrospectionURL(), DefaultClientID, DefaultClientSecret, token.AccessToken).Introspect()
+	if err != nil || introspection.Active {
+		t.Errorf("expected inactive token, got %+v %v", introspection, err)
+	}
+	var oe *errorx.OauthError
+	if _, err := config.Refresh(ctx, token.RefreshToken); !errors.As(err, &oe) || oe.Code != "invalid_grant" {
+		t.Errorf("expected invalid_grant, got %v", err)
+	}
+}
+
+func TestServerInjectError(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	var config = newConfig(srv)
+	_, refreshToken := srv.IssueToken(DefaultClientID, DefaultSubject, "openid")
+
+	srv.InjectError(TokenPath, ErrorResponse{StatusCode: http.StatusServiceUnavailable, Code: "temporarily_unavailable"})
+	var oe *errorx.OauthError
+	if _, err := config.Refresh(context.Background(), refreshToken); !errors.As(err, &oe) || oe.Code != "temporarily_unavailable" || oe.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected injected error, got %v", err)
+	}
+	if _, err := config.Refresh(context.Background(), refreshToken); err != nil {
+		t.Errorf("expected injected error to be used once, got %v", err)
+	}
+
+	srv.InjectError(AuthorizePath, ErrorResponse{Code: "access_denied"})
+	authURL, _ := config.AuthCodeURL("state-1")
+	callback, err := srv.Authorize(authURL)
+	if err != nil || callback.Query().Get("error") != "access_denied" || callback.Query().Get("state") != "state-1" {
+		t.Errorf("expected access_denied redirect, got %v %v", callback, err)
+	}
+}
+
+func TestServerLifetimeAndPKCE(t *testing.T) {
+	var mu sync.Mutex
+	var now = time.Unix(1700000000, 0)
+	var clock = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	var advance = func(d time.Duration) {
+		mu.Lock()
+		now = now.Add(d)
+		mu.Unlock()
+	}
+	srv := NewServer(
+		ServerWithClock(clock),
+		ServerWithCodeLifetime(time.Minute),
+		ServerWithTokenLifetime(time.Minute, time.Hour),
+		ServerWithClient(Client{ID: "public", RedirectURIs: []string{"http://127.0.0.1/callback"}}),
+	)
+	defer srv.Close()
+
+	var token = func(params url.Values) map[string]interface{} {
+		resp, err := http.PostForm(srv.TokenURL(), params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var body map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&body)
+		return body
+	}
+	var authorize = func(query url.Values) string {
+		callback, err := srv.Authorize(srv.AuthorizeURL() + "?" + query.Encode())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return callback.Query().Get("code")
+	}
+
+	var sum = sha256.Sum256([]byte("verifier"))
+	var query = url.Values{"client_id": {"public"}, "response_type": {"code"}, "code_challenge": {jose.Encode(sum[:])}, "code_challenge_method": {"S256"}}
+	if resp := token(url.Values{"grant_type": {"authorization_code"}, "client_id": {"public"}, "code": {authorize(query)}, "code_verifier": {"wrong"}}); resp["error"] != "invalid_grant" {
+		t.Errorf("expected PKCE mismatch, got %v", resp)
+	}
+	var code = authorize(query)
+	advance(2 * time.Minute)
+	if resp := token(url.Values{"grant_type": {"authorization_code"}, "client_id": {"public"}, "code": {code}, "code_verifier": {"verifier"}}); resp["error"] != "invalid_grant" {
+		t.Errorf("expected expired code, got %v", resp)
+	}
+	resp := token(url.Values{"grant_type": {"authorization_code"}, "client_id": {"public"}, "code": {authorize(query)}, "code_verifier": {"verifier"}})
+	if resp["access_token"] == nil || resp["expires_in"] != float64(60) {
+		t.Fatalf("unexpected token %v", resp)
+	}
+
+	advance(2 * time.Minute)
+	userinfo, err := oauth.NewUserInfo(srv.UserinfoURL(), resp["access_token"].(string)).DoRequest()
+	if err != nil || !strings.Contains(string(userinfo), "invalid_token") {
+		t.Errorf("expected expired access token, got %s %v", userinfo, err)
+	}
+	if resp := token(url.Values{"grant_type": {"refresh_token"}, "client_id": {"public"}, "refresh_token": {resp["refresh_token"].(string)}}); resp["access_token"] == nil {
+		t.Errorf("unexpected refresh %v", resp)
+	}
+	if resp := token(url.Values{"grant_type": {"client_credentials"}, "client_id": {"public"}}); resp["error"] != "unauthorized_client" {
+		t.Errorf("expected public client credentials rejected, got %v", resp)
+	}
+}
@@ -0,0 +1,299 @@
+// Package alipay alipay oauth adapter.
+// Gateway requests are signed with RSA2 (SHA256WithRSA) and
+// the json envelope of every response is verified with the alipay public key
+package alipay
+
+import (
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"github.com/demo007x/oauth2-client/oauth"
+	"github.com/demo007x/oauth2-client/types"
+	"github.com/demo007x/oauth2-client/utils"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	GatewayURL   = "https://openapi.alipay.com/gateway.do"
+	AuthorizeURL = "https://openauth.alipay.com/oauth2/publicAppAuthorize.htm"
+
+	MethodOauthToken    = "alipay.system.oauth.token"
+	MethodUserInfoShare = "alipay.user.info.share"
+
+	// ScopeAuthUser user confirmed authorization with profile
+	ScopeAuthUser = "auth_user"
+	// ScopeAuthBase silent authorization, only user id
+	ScopeAuthBase = "auth_base"
+)
+
+// beijing time used by gateway timestamp
+var cst = time.FixedZone("CST", 8*3600)
+
+type (
+	Option func(a *Alipay)
+	// Alipay alipay application
+	Alipay struct {
+		AppID       string
+		PrivateKey  *rsa.PrivateKey
+		PublicKey   *rsa.PublicKey
+		RedirectURI string
+		Scope       string
+
+		GatewayEndpoint   string
+		AuthorizeEndpoint string
+
+		interceptors []utils.RequestInterceptor
+		observer     oauth.Observer
+	}
+
+	// Token alipay access token with user id and open id
+	Token struct {
+		*oauth.Token
+		UserID      string
+		OpenID      string
+		ReExpiresIn int64
+	}
+
+	// User normalized alipay user profile
+	User struct {
+		UserID   string
+		OpenID   string
+		Nickname string
+		Avatar   string
+		// Gender male, female or empty
+		Gender   string
+		Province string
+		City     string
+		Raw      map[string]interface{}
+	}
+
+	// Error alipay gateway business error
+	Error struct {
+		Code    string `json:"code"`
+		Msg     string `json:"msg"`
+		SubCode string `json:"sub_code"`
+		SubMsg  string `json:"sub_msg"`
+	}
+)
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("alipay error: code %s, %s, sub_code %s, %s", e.Code, e.Msg, e.SubCode, e.SubMsg)
+}
+
+// WithScope set authorize scope. auth_user or auth_base
+func WithScope(scope string) Option {
+	return func(a *Alipay) {
+		a.Scope = scope
+	}
+}
+
+// WithInterceptors modify or audit every gateway request before it is sent
+func WithInterceptors(interceptors ...utils.RequestInterceptor) Option {
+	return func(a *Alipay) {
+		a.interceptors = append(a.interceptors, interceptors...)
+	}
+}
+
+// WithObserver report every gateway call to observer
+func WithObserver(observer oauth.Observer) Option {
+	return func(a *Alipay) {
+		a.observer = observer
+	}
+}
+
+// WithGateway set gateway url. eg: sandbox https://openapi-sandbox.dl.alipaydev.com/gateway.do
+func WithGateway(gatewayURL string) Option {
+	return func(a *Alipay) {
+		a.GatewayEndpoint = gatewayURL
+	}
+}
+
+// AuthorizeURL build alipay authorize url, the callback carry auth_code
+func (a *Alipay) AuthorizeURL(state string) (string, error) {
+	u, err := url.Parse(a.AuthorizeEndpoint)
+	if err != nil {
+		return "", err
+	}
+	var values = u.Query()
+	values.Set("app_id", a.AppID)
+	values.Set("scope", a.Scope)
+	values.Set("redirect_uri", a.RedirectURI)
+	if strings.TrimSpace(state) != "" {
+		values.Set("state", state)
+	}
+	u.RawQuery = values.Encode()
+	return u.String(), nil
+}
+
+// ResponseHandler verify the response signature and return the json of method response node
+func (a *Alipay) ResponseHandler(method string) types.OauthResponseHandler {
+	return func(resp *http.Response) ([]byte, error) {
+		data, err := types.DefaultOauthResponseHandler(resp)
+		if err != nil {
+			return nil, err
+		}
+		var envelope = map[string]json.RawMessage{}
+		if err := json.Unmarshal(data, &envelope); err != nil {
+			return nil, err
+		}
+		var sign string
+		if raw, ok := envelope["sign"]; ok {
+			if err := json.Unmarshal(raw, &sign); err != nil {
+				return nil, err
+			}
+		}
+
+		var node = strings.Replace(method, ".", "_", -1) + "_response"
+		content, ok := envelope[node]
+		if !ok {
+			// gateway errors may come unsigned, return the business error instead of a signature error
+			if errContent, found := envelope["error_response"]; found {
+				var bizErr = &Error{}
+				if err := json.Unmarshal(errContent, bizErr); err != nil {
+					return nil, err
+				}
+				return nil, bizErr
+			}
+			return nil, &Error{Code: "40004", Msg: "missing response node " + node}
+		}
+		// the signed content is the raw json of the response node
+		if err := rsa2Verify(a.PublicKey, content, sign); err != nil {
+			return nil, err
+		}
+
+		var bizErr = &Error{}
+		if err := json.Unmarshal(content, bizErr); err != nil {
+			return nil, err
+		}
+		if (bizErr.Code != "" && bizErr.Code != "10000") || bizErr.SubCode != "" {
+			return nil, bizErr
+		}
+		return content, nil
+	}
+}
+
+// Exchange exchange auth_code for access token
+func (a *Alipay) Exchange(authCode string) (*Token, error) {
+	data, err := a.NewRequest(MethodOauthToken, map[string]string{
+		"grant_type": types.DefaultAccessTokenGrantType,
+		"code":       authCode,
+	}).DoRequest()
+	if err != nil {
+		return nil, err
+	}
+	return parseToken(data)
+}
+
+// Refresh renew access token with refresh token
+func (a *Alipay) Refresh(refreshToken string) (*Token, error) {
+	data, err := a.NewRequest(MethodOauthToken, map[string]string{
+		"grant_type":    "refresh_token",
+		"refresh_token": refreshToken,
+	}).DoRequest()
+	if err != nil {
+		return nil, err
+	}
+	return parseToken(data)
+}
+
+// UserInfo get user profile, need auth_user scope
+func (a *Alipay) UserInfo(accessToken string) (*User, error) {
+	data, err := a.NewRequest(MethodUserInfoShare, map[string]string{"auth_token": accessToken}).DoRequest()
+	if err != nil {
+		return nil, err
+	}
+	var raw = map[string]interface{}{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	return normalize(raw), nil
+}
+
+// Login exchange auth_code and fetch user profile.
+// With auth_base scope only user id and open id are filled
+func (a *Alipay) Login(authCode string) (*User, *Token, error) {
+	token, err := a.Exchange(authCode)
+	if err != nil {
+		return nil, nil, err
+	}
+	if a.Scope == ScopeAuthBase {
+		return &User{UserID: token.UserID, OpenID: token.OpenID}, token, nil
+	}
+	user, err := a.UserInfo(token.AccessToken)
+	if err != nil {
+		return nil, token, err
+	}
+	return user, token, nil
+}
+
+func parseToken(data []byte) (*Token, error) {
+	token, err := oauth.ParseToken(data)
+	if err != nil {
+		return nil, err
+	}
+	var userID, _ = token.Raw["user_id"].(string)
+	var openID, _ = token.Raw["open_id"].(string)
+	var reExpiresIn, _ = token.Raw["re_expires_in"].(json.Number)
+	var t = &Token{Token: token, UserID: userID, OpenID: openID}
+	t.ReExpiresIn, _ = reExpiresIn.Int64()
+	return t, nil
+}
+
+// Profile normalized profile, subject is user_id of legacy apps or open_id
+func (u *User) Profile() *oauth.Profile {
+	return Profile(u.Raw)
+}
+
+// Profile map alipay.user.info.share, subject is user_id of legacy apps or open_id
+func Profile(raw map[string]interface{}) *oauth.Profile {
+	var subject = oauth.StringClaim(raw, "user_id")
+	if subject == "" {
+		subject = oauth.StringClaim(raw, "open_id")
+	}
+	return &oauth.Profile{
+		Subject:  subject,
+		Name:     oauth.StringClaim(raw, "nick_name"),
+		Picture:  oauth.StringClaim(raw, "avatar"),
+		Provider: "alipay",
+		Raw:      raw,
+	}
+}
+
+func normalize(raw map[string]interface{}) *User {
+	var user = &User{
+		UserID:   oauth.StringClaim(raw, "user_id"),
+		OpenID:   oauth.StringClaim(raw, "open_id"),
+		Nickname: oauth.StringClaim(raw, "nick_name"),
+		Avatar:   oauth.StringClaim(raw, "avatar"),
+		Province: oauth.StringClaim(raw, "province"),
+		City:     oauth.StringClaim(raw, "city"),
+		Raw:      raw,
+	}
+	switch strings.ToUpper(oauth.StringClaim(raw, "gender")) {
+	case "M":
+		user.Gender = "male"
+	case "F":
+		user.Gender = "female"
+	}
+	return user
+}
+
+// New return Alipay adapter. privateKey sign requests and publicKey verify alipay responses
+func New(appID string, privateKey *rsa.PrivateKey, publicKey *rsa.PublicKey, redirectURI string, opts ...Option) *Alipay {
+	var a = &Alipay{
+		AppID:             appID,
+		PrivateKey:        privateKey,
+		PublicKey:         publicKey,
+		RedirectURI:       redirectURI,
+		Scope:             ScopeAuthUser,
+		GatewayEndpoint:   GatewayURL,
+		AuthorizeEndpoint: AuthorizeURL,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
@@ -0,0 +1,156 @@
+package alipay
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"github.com/demo007x/oauth2-client/oauth"
+	"github.com/demo007x/oauth2-client/types"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAlipay(t *testing.T) {
+	appKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alipayKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tamper bool
+
+	// respond with the node signed by alipay private key
+	respond := func(w http.ResponseWriter, node, content string) {
+		sign, err := rsa2Sign(alipayKey, []byte(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tamper {
+			content = `{"code":"10000","user_id":"attacker"}`
+		}
+		w.Write([]byte(`{"` + node + `":` + content + `,"sign":"` + sign + `"}`))
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		if r.PostForm.Get("app_id") != "2021" || r.PostForm.Get("sign_type") != "RSA2" {
+			t.Errorf("missing common params %v", r.PostForm)
+		}
+		if err := rsa2Verify(&appKey.PublicKey, []byte(signContent(r.PostForm)), r.PostForm.Get("sign")); err != nil {
+			respond(w, "error_response", `{"code":"40002","msg":"Invalid Arguments","sub_code":"isv.invalid-signature","sub_msg":"验签出错"}`)
+			return
+		}
+		switch r.PostForm.Get("method") {
+		case MethodOauthToken:
+			if r.PostForm.Get("code") != "auth_code" && r.PostForm.Get("refresh_token") != "RT" {
+				respond(w, "error_response", `{"code":"40002","msg":"Invalid Arguments","sub_code":"isv.code-invalid","sub_msg":"授权码code无效"}`)
+				return
+			}
+			respond(w, "alipay_system_oauth_token_response", `{"user_id":"2088102150477652","open_id":"074a1CcTG1LelxKe4xQC0zgNdId0nxi95b5lsNpazWYoCo5","access_token":"AT","expires_in":1296000,"refresh_token":"RT","re_expires_in":2592000,"auth_start":"2010-11-11 11:11:11"}`)
+		case MethodUserInfoShare:
+			if r.PostForm.Get("auth_token") != "AT" {
+				respond(w, "alipay_user_info_share_response", `{"code":"20001","msg":"Insufficient Token Permissions","sub_code":"aop.invalid-auth-token","sub_msg":"无效的访问令牌"}`)
+				return
+			}
+			respond(w, "alipay_user_info_share_response", `{"code":"10000","msg":"Success","user_id":"2088102150477652","avatar":"http:\/\/tfsimg.alipay.com\/images\/partner\/T1uIxXXbpXXXXXXXX","nick_name":"支付宝小二","gender":"F","province":"安徽省","city":"安庆"}`)
+		}
+	}))
+	defer server.Close()
+
+	der, _ := x509.MarshalPKIXPublicKey(&alipayKey.PublicKey)
+	alipayPublicKey, err := ParsePublicKey(base64.StdEncoding.EncodeToString(der))
+	if err != nil {
+		t.Fatal(err)
+	}
+	appPrivateKey, err := ParsePrivateKey(string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(appKey)})))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := New("2021", appPrivateKey, alipayPublicKey, "https://app.example.com/alipay", WithGateway(server.URL+"/gateway.do"))
+	user, token, err := a.Login("auth_code")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "AT" || token.UserID != "2088102150477652" || token.ReExpiresIn != 2592000 {
+		t.Errorf("unexpected token %+v", token)
+	}
+	if user.Nickname != "支付宝小二" || user.Gender != "female" || user.Avatar != "http://tfsimg.alipay.com/images/partner/T1uIxXXbpXXXXXXXX" {
+		t.Errorf("unexpected user %+v", user)
+	}
+	if profile := user.Profile(); profile.Subject != "2088102150477652" || profile.Provider != "alipay" {
+		t.Errorf("unexpected profile %+v", profile)
+	}
+
+	if _, err := a.Refresh("RT"); err != nil {
+		t.Error(err)
+	}
+	_, err = a.Exchange("bad")
+	if ae, ok := err.(*Error); !ok || ae.SubCode != "isv.code-invalid" {
+		t.Errorf("expected isv.code-invalid, got %v", err)
+	}
+	_, err = a.UserInfo("bad")
+	if ae, ok := err.(*Error); !ok || ae.Code != "20001" {
+		t.Errorf("expected invalid auth token, got %v", err)
+	}
+
+	tamper = true
+	if _, err := a.UserInfo("AT"); err != InvalidSignatureError {
+		t.Errorf("expected invalid signature, got %v", err)
+	}
+	tamper = false
+
+	// an unsigned gateway error is returned as the business error
+	unsigned := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"error_response":{"code":"40001","msg":"Missing Required Arguments","sub_code":"isv.missing-app-id"}}`))
+	}))
+	defer unsigned.Close()
+	_, err = New("2021", appPrivateKey, alipayPublicKey, "", WithGateway(unsigned.URL)).Exchange("auth_code")
+	if ae, ok := err.(*Error); !ok || ae.SubCode != "isv.missing-app-id" {
+		t.Errorf("expected unsigned gateway error, got %v", err)
+	}
+
+	if _, err := New("2021", nil, alipayPublicKey, "", WithGateway(server.URL+"/gateway.do")).Exchange("auth_code"); err != KeyNotSetError {
+		t.Errorf("expected private key not set, got %v", err)
+	}
+	if _, err := New("2021", appPrivateKey, nil, "", WithGateway(server.URL+"/gateway.do")).UserInfo("AT"); err != KeyNotSetError {
+		t.Errorf("expected key not set without alipay public key, got %v", err)
+	}
+}
+
+func TestAlipayInterceptors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Tenant") != "t1" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	var observed []*oauth.Observation
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := New("2021", key, &key.PublicKey, "", WithGateway(server.URL),
+		WithInterceptors(func(r *http.Request) error {
+			r.Header.Set("X-Tenant", "t1")
+			return nil
+		}),
+		WithObserver(oauth.ObserverFunc(func(o *oauth.Observation) { observed = append(observed, o) })),
+	)
+	if _, err := a.NewRequest(MethodUserInfoShare, nil, RequestWithResponseHandler(types.CheckStatus(nil)(types.DefaultOauthResponseHandler))).DoRequest(); err != nil {
+		t.Fatalf("expected interceptor header sent, got %v", err)
+	}
+	if len(observed) != 1 || observed[0].Endpoint != oauth.EndpointAPI || observed[0].StatusCode != http.StatusOK {
+		t.Errorf("expected the api call observed, got %+v", observed)
+	}
+}
@@ -0,0 +1,99 @@
+package alipay
+
+import (
+	"github.com/demo007x/oauth2-client/errorx"
+	"github.com/demo007x/oauth2-client/oauth"
+	"github.com/demo007x/oauth2-client/types"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type (
+	RequestOption func(r *Request)
+	// Request signed alipay gateway request
+	Request struct {
+		Method string
+		Params map[string]string
+
+		// internal field
+		alipay  *Alipay
+		values  url.Values
+		handler types.OauthResponseHandler
+		header  map[string]string
+		err     error
+	}
+)
+
+// RequestWithResponseHandler custom response handler, the default verify response signature
+func RequestWithResponseHandler(handler types.OauthResponseHandler) RequestOption {
+	return func(r *Request) {
+		r.handler = handler
+	}
+}
+
+func (r *Request) setCommonParams() *Request {
+	if r.err == nil {
+		if strings.TrimSpace(r.alipay.AppID) == "" {
+			r.err = errorx.ClientKeyError
+			return r
+		}
+		r.values = url.Values{}
+		r.values.Set("app_id", r.alipay.AppID)
+		r.values.Set("method", r.Method)
+		r.values.Set("format", "JSON")
+		r.values.Set("charset", "utf-8")
+		r.values.Set("sign_type", "RSA2")
+		r.values.Set("timestamp", time.Now().In(cst).Format("2006-01-02 15:04:05"))
+		r.values.Set("version", "1.0")
+		for key, val := range r.Params {
+			r.values.Set(key, val)
+		}
+	}
+	return r
+}
+
+func (r *Request) setSign() *Request {
+	if r.err == nil {
+		sign, err := rsa2Sign(r.alipay.PrivateKey, []byte(signContent(r.values)))
+		if err != nil {
+			r.err = err
+			return r
+		}
+		r.values.Set("sign", sign)
+	}
+	return r
+}
+
+// DoRequest sign and post the request to alipay gateway
+func (r *Request) DoRequest() ([]byte, error) {
+	if err := r.setCommonParams().setSign().err; err != nil {
+		return nil, err
+	}
+	var handler = r.handler
+	if handler == nil {
+		handler = r.alipay.ResponseHandler(r.Method)
+	}
+	return oauth.NewAPIRequest(r.alipay.GatewayEndpoint, http.MethodPost,
+		oauth.APIRequestWithForm(r.values),
+		oauth.APIRequestWithHeader(r.header),
+		oauth.APIRequestWithResponseHandler(handler),
+		oauth.APIRequestWithInterceptors(r.alipay.interceptors...),
+		oauth.APIRequestWithObserver(r.alipay.observer),
+	).DoRequest()
+}
+
+// NewRequest return signed gateway request of api method with business params
+func (a *Alipay) NewRequest(method string, params map[string]string, opts ...RequestOption) *Request {
+	var r = &Request{
+		Method: method,
+		Params: params,
+		alipay: a,
+		header: map[string]string{"Content-Type": "application/x-www-form-urlencoded;charset=utf-8"},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
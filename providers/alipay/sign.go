@@ -0,0 +1,114 @@
+package alipay
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+var (
+	InvalidKeyError       = errors.New("alipay: invalid rsa key")
+	InvalidSignatureError = errors.New("alipay: invalid response signature")
+	KeyNotSetError        = errors.New("alipay: application private key or alipay public key is not set")
+)
+
+// signContent sort params by key and join k=v with &. sign and empty values are excluded
+func signContent(params url.Values) string {
+	var keys = make([]string, 0, len(params))
+	for key := range params {
+		if key == "sign" || params.Get(key) == "" {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var pairs = make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+params.Get(key))
+	}
+	return strings.Join(pairs, "&")
+}
+
+// rsa2Sign SHA256WithRSA signature encoded with base64
+func rsa2Sign(key *rsa.PrivateKey, content []byte) (string, error) {
+	if key == nil {
+		return "", KeyNotSetError
+	}
+	var sum = sha256.Sum256(content)
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// rsa2Verify verify SHA256WithRSA base64 signature
+func rsa2Verify(key *rsa.PublicKey, content []byte, sign string) error {
+	if key == nil {
+		return KeyNotSetError
+	}
+	sig, err := base64.StdEncoding.DecodeString(sign)
+	if err != nil {
+		return InvalidSignatureError
+	}
+	var sum = sha256.Sum256(content)
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, sum[:], sig); err != nil {
+		return InvalidSignatureError
+	}
+	return nil
+}
+
+// pemBytes accept pem or the bare base64 key string from alipay console
+func pemBytes(key string) ([]byte, error) {
+	if block, _ := pem.Decode([]byte(key)); block != nil {
+		return block.Bytes, nil
+	}
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(key), ""))
+}
+
+// ParsePrivateKey parse pkcs1 or pkcs8 application private key
+func ParsePrivateKey(key string) (*rsa.PrivateKey, error) {
+	der, err := pemBytes(key)
+	if err != nil {
+		return nil, InvalidKeyError
+	}
+	if pk, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return pk, nil
+	}
+	pk, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, InvalidKeyError
+	}
+	rsaKey, ok := pk.(*rsa.PrivateKey)
+	if !ok {
+		return nil, InvalidKeyError
+	}
+	return rsaKey, nil
+}
+
+// ParsePublicKey parse alipay public key in pkix or pkcs1 form
+func ParsePublicKey(key string) (*rsa.PublicKey, error) {
+	der, err := pemBytes(key)
+	if err != nil {
+		return nil, InvalidKeyError
+	}
+	if pub, err := x509.ParsePKIXPublicKey(der); err == nil {
+		if rsaKey, ok := pub.(*rsa.PublicKey); ok {
+			return rsaKey, nil
+		}
+		return nil, InvalidKeyError
+	}
+	pub, err := x509.ParsePKCS1PublicKey(der)
+	if err != nil {
+		return nil, InvalidKeyError
+	}
+	return pub, nil
+}
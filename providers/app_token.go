@@ -0,0 +1,75 @@
+package providers
+
+import (
+	"github.com/demo007x/oauth2-client/errorx"
+	"sync"
+	"time"
+)
+
+const (
+	defaultAppTokenLifetime = 2 * time.Hour
+	// minAppTokenReuse a fetched token is reused at least this long, even when its lifetime is within Leeway
+	minAppTokenReuse = time.Minute
+)
+
+type (
+	// AppTokenFetcher request a new app level token and its lifetime in seconds, 0 when unknown
+	AppTokenFetcher func() (token string, expiresIn int64, err error)
+
+	// AppTokenCache cache app level tokens such as app_access_token and tenant_access_token.
+	// The token is renewed before it expires and concurrent callers share one request.
+	// The zero value with Fetch set is ready to use
+	AppTokenCache struct {
+		Fetch AppTokenFetcher
+		// Leeway renew the token this long before it expires
+		Leeway time.Duration
+		// DefaultLifetime lifetime of a token fetched without expires_in, two hours when zero
+		DefaultLifetime time.Duration
+
+		mu        sync.Mutex
+		token     string
+		expiresAt time.Time
+	}
+)
+
+// Token return the cached token or fetch a new one
+func (c *AppTokenCache) Token() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && time.Now().Add(c.Leeway).Before(c.expiresAt) {
+		return c.token, nil
+	}
+	if c.Fetch == nil {
+		return "", errorx.FetcherEmptyError
+	}
+	token, expiresIn, err := c.Fetch()
+	if err != nil {
+		return "", err
+	}
+	var lifetime = time.Duration(expiresIn) * time.Second
+	if lifetime <= 0 {
+		lifetime = c.DefaultLifetime
+	}
+	if lifetime <= 0 {
+		lifetime = defaultAppTokenLifetime
+	}
+	// a new token may revoke the previous one, never refetch on every call
+	if lifetime < c.Leeway+minAppTokenReuse {
+		lifetime = c.Leeway + minAppTokenReuse
+	}
+	c.token, c.expiresAt = token, time.Now().Add(lifetime)
+	return token, nil
+}
+
+// Invalidate drop the cached token. eg: the server reject it before expiry
+func (c *AppTokenCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = ""
+}
+
+// NewAppTokenCache return AppTokenCache renew five minutes before expiry.
+// Tokens without lifetime are kept two hours, the usual app token lifetime
+func NewAppTokenCache(fetch AppTokenFetcher) *AppTokenCache {
+	return &AppTokenCache{Fetch: fetch, Leeway: 5 * time.Minute, DefaultLifetime: defaultAppTokenLifetime}
+}
@@ -0,0 +1,69 @@
+package providers
+
+import (
+	"github.com/demo007x/oauth2-client/errorx"
+	"testing"
+	"time"
+)
+
+func TestAppTokenCache(t *testing.T) {
+	var calls int
+	cache := NewAppTokenCache(func() (string, int64, error) {
+		calls++
+		return "t" + string(rune('0'+calls)), 7200, nil
+	})
+	for i := 0; i < 3; i++ {
+		token, err := cache.Token()
+		if err != nil || token != "t1" {
+			t.Fatalf("unexpected token %s %v", token, err)
+		}
+	}
+	cache.Invalidate()
+	if token, _ := cache.Token(); token != "t2" || calls != 2 {
+		t.Errorf("invalidate should fetch a new token, got %s", token)
+	}
+
+	// lifetime shorter than leeway is always renewed
+	cache.Leeway = 3 * time.Hour
+	if token, _ := cache.Token(); token != "t3" {
+		t.Errorf("expired token should be renewed, got %s", token)
+	}
+	// the renewed token is reused for a while instead of fetched on every call
+	if token, _ := cache.Token(); token != "t3" || calls != 3 {
+		t.Errorf("token within leeway fetched %d times, got %s", calls, token)
+	}
+
+	// unknown lifetime use the default instead of fetching on every call
+	calls = 0
+	unknown := NewAppTokenCache(func() (string, int64, error) {
+		calls++
+		return "t", 0, nil
+	})
+	for i := 0; i < 3; i++ {
+		if _, err := unknown.Token(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("token without expires_in fetched %d times", calls)
+	}
+}
+
+func TestAppTokenCacheZeroValue(t *testing.T) {
+	if _, err := (&AppTokenCache{}).Token(); err != errorx.FetcherEmptyError {
+		t.Errorf("expected fetcher empty error, got %v", err)
+	}
+	var calls int
+	var cache = &AppTokenCache{Fetch: func() (string, int64, error) {
+		calls++
+		return "t", 0, nil
+	}}
+	for i := 0; i < 3; i++ {
+		if token, err := cache.Token(); err != nil || token != "t" {
+			t.Fatalf("unexpected token %s %v", token, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("zero value cache fetched %d times", calls)
+	}
+}
@@ -0,0 +1,397 @@
+// Package apple sign in with apple adapter.
+// Apple use an ES256 jwt signed by the .p8 key as client secret, post the callback as a form
+// when name or email is requested and send the user name only on the first authorization
+package apple
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"github.com/demo007x/oauth2-client/errorx"
+	"github.com/demo007x/oauth2-client/jose"
+	"github.com/demo007x/oauth2-client/oauth"
+	"github.com/demo007x/oauth2-client/types"
+	"github.com/demo007x/oauth2-client/utils"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	Issuer       = "https://appleid.apple.com"
+	AuthorizeURL = "https://appleid.apple.com/auth/authorize"
+	TokenURL     = "https://appleid.apple.com/auth/token"
+	RevokeURL    = "https://appleid.apple.com/auth/revoke"
+	KeysURL      = "https://appleid.apple.com/auth/keys"
+
+	DefaultScope = "name email"
+	// MaxSecretLifetime apple reject client secrets valid for more than six months
+	MaxSecretLifetime = 15777000 * time.Second
+)
+
+var InvalidKeyError = errors.New("apple: invalid p8 private key")
+
+type (
+	Option func(a *Apple)
+	// Apple sign in with apple service
+	Apple struct {
+		TeamID string
+		KeyID  string
+		// ClientID services id for web or bundle id for native apps
+		ClientID    string
+		PrivateKey  *ecdsa.PrivateKey
+		RedirectURI string
+		Scope       string
+		// SecretLifetime lifetime of the generated client secret, 30 days by default
+		SecretLifetime time.Duration
+
+		AuthorizeEndpoint string
+		TokenEndpoint     string
+		RevokeEndpoint    string
+		KeysEndpoint      string
+
+		mu           sync.Mutex
+		secret       string
+		secretExpiry time.Time
+		verifier     *oauth.IDTokenVerifier
+		interceptors []utils.RequestInterceptor
+		observer     oauth.Observer
+	}
+
+	// Callback form_post authorization response
+	Callback struct {
+		Code    string
+		State   string
+		IDToken string
+		// User name and email, only sent on the first authorization
+		User *CallbackUser
+	}
+
+	CallbackUser struct {
+		Name struct {
+			FirstName string `json:"firstName"`
+			LastName  string `json:"lastName"`
+		} `json:"name"`
+		Email string `json:"email"`
+	}
+
+	// Token apple token with verified id token
+	Token struct {
+		*oauth.Token
+		Claims *oauth.IDToken
+	}
+
+	// User normalized apple user
+	User struct {
+		Subject        string
+		Email          string
+		EmailVerified  bool
+		IsPrivateEmail bool
+		FirstName      string
+		LastName       string
+		Raw            map[string]interface{}
+	}
+)
+
+// WithScope set authorize scope. name, email or both
+func WithScope(scope string) Option {
+	return func(a *Apple) {
+		a.Scope = scope
+	}
+}
+
+// WithSecretLifetime set lifetime of the generated client secret, at most six months
+func WithSecretLifetime(lifetime time.Duration) Option {
+	return func(a *Apple) {
+		a.SecretLifetime = lifetime
+	}
+}
+
+// WithInterceptors modify or audit every request to apple before it is sent
+func WithInterceptors(interceptors ...utils.RequestInterceptor) Option {
+	return func(a *Apple) {
+		a.interceptors = append(a.interceptors, interceptors...)
+	}
+}
+
+// WithObserver report every call to apple to observer
+func WithObserver(observer oauth.Observer) Option {
+	return func(a *Apple) {
+		a.observer = observer
+	}
+}
+
+// ParsePrivateKey parse the pkcs8 pem encoded .p8 key downloaded from apple developer account
+func ParsePrivateKey(data []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, InvalidKeyError
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	ecKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, InvalidKeyError
+	}
+	return ecKey, nil
+}
+
+// ClientSecret return the cached client secret jwt, a new one is signed an hour before it expires
+func (a *Apple) ClientSecret() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.secret != "" && time.Now().Add(time.Hour).Before(a.secretExpiry) {
+		return a.secret, nil
+	}
+	if a.PrivateKey == nil {
+		return "", InvalidKeyError
+	}
+	var lifetime = a.SecretLifetime
+	if lifetime <= 0 || lifetime > MaxSecretLifetime {
+		lifetime = MaxSecretLifetime
+	}
+	var now = time.Now()
+	secret, err := jose.Sign(jose.ES256, a.PrivateKey, map[string]interface{}{"kid": a.KeyID}, jose.Claims{
+		Issuer:   a.TeamID,
+		Subject:  a.ClientID,
+		Audience: jose.Audience{Issuer},
+		IssuedAt: now.Unix(),
+		Expiry:   now.Add(lifetime).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	a.secret, a.secretExpiry = secret, now.Add(lifetime)
+	return secret, nil
+}
+
+// AuthorizeURL build authorize url, response_mode=form_post is set when name or email is requested
+func (a *Apple) AuthorizeURL(state, nonce string, opts ...oauth.WithOption) (string, error) {
+	var query = map[string]string{}
+	if strings.TrimSpace(a.Scope) != "" {
+		query["response_mode"] = "form_post"
+	}
+	opts = append([]oauth.WithOption{
+		oauth.WithRedirectURI(a.RedirectURI),
+		oauth.WithScope(a.Scope),
+		oauth.WithState(state),
+		oauth.WithNonce(nonce),
+		oauth.WithQuery(query),
+	}, opts...)
+	return oauth.NewOauth2Client(a.AuthorizeEndpoint, a.ClientID, opts...).AuthorizeURL()
+}
+
+// ParseCallback parse the form_post callback request. Error response is returned as *errorx.OauthError
+func ParseCallback(r *http.Request) (*Callback, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	if code := r.Form.Get("error"); code != "" {
+		return nil, &errorx.OauthError{StatusCode: http.StatusBadRequest, Code: code}
+	}
+	var cb = &Callback{
+		Code:    r.Form.Get("code"),
+		State:   r.Form.Get("state"),
+		IDToken: r.Form.Get("id_token"),
+	}
+	if strings.TrimSpace(cb.Code) == "" {
+		return nil, errorx.CodeEmptyError
+	}
+	if user := r.Form.Get("user"); user != "" {
+		cb.User = &CallbackUser{}
+		if err := json.Unmarshal([]byte(user), cb.User); err != nil {
+			return nil, err
+		}
+	}
+	return cb, nil
+}
+
+// Exchange exchange authorization code and verify the returned id token
+func (a *Apple) Exchange(code string) (*Token, error) {
+	if strings.TrimSpace(code) == "" {
+		return nil, errorx.CodeEmptyError
+	}
+	var values = url.Values{}
+	values.Set("grant_type", types.DefaultAccessTokenGrantType)
+	values.Set("code", code)
+	values.Set("redirect_uri", a.RedirectURI)
+	return a.token(values)
+}
+
+// Refresh verify the refresh token and get a new id token. Apple never rotate the refresh token
+func (a *Apple) Refresh(refreshToken string) (*Token, error) {
+	if strings.TrimSpace(refreshToken) == "" {
+		return nil, errorx.RefreshTokenNotEmpty
+	}
+	var values = url.Values{}
+	values.Set("grant_type", "refresh_token")
+	values.Set("refresh_token", refreshToken)
+	return a.token(values)
+}
+
+func (a *Apple) token(values url.Values) (*Token, error) {
+	data, err := a.postForm(a.TokenEndpoint, values)
+	if err != nil {
+		return nil, err
+	}
+	token, err := oauth.ParseToken(data)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := a.VerifyIDToken(token.IDToken, "")
+	if err != nil {
+		return nil, err
+	}
+	return &Token{Token: token, Claims: claims}, nil
+}
+
+// Revoke revoke refresh token or access token. token type hint is refresh_token or access_token
+func (a *Apple) Revoke(token, tokenTypeHint string) error {
+	if strings.TrimSpace(token) == "" {
+		return errorx.TokenEmptyError
+	}
+	var values = url.Values{}
+	values.Set("token", token)
+	if tokenTypeHint != "" {
+		values.Set("token_type_hint", tokenTypeHint)
+	}
+	_, err := a.postForm(a.RevokeEndpoint, values)
+	return err
+}
+
+// VerifyIDToken verify signature and claims of apple id token with apple jwks.
+// Empty nonce is not checked
+func (a *Apple) VerifyIDToken(idToken, nonce string) (*oauth.IDToken, error) {
+	a.mu.Lock()
+	if a.verifier == nil {
+		verifier, err := oauth.NewIDTokenVerifier(Issuer, a.ClientID, oauth.NewRemoteKeySet(a.KeysEndpoint,
+			oauth.RemoteKeySetWithInterceptors(a.interceptors...), oauth.RemoteKeySetWithObserver(a.observer)),
+			oauth.IDTokenVerifierWithLeeway(time.Minute))
+		if err != nil {
+			a.mu.Unlock()
+			return nil, err
+		}
+		a.verifier = verifier
+	}
+	var verifier = a.verifier
+	a.mu.Unlock()
+	return verifier.Verify(idToken, nonce)
+}
+
+// Login exchange the callback code, check nonce and merge the one-time user name
+func (a *Apple) Login(cb *Callback, nonce string) (*User, *Token, error) {
+	token, err := a.Exchange(cb.Code)
+	if err != nil {
+		return nil, nil, err
+	}
+	if nonce != "" && token.Claims.Nonce != nonce {
+		return nil, token, errorx.NonceMismatchError
+	}
+	var user = normalize(token.Claims)
+	if cb.User != nil {
+		user.FirstName, user.LastName = cb.User.Name.FirstName, cb.User.Name.LastName
+		if user.Email == "" {
+			user.Email = cb.User.Email
+		}
+	}
+	return user, token, nil
+}
+
+func (a *Apple) postForm(endpoint string, values url.Values) ([]byte, error) {
+	secret, err := a.ClientSecret()
+	if err != nil {
+		return nil, err
+	}
+	values.Set("client_id", a.ClientID)
+	values.Set("client_secret", secret)
+	return oauth.NewAPIRequest(endpoint, http.MethodPost,
+		oauth.APIRequestWithForm(values),
+		oauth.APIRequestWithHeader(map[string]string{"Accept": "application/json"}),
+		oauth.APIRequestWithResponseHandler(ResponseHandler),
+		oauth.APIRequestWithInterceptors(a.interceptors...),
+		oauth.APIRequestWithObserver(a.observer),
+	).DoRequest()
+}
+
+// ResponseHandler read body and return non 200 responses as *errorx.OauthError
+func ResponseHandler(resp *http.Response) ([]byte, error) {
+	data, err := types.DefaultOauthResponseHandler(resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errorx.ParseOauthError(resp.StatusCode, data)
+	}
+	return data, nil
+}
+
+// Profile normalized profile. Name is only known on the first authorization
+func (u *User) Profile() *oauth.Profile {
+	var profile = Profile(u.Raw)
+	profile.Email = u.Email
+	profile.Name = strings.TrimSpace(u.FirstName + " " + u.LastName)
+	return profile
+}
+
+// Profile map id token claims, apple send booleans as "true" or true
+func Profile(raw map[string]interface{}) *oauth.Profile {
+	return &oauth.Profile{
+		Subject:       oauth.StringClaim(raw, "sub"),
+		Email:         oauth.StringClaim(raw, "email"),
+		EmailVerified: oauth.StringClaim(raw, "email_verified") == "true",
+		Provider:      "apple",
+		Raw:           raw,
+	}
+}
+
+func normalize(claims *oauth.IDToken) *User {
+	var profile = Profile(claims.Raw)
+	return &User{
+		Subject:        claims.Subject,
+		Email:          profile.Email,
+		EmailVerified:  profile.EmailVerified,
+		IsPrivateEmail: oauth.StringClaim(claims.Raw, "is_private_email") == "true",
+		Raw:            claims.Raw,
+	}
+}
+
+// New return apple adapter with the .p8 private key
+func New(teamID, keyID, clientID string, privateKey *ecdsa.PrivateKey, redirectURI string, opts ...Option) *Apple {
+	var a = &Apple{
+		TeamID:            teamID,
+		KeyID:             keyID,
+		ClientID:          clientID,
+		PrivateKey:        privateKey,
+		RedirectURI:       redirectURI,
+		Scope:             DefaultScope,
+		SecretLifetime:    30 * 24 * time.Hour,
+		AuthorizeEndpoint: AuthorizeURL,
+		TokenEndpoint:     TokenURL,
+		RevokeEndpoint:    RevokeURL,
+		KeysEndpoint:      KeysURL,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// NewFromFile return apple adapter with the .p8 private key file
+func NewFromFile(teamID, keyID, clientID, keyFile, redirectURI string, opts ...Option) (*Apple, error) {
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ParsePrivateKey(data)
+	if err != nil {
+		return nil, err
+	}
+	return New(teamID, keyID, clientID, key, redirectURI, opts...), nil
+}
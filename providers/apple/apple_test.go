@@ -0,0 +1,194 @@
+package apple
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"github.com/demo007x/oauth2-client/errorx"
+	"github.com/demo007x/oauth2-client/jose"
+	"github.com/demo007x/oauth2-client/oauth"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApple(t *testing.T) {
+	p8, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appleKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(p8)
+	keyFile := filepath.Join(t.TempDir(), "AuthKey_KEY1.p8")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var idToken = func(nonce string) string {
+		token, err := jose.Sign(jose.RS256, appleKey, map[string]interface{}{"kid": "apple1"}, map[string]interface{}{
+			"iss":              Issuer,
+			"aud":              "com.example.web",
+			"sub":              "001234.abcd.1234",
+			"iat":              time.Now().Unix(),
+			"exp":              time.Now().Add(10 * time.Minute).Unix(),
+			"nonce":            nonce,
+			"email":            "x7f@privaterelay.appleid.com",
+			"email_verified":   "true",
+			"is_private_email": true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	var secrets = map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/auth/keys" {
+			jwk, _ := jose.NewJSONWebKey(&appleKey.PublicKey)
+			jwk.Kid, jwk.Alg, jwk.Use = "apple1", jose.RS256, "sig"
+			json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{*jwk}})
+			return
+		}
+		r.ParseForm()
+		jws, err := jose.Parse(r.PostForm.Get("client_secret"))
+		var claims jose.Claims
+		if err != nil || jws.KeyID() != "KEY1" || jws.Verify(&p8.PublicKey) != nil || jws.Claims(&claims) != nil ||
+			claims.Validate(jose.Expected{Issuer: "TEAM1", Audience: Issuer}) != nil || claims.Subject != r.PostForm.Get("client_id") {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		secrets[r.PostForm.Get("client_secret")] = true
+		switch r.URL.Path {
+		case "/auth/token":
+			if r.PostForm.Get("code") != "code" && r.PostForm.Get("refresh_token") != "RT" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"invalid_grant","error_description":"The code has expired or has been revoked."}`))
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"access_token":  "AT",
+				"token_type":    "Bearer",
+				"expires_in":    3600,
+				"refresh_token": "RT",
+				"id_token":      idToken("n-0S6"),
+			})
+		case "/auth/revoke":
+			if r.PostForm.Get("token") != "RT" || r.PostForm.Get("token_type_hint") != "refresh_token" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"invalid_request"}`))
+			}
+		}
+	}))
+	defer server.Close()
+
+	a, err := NewFromFile("TEAM1", "KEY1", "com.example.web", keyFile, "https://app.example.com/apple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.TokenEndpoint = server.URL + "/auth/token"
+	a.RevokeEndpoint = server.URL + "/auth/revoke"
+	a.KeysEndpoint = server.URL + "/auth/keys"
+
+	authURL, err := a.AuthorizeURL("state", "n-0S6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(authURL)
+	if q := u.Query(); q.Get("response_mode") != "form_post" || q.Get("scope") != DefaultScope || q.Get("nonce") != "n-0S6" {
+		t.Errorf("unexpected authorize url %s", authURL)
+	}
+
+	var form = url.Values{}
+	form.Set("code", "code")
+	form.Set("state", "state")
+	form.Set("user", `{"name":{"firstName":"John","lastName":"Appleseed"},"email":"x7f@privaterelay.appleid.com"}`)
+	req := httptest.NewRequest(http.MethodPost, "/apple", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	cb, err := ParseCallback(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, token, err := a.Login(cb, "n-0S6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.RefreshToken != "RT" || token.Claims.Subject != "001234.abcd.1234" {
+		t.Errorf("unexpected token %+v", token)
+	}
+	if user.FirstName != "John" || user.LastName != "Appleseed" || !user.EmailVerified || !user.IsPrivateEmail {
+		t.Errorf("unexpected user %+v", user)
+	}
+	if profile := user.Profile(); profile.Subject != "001234.abcd.1234" || profile.Provider != "apple" || profile.Name != "John Appleseed" {
+		t.Errorf("unexpected profile %+v", profile)
+	}
+	if _, _, err := a.Login(cb, "other"); err != errorx.NonceMismatchError {
+		t.Errorf("expected nonce mismatch, got %v", err)
+	}
+
+	var oe *errorx.OauthError
+	if _, err := a.Exchange("expired"); !errors.As(err, &oe) || oe.Code != "invalid_grant" {
+		t.Errorf("expected invalid_grant, got %v", err)
+	}
+	if _, err := a.Refresh("RT"); err != nil {
+		t.Error(err)
+	}
+	if err := a.Revoke("RT", "refresh_token"); err != nil {
+		t.Error(err)
+	}
+	if len(secrets) != 1 {
+		t.Errorf("client secret should be cached, got %d secrets", len(secrets))
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/apple", strings.NewReader("error=user_cancelled_authorize&state=state"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if _, err := ParseCallback(req); !errors.As(err, &oe) || oe.Code != "user_cancelled_authorize" {
+		t.Errorf("expected user_cancelled_authorize, got %v", err)
+	}
+}
+
+func TestAppleInterceptors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Tenant") != "t1" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(``))
+	}))
+	defer server.Close()
+
+	var observed []*oauth.Observation
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := New("TEAM1", "KEY1", "com.example.web", key, "",
+		WithInterceptors(func(r *http.Request) error {
+			r.Header.Set("X-Tenant", "t1")
+			return nil
+		}),
+		WithObserver(oauth.ObserverFunc(func(o *oauth.Observation) { observed = append(observed, o) })),
+	)
+	a.RevokeEndpoint = server.URL
+	if err := a.Revoke("RT", "refresh_token"); err != nil {
+		t.Fatalf("expected interceptor header sent, got %v", err)
+	}
+	if len(observed) != 1 || observed[0].Endpoint != oauth.EndpointAPI || observed[0].StatusCode != http.StatusOK {
+		t.Errorf("expected the api call observed, got %+v", observed)
+	}
+}
@@ -0,0 +1,311 @@
+// Package dingtalk dingtalk login adapter.
+// DingTalk exchange codes with camelCase json bodies on api.dingtalk.com,
+// pass the user token in x-acs-dingtalk-access-token header
+// and resolve enterprise userid with the cached app access token on oapi.dingtalk.com
+package dingtalk
+
+import (
+	"encoding/json"
+	"github.com/demo007x/oauth2-client/errorx"
+	"github.com/demo007x/oauth2-client/oauth"
+	"github.com/demo007x/oauth2-client/providers"
+	"github.com/demo007x/oauth2-client/types"
+	"github.com/demo007x/oauth2-client/utils"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	AuthorizeURL = "https://login.dingtalk.com/oauth2/auth"
+	APIURL       = "https://api.dingtalk.com"
+	OAPIURL      = "https://oapi.dingtalk.com"
+
+	// ScopeOpenID user profile of the login user
+	ScopeOpenID = "openid"
+	// ScopeCorpID also return the corp id of the login user
+	ScopeCorpID = "openid corpid"
+)
+
+type (
+	Option func(d *DingTalk)
+	// DingTalk dingtalk internal or third party application
+	DingTalk struct {
+		ClientID     string
+		ClientSecret string
+		RedirectURI  string
+		Scope        string
+
+		AuthorizeEndpoint string
+		APIEndpoint       string
+		OAPIEndpoint      string
+
+		appToken     *providers.AppTokenCache
+		interceptors []utils.RequestInterceptor
+		observer     oauth.Observer
+	}
+
+	// Token dingtalk user access token
+	Token struct {
+		*oauth.Token
+		CorpID string
+	}
+
+	// User normalized dingtalk user profile
+	User struct {
+		OpenID  string
+		UnionID string
+		Nick    string
+		Avatar  string
+		Mobile  string
+		Email   string
+		// StateCode mobile country code
+		StateCode string
+		Raw       map[string]interface{}
+	}
+)
+
+// WithScope set authorize scope
+func WithScope(scope string) Option {
+	return func(d *DingTalk) {
+		d.Scope = scope
+	}
+}
+
+// WithInterceptors modify or audit every api request before it is sent
+func WithInterceptors(interceptors ...utils.RequestInterceptor) Option {
+	return func(d *DingTalk) {
+		d.interceptors = append(d.interceptors, interceptors...)
+	}
+}
+
+// WithObserver report every api call to observer
+func WithObserver(observer oauth.Observer) Option {
+	return func(d *DingTalk) {
+		d.observer = observer
+	}
+}
+
+// AuthorizeURL build authorize url, prompt=consent is required by dingtalk
+func (d *DingTalk) AuthorizeURL(state string, opts ...oauth.WithOption) (string, error) {
+	opts = append([]oauth.WithOption{
+		oauth.WithRedirectURI(d.RedirectURI),
+		oauth.WithScope(d.Scope),
+		oauth.WithState(state),
+		oauth.WithQuery(map[string]string{"prompt": "consent"}),
+	}, opts...)
+	return oauth.NewOauth2Client(d.AuthorizeEndpoint, d.ClientID, opts...).AuthorizeURL()
+}
+
+// Exchange exchange authorization code for user access token
+func (d *DingTalk) Exchange(code string) (*Token, error) {
+	if strings.TrimSpace(code) == "" {
+		return nil, errorx.CodeEmptyError
+	}
+	return d.userAccessToken(map[string]string{"code": code, "grantType": "authorization_code"})
+}
+
+// Refresh renew user access token
+func (d *DingTalk) Refresh(refreshToken string) (*Token, error) {
+	if strings.TrimSpace(refreshToken) == "" {
+		return nil, errorx.RefreshTokenNotEmpty
+	}
+	return d.userAccessToken(map[string]string{"refreshToken": refreshToken, "grantType": "refresh_token"})
+}
+
+func (d *DingTalk) userAccessToken(params map[string]string) (*Token, error) {
+	params["clientId"] = d.ClientID
+	params["clientSecret"] = d.ClientSecret
+	data, err := d.postJSON(d.APIEndpoint+"/v1.0/oauth2/userAccessToken", params)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		AccessToken  string `json:"accessToken"`
+		RefreshToken string `json:"refreshToken"`
+		ExpireIn     int64  `json:"expireIn"`
+		CorpID       string `json:"corpId"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, err
+	}
+	if resp.AccessToken == "" {
+		return nil, errorx.TokenEmptyError
+	}
+	var raw = map[string]interface{}{}
+	_ = json.Unmarshal(data, &raw)
+	var token = &oauth.Token{
+		AccessToken:  resp.AccessToken,
+		TokenType:    "Bearer",
+		RefreshToken: resp.RefreshToken,
+		ExpiresIn:    resp.ExpireIn,
+		Raw:          raw,
+	}
+	if resp.ExpireIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(resp.ExpireIn) * time.Second)
+	}
+	return &Token{Token: token, CorpID: resp.CorpID}, nil
+}
+
+// UserInfo get the profile of the login user
+func (d *DingTalk) UserInfo(accessToken string) (*User, error) {
+	data, err := oauth.NewUserInfo(d.APIEndpoint+"/v1.0/contact/users/me", accessToken,
+		oauth.UserInfoWithMethod(http.MethodGet),
+		oauth.UserInfoWithHeader(map[string]string{"x-acs-dingtalk-access-token": accessToken}),
+		oauth.UserInfoWithResponseHandler(ResponseHandler),
+		oauth.UserInfoWithInterceptors(d.interceptors...),
+		oauth.UserInfoWithObserver(d.observer),
+	).DoRequest()
+	if err != nil {
+		return nil, err
+	}
+	var raw = map[string]interface{}{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	return normalize(raw), nil
+}
+
+// Login exchange the code and fetch user profile
+func (d *DingTalk) Login(code string) (*User, *Token, error) {
+	token, err := d.Exchange(code)
+	if err != nil {
+		return nil, nil, err
+	}
+	user, err := d.UserInfo(token.AccessToken)
+	if err != nil {
+		return nil, token, err
+	}
+	return user, token, nil
+}
+
+// AppAccessToken return the cached application access token
+func (d *DingTalk) AppAccessToken() (string, error) {
+	return d.appToken.Token()
+}
+
+func (d *DingTalk) fetchAppAccessToken() (string, int64, error) {
+	data, err := d.postJSON(d.APIEndpoint+"/v1.0/oauth2/accessToken", map[string]string{
+		"appKey":    d.ClientID,
+		"appSecret": d.ClientSecret,
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	var resp struct {
+		AccessToken string `json:"accessToken"`
+		ExpireIn    int64  `json:"expireIn"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return "", 0, err
+	}
+	if resp.AccessToken == "" {
+		return "", 0, errorx.TokenEmptyError
+	}
+	return resp.AccessToken, resp.ExpireIn, nil
+}
+
+// UserID resolve the enterprise userid of an unionid, the user must be a member of the corp.
+// A rejected app access token is renewed and the request is sent once more
+func (d *DingTalk) UserID(unionID string) (string, error) {
+	data, err := d.getByUnionID(unionID)
+	if IsTokenInvalid(err) {
+		d.appToken.Invalidate()
+		data, err = d.getByUnionID(unionID)
+	}
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		Result struct {
+			UserID string `json:"userid"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return "", err
+	}
+	return resp.Result.UserID, nil
+}
+
+func (d *DingTalk) getByUnionID(unionID string) ([]byte, error) {
+	appToken, err := d.AppAccessToken()
+	if err != nil {
+		return nil, err
+	}
+	var endpoint = d.OAPIEndpoint + "/topapi/user/getbyunionid?access_token=" + url.QueryEscape(appToken)
+	return d.postJSON(endpoint, map[string]string{"unionid": unionID})
+}
+
+func (d *DingTalk) postJSON(endpoint string, params interface{}) ([]byte, error) {
+	return oauth.NewAPIRequest(endpoint, http.MethodPost,
+		oauth.APIRequestWithJSON(params),
+		oauth.APIRequestWithResponseHandler(ResponseHandler),
+		oauth.APIRequestWithInterceptors(d.interceptors...),
+		oauth.APIRequestWithObserver(d.observer),
+	).DoRequest()
+}
+
+// ResponseHandler read body and return code/message or errcode/errmsg envelopes as *Error
+func ResponseHandler(resp *http.Response) ([]byte, error) {
+	data, err := types.DefaultOauthResponseHandler(resp)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkError(resp.StatusCode, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Profile normalized profile, subject is unionid which is stable across apps of the same corp
+func (u *User) Profile() *oauth.Profile {
+	return Profile(u.Raw)
+}
+
+// Profile map /v1.0/contact/users/me, subject is unionid which is stable across apps of the same corp
+func Profile(raw map[string]interface{}) *oauth.Profile {
+	var subject = oauth.StringClaim(raw, "unionId")
+	if subject == "" {
+		subject = oauth.StringClaim(raw, "openId")
+	}
+	return &oauth.Profile{
+		Subject:  subject,
+		Email:    oauth.StringClaim(raw, "email"),
+		Name:     oauth.StringClaim(raw, "nick"),
+		Picture:  oauth.StringClaim(raw, "avatarUrl"),
+		Provider: "dingtalk",
+		Raw:      raw,
+	}
+}
+
+func normalize(raw map[string]interface{}) *User {
+	return &User{
+		OpenID:    oauth.StringClaim(raw, "openId"),
+		UnionID:   oauth.StringClaim(raw, "unionId"),
+		Nick:      oauth.StringClaim(raw, "nick"),
+		Avatar:    oauth.StringClaim(raw, "avatarUrl"),
+		Mobile:    oauth.StringClaim(raw, "mobile"),
+		Email:     oauth.StringClaim(raw, "email"),
+		StateCode: oauth.StringClaim(raw, "stateCode"),
+		Raw:       raw,
+	}
+}
+
+// New return dingtalk adapter, client id is the app key of the application
+func New(clientID, clientSecret, redirectURI string, opts ...Option) *DingTalk {
+	var d = &DingTalk{
+		ClientID:          clientID,
+		ClientSecret:      clientSecret,
+		RedirectURI:       redirectURI,
+		Scope:             ScopeOpenID,
+		AuthorizeEndpoint: AuthorizeURL,
+		APIEndpoint:       APIURL,
+		OAPIEndpoint:      OAPIURL,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	d.appToken = providers.NewAppTokenCache(d.fetchAppAccessToken)
+	return d
+}
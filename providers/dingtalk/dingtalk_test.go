@@ -0,0 +1,134 @@
+package dingtalk
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/demo007x/oauth2-client/oauth"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestDingTalk(t *testing.T) {
+	var appTokens int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body = map[string]string{}
+		if r.Method == http.MethodPost {
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+		}
+		switch r.URL.Path {
+		case "/v1.0/oauth2/userAccessToken":
+			if body["clientId"] != "key" || body["clientSecret"] != "secret" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"code":"invalidClientIdOrSecret","message":"无效的clientId或者clientSecret","requestid":"r1"}`))
+				return
+			}
+			if (body["grantType"] == "authorization_code" && body["code"] != "code") || (body["grantType"] == "refresh_token" && body["refreshToken"] != "RT") {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"code":"invalidParameter.authCode.notFound","message":"不合法的临时授权码","requestid":"r2"}`))
+				return
+			}
+			w.Write([]byte(`{"expireIn":7200,"accessToken":"UAT","refreshToken":"RT","corpId":"ding1"}`))
+		case "/v1.0/contact/users/me":
+			if r.Header.Get("x-acs-dingtalk-access-token") != "UAT" {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"code":"invalidAuthentication","message":"不合法的access_token"}`))
+				return
+			}
+			w.Write([]byte(`{"nick":"zhangsan","avatarUrl":"https://static-legacy.dingtalk.com/a.jpg","mobile":"150xxxx9144","openId":"OPENID","unionId":"UNIONID","email":"zhangsan@example.com","stateCode":"86"}`))
+		case "/v1.0/oauth2/accessToken":
+			appTokens++
+			w.Write([]byte(`{"accessToken":"APP` + string(rune('0'+appTokens)) + `","expireIn":7200}`))
+		case "/topapi/user/getbyunionid":
+			// the first app token is revoked on server side
+			if r.URL.Query().Get("access_token") != "APP2" {
+				w.Write([]byte(`{"errcode":40014,"errmsg":"不合法的access_token"}`))
+				return
+			}
+			if body["unionid"] != "UNIONID" {
+				w.Write([]byte(`{"errcode":60121,"errmsg":"找不到该用户"}`))
+				return
+			}
+			w.Write([]byte(`{"errcode":0,"errmsg":"ok","result":{"contact_type":0,"userid":"USERID"}}`))
+		}
+	}))
+	defer server.Close()
+
+	d := New("key", "secret", "https://app.example.com/dingtalk", WithScope(ScopeCorpID))
+	d.APIEndpoint = server.URL
+	d.OAPIEndpoint = server.URL
+
+	authURL, err := d.AuthorizeURL("state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(authURL)
+	if q := u.Query(); q.Get("client_id") != "key" || q.Get("prompt") != "consent" || q.Get("scope") != ScopeCorpID || q.Get("response_type") != "code" {
+		t.Errorf("unexpected authorize url %s", authURL)
+	}
+
+	user, token, err := d.Login("code")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "UAT" || token.CorpID != "ding1" || token.Expiry.IsZero() {
+		t.Errorf("unexpected token %+v", token)
+	}
+	if user.UnionID != "UNIONID" || user.Nick != "zhangsan" || user.Avatar == "" {
+		t.Errorf("unexpected user %+v", user)
+	}
+	if profile := user.Profile(); profile.Subject != "UNIONID" || profile.Provider != "dingtalk" {
+		t.Errorf("unexpected profile %+v", profile)
+	}
+
+	if _, err := d.Exchange("bad"); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("expected invalid code, got %v", err)
+	}
+	if token, err := d.Refresh("RT"); err != nil || token.RefreshToken != "RT" {
+		t.Errorf("unexpected refresh %+v %v", token, err)
+	}
+	if _, err := d.UserInfo("bad"); !errors.Is(err, ErrInvalidAuthentication) {
+		t.Errorf("expected invalid authentication, got %v", err)
+	}
+
+	userID, err := d.UserID("UNIONID")
+	if err != nil || userID != "USERID" {
+		t.Errorf("unexpected userid %s %v", userID, err)
+	}
+	if _, err := d.UserID("other"); !errors.Is(err, ErrNotCorpMember) {
+		t.Errorf("expected not corp member, got %v", err)
+	}
+	if appTokens != 2 {
+		t.Errorf("app token should be cached and renewed once, fetched %d times", appTokens)
+	}
+}
+
+func TestDingTalkInterceptors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Tenant") != "t1" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"accessToken":"APP","expireIn":7200}`))
+	}))
+	defer server.Close()
+
+	var observed []*oauth.Observation
+	d := New("key", "secret", "",
+		WithInterceptors(func(r *http.Request) error {
+			r.Header.Set("X-Tenant", "t1")
+			return nil
+		}),
+		WithObserver(oauth.ObserverFunc(func(o *oauth.Observation) { observed = append(observed, o) })),
+	)
+	d.APIEndpoint = server.URL
+	if token, err := d.AppAccessToken(); err != nil || token != "APP" {
+		t.Fatalf("expected interceptor header sent, got %q %v", token, err)
+	}
+	if len(observed) != 1 || observed[0].Endpoint != oauth.EndpointAPI || observed[0].StatusCode != http.StatusOK {
+		t.Errorf("expected the api call observed, got %+v", observed)
+	}
+}
@@ -0,0 +1,68 @@
+package dingtalk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Error dingtalk api error.
+// api.dingtalk.com return code and message with http error status,
+// oapi.dingtalk.com return errcode and errmsg inside 200 responses
+type Error struct {
+	StatusCode int    `json:"-"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+	RequestID  string `json:"requestid"`
+}
+
+// Common dingtalk errors, compare with errors.Is
+var (
+	ErrInvalidAuthentication = &Error{Code: "invalidAuthentication"}
+	ErrInvalidCode           = &Error{Code: "invalidParameter.authCode.notFound"}
+	ErrInvalidRefreshToken   = &Error{Code: "invalidParameter.refreshToken.notFound"}
+	ErrForbidden             = &Error{Code: "Forbidden.AccessDenied.AccessTokenPermissionDenied"}
+	ErrInvalidAppToken       = &Error{Code: "40014"}
+	ErrAppTokenExpired       = &Error{Code: "42001"}
+	ErrNotCorpMember         = &Error{Code: "60121"}
+)
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("dingtalk error: status %d, code %s, %s", e.StatusCode, e.Code, e.Message)
+}
+
+// Is match errors by code
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// IsTokenInvalid whether the app access token is rejected and should be renewed
+func IsTokenInvalid(err error) bool {
+	return errors.Is(err, ErrInvalidAppToken) || errors.Is(err, ErrAppTokenExpired)
+}
+
+// checkError return *Error of both error envelopes
+func checkError(statusCode int, data []byte) error {
+	var envelope struct {
+		Error
+		ErrCode *int   `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		if statusCode >= 400 {
+			return &Error{StatusCode: statusCode, Message: string(data)}
+		}
+		return nil
+	}
+	if envelope.ErrCode != nil && *envelope.ErrCode != 0 {
+		return &Error{StatusCode: statusCode, Code: strconv.Itoa(*envelope.ErrCode), Message: envelope.ErrMsg, RequestID: envelope.RequestID}
+	}
+	if statusCode >= 400 || envelope.Code != "" {
+		var e = envelope.Error
+		e.StatusCode = statusCode
+		return &e
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package feishu
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Error feishu api error returned as code and msg
+type Error struct {
+	StatusCode int    `json:"-"`
+	Code       int    `json:"code"`
+	Msg        string `json:"msg"`
+}
+
+// Common feishu errors, compare with errors.Is
+var (
+	ErrInvalidAppSecret       = &Error{Code: 10014, Msg: "app secret invalid"}
+	ErrMissingAccessToken     = &Error{Code: 99991661, Msg: "missing access token"}
+	ErrInvalidTenantToken     = &Error{Code: 99991663, Msg: "invalid tenant access token"}
+	ErrInvalidAppToken        = &Error{Code: 99991664, Msg: "invalid app access token"}
+	ErrInvalidUserAccessToken = &Error{Code: 99991668, Msg: "invalid user access token"}
+	ErrUserAccessTokenExpired = &Error{Code: 99991677, Msg: "user access token expired"}
+)
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("feishu error: status %d, code %d, %s", e.StatusCode, e.Code, e.Msg)
+}
+
+// Is match errors by code
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// IsTokenInvalid whether the app or tenant access token is rejected and should be renewed
+func IsTokenInvalid(err error) bool {
+	return errors.Is(err, ErrInvalidAppToken) || errors.Is(err, ErrInvalidTenantToken)
+}
+
+// checkError return *Error when body carry a non zero code or the status is not ok
+func checkError(statusCode int, data []byte) error {
+	var e = &Error{StatusCode: statusCode}
+	if err := json.Unmarshal(data, e); err != nil {
+		if statusCode >= 400 {
+			e.Msg = string(data)
+			return e
+		}
+		return nil
+	}
+	if e.Code != 0 || statusCode >= 400 {
+		return e
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+// Package feishu feishu and lark login adapter.
+// Feishu wrap every response in a code/msg/data envelope and exchange user codes
+// with the cached app_access_token instead of the app secret
+package feishu
+
+import (
+	"encoding/json"
+	"github.com/demo007x/oauth2-client/errorx"
+	"github.com/demo007x/oauth2-client/oauth"
+	"github.com/demo007x/oauth2-client/providers"
+	"github.com/demo007x/oauth2-client/types"
+	"github.com/demo007x/oauth2-client/utils"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	FeishuAuthorizeURL = "https://accounts.feishu.cn/open-apis/authen/v1/authorize"
+	FeishuAPIURL       = "https://open.feishu.cn"
+	LarkAuthorizeURL   = "https://accounts.larksuite.com/open-apis/authen/v1/authorize"
+	LarkAPIURL         = "https://open.larksuite.com"
+)
+
+type (
+	Option func(f *Feishu)
+	// Feishu feishu or lark self built application
+	Feishu struct {
+		AppID       string
+		AppSecret   string
+		RedirectURI string
+		Scope       string
+
+		AuthorizeEndpoint string
+		APIEndpoint       string
+
+		appToken     *providers.AppTokenCache
+		tenantToken  *providers.AppTokenCache
+		interceptors []utils.RequestInterceptor
+		observer     oauth.Observer
+	}
+
+	// Token feishu user access token
+	Token struct {
+		*oauth.Token
+		RefreshExpiresIn int64
+	}
+
+	// User normalized feishu user profile
+	User struct {
+		OpenID          string
+		UnionID         string
+		UserID          string
+		TenantKey       string
+		Name            string
+		EnName          string
+		Avatar          string
+		Email           string
+		EnterpriseEmail string
+		Mobile          string
+		EmployeeNo      string
+		Raw             map[string]interface{}
+	}
+)
+
+// WithScope set authorize scope, space separated
+func WithScope(scope string) Option {
+	return func(f *Feishu) {
+		f.Scope = scope
+	}
+}
+
+// WithInterceptors modify or audit every api request before it is sent
+func WithInterceptors(interceptors ...utils.RequestInterceptor) Option {
+	return func(f *Feishu) {
+		f.interceptors = append(f.interceptors, interceptors...)
+	}
+}
+
+// WithObserver report every api call to observer
+func WithObserver(observer oauth.Observer) Option {
+	return func(f *Feishu) {
+		f.observer = observer
+	}
+}
+
+// AuthorizeURL build authorize url
+func (f *Feishu) AuthorizeURL(state string, opts ...oauth.WithOption) (string, error) {
+	opts = append([]oauth.WithOption{
+		oauth.WithRedirectURI(f.RedirectURI),
+		oauth.WithScope(f.Scope),
+		oauth.WithState(state),
+	}, opts...)
+	return oauth.NewOauth2Client(f.AuthorizeEndpoint, f.AppID, opts...).AuthorizeURL()
+}
+
+// Exchange exchange authorization code for user access token
+func (f *Feishu) Exchange(code string) (*Token, error) {
+	if strings.TrimSpace(code) == "" {
+		return nil, errorx.CodeEmptyError
+	}
+	return f.userAccessToken("/open-apis/authen/v1/oidc/access_token", map[string]string{
+		"grant_type": "authorization_code",
+		"code":       code,
+	})
+}
+
+// Refresh renew user access token, the refresh token can only be used once
+func (f *Feishu) Refresh(refreshToken string) (*Token, error) {
+	if strings.TrimSpace(refreshToken) == "" {
+		return nil, errorx.RefreshTokenNotEmpty
+	}
+	return f.userAccessToken("/open-apis/authen/v1/oidc/refresh_access_token", map[string]string{
+		"grant_type":    "refresh_token",
+		"refresh_token": refreshToken,
+	})
+}
+
+func (f *Feishu) userAccessToken(path string, params map[string]string) (*Token, error) {
+	data, err := f.withAppToken(f.appToken, func(appToken string) ([]byte, error) {
+		return f.postJSON(f.APIEndpoint+path, map[string]string{"Authorization": utils.GenerateBearAuthorization(appToken)}, params)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, err
+	}
+	token, err := oauth.ParseToken(resp.Data)
+	if err != nil {
+		return nil, err
+	}
+	var t = &Token{Token: token}
+	if refreshExpiresIn, ok := token.Raw["refresh_expires_in"].(json.Number); ok {
+		t.RefreshExpiresIn, _ = refreshExpiresIn.Int64()
+	}
+	return t, nil
+}
+
+// UserInfo get the profile of the login user
+func (f *Feishu) UserInfo(accessToken string) (*User, error) {
+	data, err := oauth.NewUserInfo(f.APIEndpoint+"/open-apis/authen/v1/user_info", accessToken,
+		oauth.UserInfoWithMethod(http.MethodGet),
+		oauth.UserInfoWithResponseHandler(ResponseHandler),
+		oauth.UserInfoWithInterceptors(f.interceptors...),
+		oauth.UserInfoWithObserver(f.observer),
+	).DoRequest()
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, err
+	}
+	return normalize(resp.Data), nil
+}
+
+// Login exchange the code and fetch user profile
+func (f *Feishu) Login(code string) (*User, *Token, error) {
+	token, err := f.Exchange(code)
+	if err != nil {
+		return nil, nil, err
+	}
+	user, err := f.UserInfo(token.AccessToken)
+	if err != nil {
+		return nil, token, err
+	}
+	return user, token, nil
+}
+
+// AppAccessToken return the cached app_access_token
+func (f *Feishu) AppAccessToken() (string, error) {
+	return f.appToken.Token()
+}
+
+// TenantAccessToken return the cached tenant_access_token for tenant level apis
+func (f *Feishu) TenantAccessToken() (string, error) {
+	return f.tenantToken.Token()
+}
+
+func (f *Feishu) fetchToken(path, field string) providers.AppTokenFetcher {
+	return func() (string, int64, error) {
+		data, err := f.postJSON(f.APIEndpoint+path, nil, map[string]string{
+			"app_id":     f.AppID,
+			"app_secret": f.AppSecret,
+		})
+		if err != nil {
+			return "", 0, err
+		}
+		var resp = map[string]interface{}{}
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return "", 0, err
+		}
+		token, _ := resp[field].(string)
+		if token == "" {
+			return "", 0, errorx.TokenEmptyError
+		}
+		expire, _ := resp["expire"].(float64)
+		return token, int64(expire), nil
+	}
+}
+
+// withAppToken call api with the cached token, renew the token and call once more if it is rejected
+func (f *Feishu) withAppToken(cache *providers.AppTokenCache, call func(token string) ([]byte, error)) ([]byte, error) {
+	token, err := cache.Token()
+	if err != nil {
+		return nil, err
+	}
+	data, err := call(token)
+	if IsTokenInvalid(err) {
+		cache.Invalidate()
+		if token, err = cache.Token(); err != nil {
+			return nil, err
+		}
+		data, err = call(token)
+	}
+	return data, err
+}
+
+func (f *Feishu) postJSON(endpoint string, header map[string]string, params interface{}) ([]byte, error) {
+	return oauth.NewAPIRequest(endpoint, http.MethodPost,
+		oauth.APIRequestWithJSON(params),
+		oauth.APIRequestWithHeader(map[string]string{"Content-Type": "application/json; charset=utf-8"}),
+		oauth.APIRequestWithHeader(header),
+		oauth.APIRequestWithResponseHandler(ResponseHandler),
+		oauth.APIRequestWithInterceptors(f.interceptors...),
+		oauth.APIRequestWithObserver(f.observer),
+	).DoRequest()
+}
+
+// ResponseHandler read body and return non zero code as *Error
+func ResponseHandler(resp *http.Response) ([]byte, error) {
+	data, err := types.DefaultOauthResponseHandler(resp)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkError(resp.StatusCode, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Profile normalized profile, subject is union_id which is stable across apps of the same developer
+func (u *User) Profile() *oauth.Profile {
+	return Profile(u.Raw)
+}
+
+// Profile map data of /open-apis/authen/v1/user_info, subject is union_id which is stable across apps of the same developer
+func Profile(raw map[string]interface{}) *oauth.Profile {
+	var subject = oauth.StringClaim(raw, "union_id")
+	if subject == "" {
+		subject = oauth.StringClaim(raw, "open_id")
+	}
+	var email = oauth.StringClaim(raw, "email")
+	if email == "" {
+		email = oauth.StringClaim(raw, "enterprise_email")
+	}
+	return &oauth.Profile{
+		Subject:  subject,
+		Email:    email,
+		Name:     oauth.StringClaim(raw, "name"),
+		Picture:  oauth.StringClaim(raw, "avatar_url"),
+		Provider: "feishu",
+		Raw:      raw,
+	}
+}
+
+func normalize(raw map[string]interface{}) *User {
+	return &User{
+		OpenID:          oauth.StringClaim(raw, "open_id"),
+		UnionID:         oauth.StringClaim(raw, "union_id"),
+		UserID:          oauth.StringClaim(raw, "user_id"),
+		TenantKey:       oauth.StringClaim(raw, "tenant_key"),
+		Name:            oauth.StringClaim(raw, "name"),
+		EnName:          oauth.StringClaim(raw, "en_name"),
+		Avatar:          oauth.StringClaim(raw, "avatar_url"),
+		Email:           oauth.StringClaim(raw, "email"),
+		EnterpriseEmail: oauth.StringClaim(raw, "enterprise_email"),
+		Mobile:          oauth.StringClaim(raw, "mobile"),
+		EmployeeNo:      oauth.StringClaim(raw, "employee_no"),
+		Raw:             raw,
+	}
+}
+
+// New return feishu adapter
+func New(appID, appSecret, redirectURI string, opts ...Option) *Feishu {
+	return newFeishu(FeishuAuthorizeURL, FeishuAPIURL, appID, appSecret, redirectURI, opts...)
+}
+
+// NewLark return adapter of lark, the international version of feishu
+func NewLark(appID, appSecret, redirectURI string, opts ...Option) *Feishu {
+	return newFeishu(LarkAuthorizeURL, LarkAPIURL, appID, appSecret, redirectURI, opts...)
+}
+
+func newFeishu(authorizeURL, apiURL, appID, appSecret, redirectURI string, opts ...Option) *Feishu {
+	var f = &Feishu{
+		AppID:             appID,
+		AppSecret:         appSecret,
+		RedirectURI:       redirectURI,
+		AuthorizeEndpoint: authorizeURL,
+		APIEndpoint:       apiURL,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	f.appToken = providers.NewAppTokenCache(f.fetchToken("/open-apis/auth/v3/app_access_token/internal", "app_access_token"))
+	f.tenantToken = providers.NewAppTokenCache(f.fetchToken("/open-apis/auth/v3/tenant_access_token/internal", "tenant_access_token"))
+	// feishu only issue a new token within the last 30 minutes of the old one
+	f.appToken.Leeway, f.tenantToken.Leeway = 30*time.Minute, 30*time.Minute
+	return f
+}
@@ -0,0 +1,137 @@
+package feishu
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/demo007x/oauth2-client/oauth"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestFeishu(t *testing.T) {
+	var appTokens, tenantTokens int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body = map[string]string{}
+		if r.Method == http.MethodPost {
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+		}
+		switch r.URL.Path {
+		case "/open-apis/auth/v3/app_access_token/internal":
+			if body["app_id"] != "cli_1" || body["app_secret"] != "secret" {
+				w.Write([]byte(`{"code":10014,"msg":"app secret invalid"}`))
+				return
+			}
+			appTokens++
+			w.Write([]byte(`{"code":0,"msg":"ok","app_access_token":"t-app` + string(rune('0'+appTokens)) + `","expire":7200,"tenant_access_token":"t-tenant"}`))
+		case "/open-apis/auth/v3/tenant_access_token/internal":
+			tenantTokens++
+			w.Write([]byte(`{"code":0,"msg":"ok","tenant_access_token":"t-tenant","expire":7200}`))
+		case "/open-apis/authen/v1/oidc/access_token", "/open-apis/authen/v1/oidc/refresh_access_token":
+			// the first app token is revoked on server side
+			if r.Header.Get("Authorization") != "Bearer t-app2" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"code":99991664,"msg":"invalid app_access_token"}`))
+				return
+			}
+			if body["code"] != "code" && body["refresh_token"] != "ur-RT" {
+				w.Write([]byte(`{"code":20003,"msg":"invalid grant"}`))
+				return
+			}
+			w.Write([]byte(`{"code":0,"msg":"success","data":{"access_token":"u-AT","token_type":"Bearer","expires_in":6900,"refresh_token":"ur-RT","refresh_expires_in":2592000,"scope":"contact:user.email:readonly"}}`))
+		case "/open-apis/authen/v1/user_info":
+			if r.Header.Get("Authorization") != "Bearer u-AT" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"code":99991668,"msg":"Invalid access token for authorization"}`))
+				return
+			}
+			w.Write([]byte(`{"code":0,"msg":"success","data":{"name":"zhangsan","en_name":"San Zhang","avatar_url":"https://s1-imfile.feishucdn.com/a.jpg","open_id":"ou_1","union_id":"on_1","email":"zhangsan@example.com","user_id":"5d9bdxxx","tenant_key":"736588c92lxf175d"}}`))
+		}
+	}))
+	defer server.Close()
+
+	f := New("cli_1", "secret", "https://app.example.com/feishu", WithScope("contact:user.email:readonly"))
+	f.APIEndpoint = server.URL
+
+	authURL, err := f.AuthorizeURL("state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(authURL)
+	if q := u.Query(); u.Host != "accounts.feishu.cn" || q.Get("client_id") != "cli_1" || q.Get("state") != "state" {
+		t.Errorf("unexpected authorize url %s", authURL)
+	}
+
+	user, token, err := f.Login("code")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "u-AT" || token.RefreshExpiresIn != 2592000 || token.Expiry.IsZero() {
+		t.Errorf("unexpected token %+v", token)
+	}
+	if user.OpenID != "ou_1" || user.UnionID != "on_1" || user.Name != "zhangsan" || user.TenantKey == "" {
+		t.Errorf("unexpected user %+v", user)
+	}
+	if profile := user.Profile(); profile.Subject != "on_1" || profile.Provider != "feishu" {
+		t.Errorf("unexpected profile %+v", profile)
+	}
+
+	var e *Error
+	if _, err := f.Exchange("bad"); !errors.As(err, &e) || e.Code != 20003 {
+		t.Errorf("expected invalid grant, got %v", err)
+	}
+	if token, err := f.Refresh("ur-RT"); err != nil || token.AccessToken != "u-AT" {
+		t.Errorf("unexpected refresh %+v %v", token, err)
+	}
+	if _, err := f.UserInfo("bad"); !errors.Is(err, ErrInvalidUserAccessToken) {
+		t.Errorf("expected invalid user access token, got %v", err)
+	}
+	if appTokens != 2 {
+		t.Errorf("app token should be cached and renewed once, fetched %d times", appTokens)
+	}
+
+	for i := 0; i < 2; i++ {
+		if token, err := f.TenantAccessToken(); err != nil || token != "t-tenant" {
+			t.Errorf("unexpected tenant token %s %v", token, err)
+		}
+	}
+	if tenantTokens != 1 {
+		t.Errorf("tenant token should be cached, fetched %d times", tenantTokens)
+	}
+
+	f = NewLark("cli_1", "wrong", "https://app.example.com/lark")
+	f.APIEndpoint = server.URL
+	if _, err := f.Exchange("code"); !errors.Is(err, ErrInvalidAppSecret) {
+		t.Errorf("expected invalid app secret, got %v", err)
+	}
+}
+
+func TestFeishuInterceptors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Tenant") != "t1" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"code":0,"msg":"ok","app_access_token":"APP","expire":7200}`))
+	}))
+	defer server.Close()
+
+	var observed []*oauth.Observation
+	f := New("cli_1", "secret", "",
+		WithInterceptors(func(r *http.Request) error {
+			r.Header.Set("X-Tenant", "t1")
+			return nil
+		}),
+		WithObserver(oauth.ObserverFunc(func(o *oauth.Observation) { observed = append(observed, o) })),
+	)
+	f.APIEndpoint = server.URL
+	if token, err := f.AppAccessToken(); err != nil || token != "APP" {
+		t.Fatalf("expected interceptor header sent, got %q %v", token, err)
+	}
+	if len(observed) != 1 || observed[0].Endpoint != oauth.EndpointAPI || observed[0].StatusCode != http.StatusOK {
+		t.Errorf("expected the api call observed, got %+v", observed)
+	}
+}
@@ -0,0 +1,234 @@
+package providers
+
+import (
+	"encoding/json"
+	"github.com/demo007x/oauth2-client/errorx"
+	"github.com/demo007x/oauth2-client/oauth"
+	"github.com/demo007x/oauth2-client/types"
+	"net/http"
+	"strings"
+)
+
+// host trim scheme and trailing slash. eg: https://gitlab.example.com/ -> gitlab.example.com
+func host(h string) string {
+	h = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(h), "https://"), "http://")
+	return strings.TrimRight(h, "/")
+}
+
+// GitHub github.com oauth app. The token endpoint return form encoded data unless json is accepted
+func GitHub() *Provider {
+	return &Provider{
+		Name:           "github",
+		AuthorizeURL:   "https://github.com/login/oauth/authorize",
+		TokenURL:       "https://github.com/login/oauth/access_token",
+		UserInfoURL:    "https://api.github.com/user",
+		AuthMethod:     oauth.AuthMethodClientSecretPost,
+		ResponseFormat: ResponseFormatJSON,
+		UserInfoMethod: http.MethodGet,
+		Scopes:         []string{"read:user", "user:email"},
+		ProfileMapper:  GitHubProfile,
+	}
+}
+
+// GitHubEnterprise github enterprise server on host
+func GitHubEnterprise(h string) *Provider {
+	var base = "https://" + host(h)
+	var p = GitHub()
+	p.Name = "github-enterprise"
+	p.AuthorizeURL = base + "/login/oauth/authorize"
+	p.TokenURL = base + "/login/oauth/access_token"
+	p.UserInfoURL = base + "/api/v3/user"
+	return p
+}
+
+// Google google openid connect
+func Google() *Provider {
+	return &Provider{
+		Name:           "google",
+		AuthorizeURL:   "https://accounts.google.com/o/oauth2/v2/auth",
+		TokenURL:       "https://oauth2.googleapis.com/token",
+		UserInfoURL:    "https://openidconnect.googleapis.com/v1/userinfo",
+		RevokeURL:      "https://oauth2.googleapis.com/revoke",
+		DiscoveryURL:   "https://accounts.google.com/.well-known/openid-configuration",
+		AuthMethod:     oauth.AuthMethodClientSecretPost,
+		ResponseFormat: ResponseFormatJSON,
+		UserInfoMethod: http.MethodGet,
+		Scopes:         []string{"openid", "email", "profile"},
+	}
+}
+
+// Microsoft microsoft entra id v2.0 endpoint. tenant is tenant id, domain, common or organizations
+func Microsoft(tenant string) *Provider {
+	if strings.TrimSpace(tenant) == "" {
+		tenant = "common"
+	}
+	var base = "https://login.microsoftonline.com/" + tenant
+	return &Provider{
+		Name:           "microsoft",
+		AuthorizeURL:   base + "/oauth2/v2.0/authorize",
+		TokenURL:       base + "/oauth2/v2.0/token",
+		UserInfoURL:    "https://graph.microsoft.com/oidc/userinfo",
+		DiscoveryURL:   base + "/v2.0/.well-known/openid-configuration",
+		AuthMethod:     oauth.AuthMethodClientSecretPost,
+		ResponseFormat: ResponseFormatJSON,
+		UserInfoMethod: http.MethodGet,
+		Scopes:         []string{"openid", "profile", "email", "offline_access"},
+	}
+}
+
+// GitLab gitlab on host, gitlab.com when host is empty
+func GitLab(h string) *Provider {
+	if strings.TrimSpace(h) == "" {
+		h = "gitlab.com"
+	}
+	var base = "https://" + host(h)
+	return &Provider{
+		Name:           "gitlab",
+		AuthorizeURL:   base + "/oauth/authorize",
+		TokenURL:       base + "/oauth/token",
+		UserInfoURL:    base + "/oauth/userinfo",
+		RevokeURL:      base + "/oauth/revoke",
+		DiscoveryURL:   base + "/.well-known/openid-configuration",
+		AuthMethod:     oauth.AuthMethodClientSecretPost,
+		ResponseFormat: ResponseFormatJSON,
+		UserInfoMethod: http.MethodGet,
+		Scopes:         []string{"openid", "profile", "email"},
+	}
+}
+
+// Okta okta custom authorization server of domain. eg: dev-123456.okta.com, aus1a2b3c4d5e6f7g8h9.
+// Empty authorization server id is the default custom authorization server
+func Okta(domain, authorizationServerID string) *Provider {
+	if strings.TrimSpace(authorizationServerID) == "" {
+		authorizationServerID = "default"
+	}
+	var base = "https://" + host(domain) + "/oauth2/" + authorizationServerID
+	return &Provider{
+		Name:           "okta",
+		AuthorizeURL:   base + "/v1/authorize",
+		TokenURL:       base + "/v1/token",
+		UserInfoURL:    base + "/v1/userinfo",
+		RevokeURL:      base + "/v1/revoke",
+		IntrospectURL:  base + "/v1/introspect",
+		DiscoveryURL:   base + "/.well-known/openid-configuration",
+		AuthMethod:     oauth.AuthMethodClientSecretBasic,
+		ResponseFormat: ResponseFormatJSON,
+		UserInfoMethod: http.MethodGet,
+		Scopes:         []string{"openid", "profile", "email", "offline_access"},
+	}
+}
+
+// Auth0 auth0 tenant domain. eg: example.us.auth0.com
+func Auth0(domain string) *Provider {
+	var base = "https://" + host(domain)
+	return &Provider{
+		Name:           "auth0",
+		AuthorizeURL:   base + "/authorize",
+		TokenURL:       base + "/oauth/token",
+		UserInfoURL:    base + "/userinfo",
+		RevokeURL:      base + "/oauth/revoke",
+		DiscoveryURL:   base + "/.well-known/openid-configuration",
+		AuthMethod:     oauth.AuthMethodClientSecretPost,
+		ResponseFormat: ResponseFormatJSON,
+		UserInfoMethod: http.MethodGet,
+		Scopes:         []string{"openid", "profile", "email", "offline_access"},
+	}
+}
+
+// Keycloak keycloak realm. baseURL include scheme and context path. eg: https://sso.example.com
+func Keycloak(baseURL, realm string) *Provider {
+	var base = strings.TrimRight(baseURL, "/") + "/realms/" + realm
+	return &Provider{
+		Name:           "keycloak",
+		AuthorizeURL:   base + "/protocol/openid-connect/auth",
+		TokenURL:       base + "/protocol/openid-connect/token",
+		UserInfoURL:    base + "/protocol/openid-connect/userinfo",
+		RevokeURL:      base + "/protocol/openid-connect/revoke",
+		IntrospectURL:  base + "/protocol/openid-connect/token/introspect",
+		DiscoveryURL:   base + "/.well-known/openid-configuration",
+		AuthMethod:     oauth.AuthMethodClientSecretBasic,
+		ResponseFormat: ResponseFormatJSON,
+		UserInfoMethod: http.MethodGet,
+		Scopes:         []string{"openid", "profile", "email"},
+	}
+}
+
+// Facebook facebook login. The token endpoint is a GET request and scopes are comma separated
+func Facebook() *Provider {
+	return &Provider{
+		Name:           "facebook",
+		AuthorizeURL:   "https://www.facebook.com/v19.0/dialog/oauth",
+		TokenURL:       "https://graph.facebook.com/v19.0/oauth/access_token",
+		UserInfoURL:    "https://graph.facebook.com/me?fields=id,name,email,picture",
+		AuthMethod:     oauth.AuthMethodClientSecretPost,
+		ResponseFormat: ResponseFormatJSON,
+		TokenMethod:    http.MethodGet,
+		UserInfoMethod: http.MethodGet,
+		Scopes:         []string{"email", "public_profile"},
+		ScopeSeparator: ",",
+		ProfileMapper:  FacebookProfile,
+	}
+}
+
+// Slack sign in with slack (openid connect). Errors are returned as ok=false with status 200
+func Slack() *Provider {
+	return &Provider{
+		Name:            "slack",
+		AuthorizeURL:    "https://slack.com/openid/connect/authorize",
+		TokenURL:        "https://slack.com/api/openid.connect.token",
+		UserInfoURL:     "https://slack.com/api/openid.connect.userInfo",
+		DiscoveryURL:    "https://slack.com/.well-known/openid-configuration",
+		AuthMethod:      oauth.AuthMethodClientSecretPost,
+		ResponseFormat:  ResponseFormatJSON,
+		UserInfoMethod:  http.MethodGet,
+		Scopes:          []string{"openid", "profile", "email"},
+		ResponseHandler: SlackResponseHandler,
+	}
+}
+
+// SlackResponseHandler return ok=false responses of slack apis, sent with status 200, as *errorx.OauthError
+func SlackResponseHandler(resp *http.Response) ([]byte, error) {
+	data, err := types.DefaultOauthResponseHandler(resp)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		OK    *bool  `json:"ok"`
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(data, &result) == nil && result.OK != nil && !*result.OK {
+		return nil, &errorx.OauthError{StatusCode: resp.StatusCode, Code: result.Error}
+	}
+	return data, nil
+}
+
+// Discord discord oauth2
+func Discord() *Provider {
+	return &Provider{
+		Name:           "discord",
+		AuthorizeURL:   "https://discord.com/oauth2/authorize",
+		TokenURL:       "https://discord.com/api/oauth2/token",
+		UserInfoURL:    "https://discord.com/api/users/@me",
+		RevokeURL:      "https://discord.com/api/oauth2/token/revoke",
+		AuthMethod:     oauth.AuthMethodClientSecretPost,
+		ResponseFormat: ResponseFormatJSON,
+		UserInfoMethod: http.MethodGet,
+		Scopes:         []string{"identify", "email"},
+		ProfileMapper:  DiscordProfile,
+	}
+}
+
+// Bitbucket bitbucket cloud oauth consumer
+func Bitbucket() *Provider {
+	return &Provider{
+		Name:           "bitbucket",
+		AuthorizeURL:   "https://bitbucket.org/site/oauth2/authorize",
+		TokenURL:       "https://bitbucket.org/site/oauth2/access_token",
+		UserInfoURL:    "https://api.bitbucket.org/2.0/user",
+		AuthMethod:     oauth.AuthMethodClientSecretBasic,
+		ResponseFormat: ResponseFormatJSON,
+		UserInfoMethod: http.MethodGet,
+		Scopes:         []string{"account", "email"},
+		ProfileMapper:  BitbucketProfile,
+	}
+}
@@ -0,0 +1,56 @@
+package providers
+
+import (
+	"errors"
+	"github.com/demo007x/oauth2-client/errorx"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPresets(t *testing.T) {
+	var presets = map[string]*Provider{
+		"https://ghe.example.com/login/oauth/authorize":                                   GitHubEnterprise("https://ghe.example.com/"),
+		"https://login.microsoftonline.com/contoso.onmicrosoft.com/oauth2/v2.0/authorize": Microsoft("contoso.onmicrosoft.com"),
+		"https://gitlab.com/oauth/authorize":                                              GitLab(""),
+		"https://dev-1.okta.com/oauth2/default/v1/authorize":                              Okta("dev-1.okta.com", ""),
+		"https://dev-1.okta.com/oauth2/aus1a2b3c4/v1/authorize":                           Okta("dev-1.okta.com", "aus1a2b3c4"),
+		"https://example.us.auth0.com/authorize":                                          Auth0("https://example.us.auth0.com"),
+		"https://sso.example.com/realms/dev/protocol/openid-connect/auth":                 Keycloak("https://sso.example.com/", "dev"),
+	}
+	for want, p := range presets {
+		if p.AuthorizeURL != want {
+			t.Errorf("%s authorize url %s, want %s", p.Name, p.AuthorizeURL, want)
+		}
+	}
+
+	for _, p := range []*Provider{GitHub(), Google(), Microsoft(""), GitLab("gitlab.example.com"), Okta("d", ""), Auth0("d"), Keycloak("https://k", "r"), Facebook(), Slack(), Discord(), Bitbucket()} {
+		if p.TokenURL == "" || p.UserInfoURL == "" || p.AuthMethod == "" || len(p.Scopes) == 0 {
+			t.Errorf("%s preset is incomplete %+v", p.Name, p)
+		}
+		if p.UserInfoMethod != http.MethodGet {
+			t.Errorf("%s userinfo should use GET", p.Name)
+		}
+	}
+}
+
+func TestSlackResponseHandler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != "code" {
+			w.Write([]byte(`{"ok":false,"error":"invalid_code"}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"access_token":"xoxp-1","token_type":"Bearer","id_token":"eyJ"}`))
+	}))
+	defer server.Close()
+
+	var slack = Slack()
+	slack.TokenURL = server.URL
+	var oe *errorx.OauthError
+	if _, err := slack.NewAccessToken("client", "secret", "bad").DoRequest(); !errors.As(err, &oe) || oe.Code != "invalid_code" {
+		t.Errorf("expected invalid_code error, got %v", err)
+	}
+	if _, err := slack.NewAccessToken("client", "secret", "code").DoRequest(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package providers
+
+import (
+	"github.com/demo007x/oauth2-client/oauth"
+)
+
+// GitHubProfile map github /user. Email is empty when the user keep it private
+func GitHubProfile(raw map[string]interface{}) *oauth.Profile {
+	return &oauth.Profile{
+		Subject:           oauth.StringClaim(raw, "id"),
+		Email:             oauth.StringClaim(raw, "email"),
+		Name:              oauth.StringClaim(raw, "name"),
+		PreferredUsername: oauth.StringClaim(raw, "login"),
+		Picture:           oauth.StringClaim(raw, "avatar_url"),
+		Raw:               raw,
+	}
+}
+
+// FacebookProfile map graph api /me with id, name, email and picture fields
+func FacebookProfile(raw map[string]interface{}) *oauth.Profile {
+	var email = oauth.StringClaim(raw, "email")
+	return &oauth.Profile{
+		Subject: oauth.StringClaim(raw, "id"),
+		Email:   email,
+		// facebook only return confirmed emails
+		EmailVerified: email != "",
+		Name:          oauth.StringClaim(raw, "name"),
+		Picture:       oauth.StringClaim(raw, "picture", "data", "url"),
+		Raw:           raw,
+	}
+}
+
+// DiscordProfile map discord /users/@me, avatar hash is expanded to cdn url
+func DiscordProfile(raw map[string]interface{}) *oauth.Profile {
+	var profile = &oauth.Profile{
+		Subject:           oauth.StringClaim(raw, "id"),
+		Email:             oauth.StringClaim(raw, "email"),
+		EmailVerified:     oauth.StringClaim(raw, "verified") == "true",
+		Name:              oauth.StringClaim(raw, "global_name"),
+		PreferredUsername: oauth.StringClaim(raw, "username"),
+		Locale:            oauth.StringClaim(raw, "locale"),
+		Raw:               raw,
+	}
+	if avatar := oauth.StringClaim(raw, "avatar"); avatar != "" {
+		profile.Picture = "https://cdn.discordapp.com/avatars/" + profile.Subject + "/" + avatar + ".png"
+	}
+	return profile
+}
+
+// BitbucketProfile map bitbucket /2.0/user. Email need another request to /2.0/user/emails
+func BitbucketProfile(raw map[string]interface{}) *oauth.Profile {
+	return &oauth.Profile{
+		Subject:           oauth.StringClaim(raw, "uuid"),
+		Name:              oauth.StringClaim(raw, "display_name"),
+		PreferredUsername: oauth.StringClaim(raw, "username"),
+		Picture:           oauth.StringClaim(raw, "links", "avatar", "href"),
+		Raw:               raw,
+	}
+}
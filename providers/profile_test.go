@@ -0,0 +1,56 @@
+package providers
+
+import (
+	"github.com/demo007x/oauth2-client/oauth"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestProfileMappers(t *testing.T) {
+	cases := []struct {
+		provider *Provider
+		body     string
+		want     oauth.Profile
+	}{
+		{GitHub(), `{"login":"octocat","id":583231,"avatar_url":"https://avatars.githubusercontent.com/u/583231?v=4","name":"The Octocat","email":null}`,
+			oauth.Profile{Subject: "583231", Name: "The Octocat", PreferredUsername: "octocat", Picture: "https://avatars.githubusercontent.com/u/583231?v=4"}},
+		{Google(), `{"sub":"1234567890","name":"Jane","email":"jane@gmail.com","email_verified":true,"picture":"https://lh3.googleusercontent.com/a","locale":"en"}`,
+			oauth.Profile{Subject: "1234567890", Email: "jane@gmail.com", EmailVerified: true, Name: "Jane", Picture: "https://lh3.googleusercontent.com/a", Locale: "en"}},
+		{Facebook(), `{"id":"10158","name":"Jane","email":"jane@example.com","picture":{"data":{"height":50,"url":"https://platform-lookaside.fbsbx.com/p","width":50}}}`,
+			oauth.Profile{Subject: "10158", Email: "jane@example.com", EmailVerified: true, Name: "Jane", Picture: "https://platform-lookaside.fbsbx.com/p"}},
+		{Discord(), `{"id":"80351110224678912","username":"nelly","global_name":"Nelly","avatar":"8342729096ea3675442027381ff50dfe","verified":true,"email":"nelly@discord.com","locale":"en-US"}`,
+			oauth.Profile{Subject: "80351110224678912", Email: "nelly@discord.com", EmailVerified: true, Name: "Nelly", PreferredUsername: "nelly",
+				Picture: "https://cdn.discordapp.com/avatars/80351110224678912/8342729096ea3675442027381ff50dfe.png", Locale: "en-US"}},
+		{Bitbucket(), `{"uuid":"{d301aafa-d676-4ee0-88be-962be7417567}","username":"evzijst","display_name":"Erik","links":{"avatar":{"href":"https://bitbucket.org/account/evzijst/avatar/"}}}`,
+			oauth.Profile{Subject: "{d301aafa-d676-4ee0-88be-962be7417567}", Name: "Erik", PreferredUsername: "evzijst", Picture: "https://bitbucket.org/account/evzijst/avatar/"}},
+	}
+	for _, c := range cases {
+		profile, err := oauth.ParseProfile([]byte(c.body), c.provider.ProfileMapper)
+		if err != nil {
+			t.Fatalf("%s: %v", c.provider.Name, err)
+		}
+		profile.Raw = nil
+		if !reflect.DeepEqual(*profile, c.want) {
+			t.Errorf("%s: unexpected profile %+v", c.provider.Name, profile)
+		}
+	}
+}
+
+func TestProviderProfile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"login":"octocat","id":1,"email":"octocat@github.com"}`))
+	}))
+	defer server.Close()
+
+	p := GitHubEnterprise(server.URL)
+	p.UserInfoURL = server.URL + "/api/v3/user"
+	profile, err := p.Profile("gho_abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Provider != "github-enterprise" || profile.Subject != "1" || profile.PreferredUsername != "octocat" || profile.Raw["login"] != "octocat" {
+		t.Errorf("unexpected profile %+v", profile)
+	}
+}
@@ -0,0 +1,119 @@
+package providers
+
+import (
+	"github.com/demo007x/oauth2-client/oauth"
+	"github.com/demo007x/oauth2-client/types"
+	"net/http"
+	"strings"
+)
+
+// Token endpoint response formats
+const (
+	ResponseFormatJSON = "json"
+	ResponseFormatForm = "form"
+)
+
+// Provider endpoint preset and quirk settings of an identity provider
+type Provider struct {
+	Name          string
+	AuthorizeURL  string
+	TokenURL      string
+	UserInfoURL   string
+	RevokeURL     string
+	IntrospectURL string
+	// DiscoveryURL openid configuration, empty when the provider has none
+	DiscoveryURL string
+	// AuthMethod token endpoint client authentication method
+	AuthMethod string
+	// ResponseFormat token endpoint response format we ask for
+	ResponseFormat string
+	// TokenMethod http method of token endpoint, POST by default
+	TokenMethod string
+	// UserInfoMethod http method of userinfo endpoint
+	UserInfoMethod string
+	Scopes         []string
+	// ScopeSeparator space by default. eg: facebook use comma
+	ScopeSeparator string
+	// ProfileMapper map userinfo to normalized profile, OIDC standard claims when nil
+	ProfileMapper oauth.ProfileMapper
+	// ResponseHandler read token and userinfo responses, for errors sent with status 200. eg: slack
+	ResponseHandler types.OauthResponseHandler
+}
+
+// Scope join default scopes with provider separator
+func (p *Provider) Scope() string {
+	var sep = p.ScopeSeparator
+	if sep == "" {
+		sep = " "
+	}
+	return strings.Join(p.Scopes, sep)
+}
+
+func (p *Provider) tokenMethod() string {
+	if p.TokenMethod == "" {
+		return http.MethodPost
+	}
+	return p.TokenMethod
+}
+
+// NewOauth2Client return Client of the authorize endpoint with default scopes
+func (p *Provider) NewOauth2Client(clientID string, opts ...oauth.WithOption) *oauth.Client {
+	opts = append([]oauth.WithOption{oauth.WithScope(p.Scope())}, opts...)
+	return oauth.NewOauth2Client(p.AuthorizeURL, clientID, opts...)
+}
+
+// NewAccessToken return AccessToken of the token endpoint with provider auth method and response format
+func (p *Provider) NewAccessToken(clientID, secret, code string, opts ...oauth.AccessTokenOption) *oauth.AccessToken {
+	var defaults = []oauth.AccessTokenOption{
+		oauth.AccessTokenWithGrantType(types.DefaultAccessTokenGrantType),
+		oauth.AccessTokenWithAuthMethod(p.AuthMethod),
+		oauth.AccessTokenWithMethod(p.tokenMethod()),
+	}
+	if p.ResponseFormat == ResponseFormatJSON {
+		defaults = append(defaults, oauth.AccessTokenWithAccept("application/json"))
+	}
+	if p.ResponseHandler != nil {
+		defaults = append(defaults, oauth.AccessTokenWithResponseHandler(p.ResponseHandler))
+	}
+	return oauth.NewAccessToken(p.TokenURL, clientID, secret, code, append(defaults, opts...)...)
+}
+
+// NewRefreshToken return RefreshToken of the token endpoint with provider auth method and response format
+func (p *Provider) NewRefreshToken(clientID, secret, refreshToken string, opts ...oauth.RefreshTokenOption) *oauth.RefreshToken {
+	var defaults = []oauth.RefreshTokenOption{
+		oauth.RefreshTokenWithAuthMethod(p.AuthMethod),
+		oauth.RefreshTokenWithMethod(p.tokenMethod()),
+	}
+	if p.ResponseFormat == ResponseFormatJSON {
+		defaults = append(defaults, oauth.RefreshTokenWithAccept("application/json"))
+	}
+	if p.ResponseHandler != nil {
+		defaults = append(defaults, oauth.RefreshTokenWithResponseHandler(p.ResponseHandler))
+	}
+	return oauth.NewRefreshToken(p.TokenURL, clientID, secret, refreshToken, append(defaults, opts...)...)
+}
+
+// NewUserInfo return UserInfo of the userinfo endpoint with provider http method
+func (p *Provider) NewUserInfo(accessToken string, opts ...oauth.WithUserInfoOption) *oauth.UserInfo {
+	var defaults = []oauth.WithUserInfoOption{oauth.UserInfoWithMethod(p.UserInfoMethod)}
+	if p.ResponseHandler != nil {
+		defaults = append(defaults, oauth.UserInfoWithResponseHandler(p.ResponseHandler))
+	}
+	return oauth.NewUserInfo(p.UserInfoURL, accessToken, append(defaults, opts...)...)
+}
+
+// NewRevokeToken return RevokeToken of the revocation endpoint
+func (p *Provider) NewRevokeToken(clientID, secret, token string, opts ...oauth.RevokeTokenOption) *oauth.RevokeToken {
+	return oauth.NewOauthRevokeToken(p.RevokeURL, clientID, secret, token, opts...)
+}
+
+// Profile request userinfo and map it to normalized profile of the provider
+func (p *Provider) Profile(accessToken string, opts ...oauth.WithUserInfoOption) (*oauth.Profile, error) {
+	opts = append([]oauth.WithUserInfoOption{oauth.UserInfoWithProfileMapper(p.ProfileMapper)}, opts...)
+	profile, err := p.NewUserInfo(accessToken, opts...).Profile()
+	if err != nil {
+		return nil, err
+	}
+	profile.Provider = p.Name
+	return profile, nil
+}
@@ -0,0 +1,57 @@
+package providers
+
+import (
+	"github.com/demo007x/oauth2-client/oauth"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login/oauth/access_token":
+			r.ParseForm()
+			query := r.PostForm
+			if r.Method != http.MethodPost || r.Header.Get("Accept") != "application/json" {
+				t.Errorf("unexpected token request %s accept %s", r.Method, r.Header.Get("Accept"))
+			}
+			if query.Get("client_id") != "id" || query.Get("client_secret") != "secret" || query.Get("code") != "code" {
+				w.Write([]byte(`{"error":"incorrect_client_credentials"}`))
+				return
+			}
+			w.Write([]byte(`{"access_token":"gho_abc","token_type":"bearer","scope":"read:user"}`))
+		case "/api/v3/user":
+			if r.Method != http.MethodGet || r.Header.Get("Authorization") != "Bearer gho_abc" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"login":"octocat","id":1}`))
+		}
+	}))
+	defer server.Close()
+
+	p := GitHubEnterprise(server.URL)
+	// httptest server is plain http
+	p.TokenURL = server.URL + "/login/oauth/access_token"
+	p.UserInfoURL = server.URL + "/api/v3/user"
+
+	data, err := p.NewAccessToken("id", "secret", "code").DoRequest()
+	if err != nil || string(data) != `{"access_token":"gho_abc","token_type":"bearer","scope":"read:user"}` {
+		t.Fatalf("unexpected token response %s %v", data, err)
+	}
+	data, err = p.NewUserInfo("gho_abc").DoRequest()
+	if err != nil || string(data) != `{"login":"octocat","id":1}` {
+		t.Errorf("unexpected userinfo %s %v", data, err)
+	}
+
+	authURL, err := Facebook().NewOauth2Client("app", oauth.WithState("s")).AuthorizeURL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(authURL)
+	if u.Query().Get("scope") != "email,public_profile" {
+		t.Errorf("facebook scopes should be comma separated: %s", authURL)
+	}
+}
@@ -0,0 +1,286 @@
+// Package qq tencent qq connect adapter.
+// The token endpoint return form encoded data, /oauth2.0/me return jsonp
+// and get_user_info need oauth_consumer_key and openid query params
+package qq
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/demo007x/oauth2-client/errorx"
+	"github.com/demo007x/oauth2-client/oauth"
+	"github.com/demo007x/oauth2-client/types"
+	"github.com/demo007x/oauth2-client/utils"
+	"net/http"
+	"net/url"
+)
+
+const (
+	AuthorizeURL = "https://graph.qq.com/oauth2.0/authorize"
+	TokenURL     = "https://graph.qq.com/oauth2.0/token"
+	MeURL        = "https://graph.qq.com/oauth2.0/me"
+	UserInfoURL  = "https://graph.qq.com/user/get_user_info"
+	// DefaultScope qq connect get_user_info api
+	DefaultScope = "get_user_info"
+)
+
+type (
+	Option func(q *QQ)
+	// QQ qq connect application
+	QQ struct {
+		AppID       string
+		AppKey      string
+		RedirectURI string
+		Scope       string
+		// UnionID request unionid with openid, need apply on qq connect
+		UnionID bool
+
+		AuthorizeEndpoint string
+		TokenEndpoint     string
+		MeEndpoint        string
+		UserInfoEndpoint  string
+	}
+
+	// OpenID response of /oauth2.0/me
+	OpenID struct {
+		ClientID string `json:"client_id"`
+		OpenID   string `json:"openid"`
+		UnionID  string `json:"unionid,omitempty"`
+	}
+
+	// User normalized qq user profile
+	User struct {
+		OpenID   string
+		UnionID  string
+		Nickname string
+		// Avatar the largest qq avatar available
+		Avatar string
+		// Gender male, female or empty
+		Gender   string
+		Province string
+		City     string
+		Year     string
+		// Raw get_user_info response with openid and unionid
+		Raw map[string]interface{}
+	}
+
+	// Error qq api error with ret and msg
+	Error struct {
+		Ret int    `json:"ret"`
+		Msg string `json:"msg"`
+	}
+)
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("qq connect error: ret %d, %s", e.Ret, e.Msg)
+}
+
+// WithScope set scope, get_user_info by default
+func WithScope(scope string) Option {
+	return func(q *QQ) {
+		q.Scope = scope
+	}
+}
+
+// WithUnionID request unionid from /oauth2.0/me
+func WithUnionID() Option {
+	return func(q *QQ) {
+		q.UnionID = true
+	}
+}
+
+// JSONPResponseHandler read body and strip callback( ... ); wrapper
+func JSONPResponseHandler(resp *http.Response) ([]byte, error) {
+	data, err := types.DefaultOauthResponseHandler(resp)
+	if err != nil {
+		return nil, err
+	}
+	return utils.UnwrapJSONP(data), nil
+}
+
+// parseError read the error of /oauth2.0 responses, qq send numeric codes. eg: {"error":100016}
+func parseError(data []byte) *errorx.OauthError {
+	var raw = map[string]interface{}{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil
+	}
+	if code := oauth.StringClaim(raw, "error"); code != "" {
+		return &errorx.OauthError{StatusCode: http.StatusOK, Code: code, Description: oauth.StringClaim(raw, "error_description")}
+	}
+	return nil
+}
+
+// AuthorizeURL build qq connect authorize url
+func (q *QQ) AuthorizeURL(state string, opts ...oauth.WithOption) (string, error) {
+	opts = append([]oauth.WithOption{
+		oauth.WithRedirectURI(q.RedirectURI),
+		oauth.WithScope(q.Scope),
+		oauth.WithState(state),
+	}, opts...)
+	return oauth.NewOauth2Client(q.AuthorizeEndpoint, q.AppID, opts...).AuthorizeURL()
+}
+
+// Exchange exchange authorization code for access token
+func (q *QQ) Exchange(code string) (*oauth.Token, error) {
+	data, err := oauth.NewAccessToken(q.TokenEndpoint, q.AppID, q.AppKey, code,
+		oauth.AccessTokenWithGrantType(types.DefaultAccessTokenGrantType),
+		oauth.AccessTokenWithRedirectURI(q.RedirectURI),
+		oauth.AccessTokenWithAuthMethod(oauth.AuthMethodClientSecretPost),
+		oauth.AccessTokenWithMethod(http.MethodGet),
+		oauth.AccessTokenWithResponseHandler(JSONPResponseHandler),
+	).DoRequest()
+	if err != nil {
+		return nil, err
+	}
+	return oauth.ParseToken(data)
+}
+
+// Refresh renew access token with refresh token
+func (q *QQ) Refresh(refreshToken string) (*oauth.Token, error) {
+	data, err := oauth.NewRefreshToken(q.TokenEndpoint, q.AppID, q.AppKey, refreshToken,
+		oauth.RefreshTokenWithGrantType("refresh_token"),
+		oauth.RefreshTokenWithAuthMethod(oauth.AuthMethodClientSecretPost),
+		oauth.RefreshTokenWithMethod(http.MethodGet),
+		oauth.RefreshTokenWithResponseHandler(JSONPResponseHandler),
+	).DoRequest()
+	if err != nil {
+		return nil, err
+	}
+	return oauth.ParseToken(data)
+}
+
+// OpenID lookup openid and unionid of the access token
+func (q *QQ) OpenID(accessToken string) (*OpenID, error) {
+	var values = url.Values{}
+	values.Set("access_token", accessToken)
+	values.Set("fmt", "json")
+	if q.UnionID {
+		values.Set("unionid", "1")
+	}
+	data, err := oauth.NewUserInfo(q.MeEndpoint+"?"+values.Encode(), accessToken,
+		oauth.UserInfoWithMethod(http.MethodGet),
+		oauth.UserInfoWithResponseHandler(JSONPResponseHandler),
+	).DoRequest()
+	if err != nil {
+		return nil, err
+	}
+	if oe := parseError(data); oe != nil {
+		return nil, oe
+	}
+	var openID = &OpenID{}
+	if err := json.Unmarshal(data, openID); err != nil {
+		return nil, err
+	}
+	if openID.OpenID == "" {
+		return nil, errorx.ParseOauthError(http.StatusOK, data)
+	}
+	return openID, nil
+}
+
+// UserInfo get and normalize user profile of openid
+func (q *QQ) UserInfo(accessToken, openID string) (*User, error) {
+	var values = url.Values{}
+	values.Set("access_token", accessToken)
+	values.Set("oauth_consumer_key", q.AppID)
+	values.Set("openid", openID)
+	data, err := oauth.NewUserInfo(q.UserInfoEndpoint+"?"+values.Encode(), accessToken,
+		oauth.UserInfoWithMethod(http.MethodGet),
+	).DoRequest()
+	if err != nil {
+		return nil, err
+	}
+
+	var qqErr = &Error{}
+	if err := json.Unmarshal(data, qqErr); err != nil {
+		return nil, err
+	}
+	if qqErr.Ret != 0 {
+		return nil, qqErr
+	}
+	var raw = map[string]interface{}{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	return normalize(openID, raw), nil
+}
+
+// Login exchange the code and fetch user profile with openid and unionid
+func (q *QQ) Login(code string) (*User, *oauth.Token, error) {
+	token, err := q.Exchange(code)
+	if err != nil {
+		return nil, nil, err
+	}
+	openID, err := q.OpenID(token.AccessToken)
+	if err != nil {
+		return nil, token, err
+	}
+	user, err := q.UserInfo(token.AccessToken, openID.OpenID)
+	if err != nil {
+		return nil, token, err
+	}
+	if openID.UnionID != "" {
+		user.UnionID, user.Raw["unionid"] = openID.UnionID, openID.UnionID
+	}
+	return user, token, nil
+}
+
+// Profile normalized profile, subject is unionid when enabled or openid
+func (u *User) Profile() *oauth.Profile {
+	return Profile(u.Raw)
+}
+
+// Profile map get_user_info merged with openid and unionid of /oauth2.0/me
+func Profile(raw map[string]interface{}) *oauth.Profile {
+	var subject = oauth.StringClaim(raw, "unionid")
+	if subject == "" {
+		subject = oauth.StringClaim(raw, "openid")
+	}
+	var profile = &oauth.Profile{Subject: subject, Name: oauth.StringClaim(raw, "nickname"), Provider: "qq", Raw: raw}
+	for _, key := range avatarKeys {
+		if profile.Picture = oauth.StringClaim(raw, key); profile.Picture != "" {
+			break
+		}
+	}
+	return profile
+}
+
+// avatarKeys qq avatars from the largest
+var avatarKeys = []string{"figureurl_qq_2", "figureurl_qq_1", "figureurl_2", "figureurl_1", "figureurl"}
+
+func normalize(openID string, raw map[string]interface{}) *User {
+	raw["openid"] = openID
+	var profile = Profile(raw)
+	var user = &User{
+		OpenID:   openID,
+		Nickname: profile.Name,
+		Avatar:   profile.Picture,
+		Province: oauth.StringClaim(raw, "province"),
+		City:     oauth.StringClaim(raw, "city"),
+		Year:     oauth.StringClaim(raw, "year"),
+		Raw:      raw,
+	}
+	switch gender, genderType := oauth.StringClaim(raw, "gender"), oauth.StringClaim(raw, "gender_type"); {
+	case gender == "男" || genderType == "1":
+		user.Gender = "male"
+	case gender == "女" || genderType == "2":
+		user.Gender = "female"
+	}
+	return user
+}
+
+// New return QQ connect adapter
+func New(appID, appKey, redirectURI string, opts ...Option) *QQ {
+	var q = &QQ{
+		AppID:             appID,
+		AppKey:            appKey,
+		RedirectURI:       redirectURI,
+		Scope:             DefaultScope,
+		AuthorizeEndpoint: AuthorizeURL,
+		TokenEndpoint:     TokenURL,
+		MeEndpoint:        MeURL,
+		UserInfoEndpoint:  UserInfoURL,
+	}
+	for _, opt := range opts {
+		opt(q)
+	}
+	return q
+}
@@ -0,0 +1,95 @@
+package qq
+
+import (
+	"github.com/demo007x/oauth2-client/errorx"
+	"github.com/demo007x/oauth2-client/oauth"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestQQ(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch r.URL.Path {
+		case "/oauth2.0/token":
+			if r.Method != http.MethodGet || query.Get("client_id") != "101" || query.Get("client_secret") != "key" {
+				w.Write([]byte(`callback( {"error":100016,"error_description":"access token check failed"} );`))
+				return
+			}
+			if query.Get("grant_type") == "refresh_token" {
+				w.Write([]byte("access_token=AT2&expires_in=7776000&refresh_token=RT2"))
+				return
+			}
+			if query.Get("code") != "code" || query.Get("redirect_uri") != "https://app.example.com/qq" {
+				w.Write([]byte(`callback( {"error":100020,"error_description":"code is reused error"} );`))
+				return
+			}
+			w.Write([]byte("access_token=AT&expires_in=7776000&refresh_token=RT"))
+		case "/oauth2.0/me":
+			if query.Get("access_token") != "AT" {
+				w.Write([]byte(`callback( {"error":100016,"error_description":"access token check failed"} );`))
+				return
+			}
+			w.Write([]byte(`callback( {"client_id":"101","openid":"OPENID","unionid":"UNIONID"} );`))
+		case "/user/get_user_info":
+			if query.Get("oauth_consumer_key") != "101" || query.Get("openid") != "OPENID" {
+				w.Write([]byte(`{"ret":-1,"msg":"client request's parameters are invalid, invalid openid"}`))
+				return
+			}
+			w.Write([]byte(`{"ret":0,"msg":"","nickname":"Peter","gender":"男","gender_type":1,"province":"广东","city":"深圳","figureurl_qq_1":"http://q.qlogo.cn/40","figureurl_qq_2":"http://q.qlogo.cn/100"}`))
+		}
+	}))
+	defer server.Close()
+
+	q := New("101", "key", "https://app.example.com/qq", WithUnionID())
+	q.TokenEndpoint = server.URL + "/oauth2.0/token"
+	q.MeEndpoint = server.URL + "/oauth2.0/me"
+	q.UserInfoEndpoint = server.URL + "/user/get_user_info"
+
+	authURL, err := q.AuthorizeURL("state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(authURL)
+	if u.Query().Get("scope") != DefaultScope || u.Query().Get("client_id") != "101" {
+		t.Errorf("unexpected authorize url %s", authURL)
+	}
+
+	user, token, err := q.Login("code")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "AT" || token.RefreshToken != "RT" || token.ExpiresIn != 7776000 {
+		t.Errorf("unexpected token %+v", token)
+	}
+	if user.OpenID != "OPENID" || user.UnionID != "UNIONID" || user.Nickname != "Peter" || user.Gender != "male" || user.Avatar != "http://q.qlogo.cn/100" {
+		t.Errorf("unexpected user %+v", user)
+	}
+	if profile := user.Profile(); profile.Subject != "UNIONID" || profile.Provider != "qq" {
+		t.Errorf("unexpected profile %+v", profile)
+	}
+	// the mapper read raw user info merged with the ids of /oauth2.0/me
+	if profile, err := oauth.ParseProfile([]byte(`{"ret":0,"nickname":"Peter","figureurl_qq_1":"http://q.qlogo.cn/40","openid":"OPENID"}`), Profile); err != nil || profile.Subject != "OPENID" || profile.Picture != "http://q.qlogo.cn/40" {
+		t.Errorf("unexpected mapped profile %+v %v", profile, err)
+	}
+
+	token, err = q.Refresh("RT")
+	if err != nil || token.AccessToken != "AT2" {
+		t.Errorf("unexpected refresh %+v %v", token, err)
+	}
+
+	_, err = q.Exchange("reused")
+	if oe, ok := err.(*errorx.OauthError); !ok || oe.Code != "100020" {
+		t.Errorf("expected code reused error, got %v", err)
+	}
+	_, err = q.OpenID("expired")
+	if oe, ok := err.(*errorx.OauthError); !ok || oe.Code != "100016" || oe.Description != "access token check failed" {
+		t.Errorf("expected numeric qq error code, got %v", err)
+	}
+	_, err = q.UserInfo("AT", "other")
+	if qe, ok := err.(*Error); !ok || qe.Ret != -1 {
+		t.Errorf("expected qq api error, got %v", err)
+	}
+}
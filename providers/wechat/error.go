@@ -0,0 +1,49 @@
+package wechat
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Error wechat api error returned as errcode and errmsg
+type Error struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+// Common wechat oauth errors, compare with errors.Is
+var (
+	ErrInvalidCredential   = &Error{ErrCode: 40001, ErrMsg: "invalid credential"}
+	ErrInvalidOpenID       = &Error{ErrCode: 40003, ErrMsg: "invalid openid"}
+	ErrInvalidAppID        = &Error{ErrCode: 40013, ErrMsg: "invalid appid"}
+	ErrInvalidCode         = &Error{ErrCode: 40029, ErrMsg: "invalid code"}
+	ErrInvalidRefreshToken = &Error{ErrCode: 40030, ErrMsg: "invalid refresh_token"}
+	ErrInvalidScope        = &Error{ErrCode: 40117, ErrMsg: "invalid scope"}
+	ErrCodeUsed            = &Error{ErrCode: 40163, ErrMsg: "code been used"}
+	ErrAccessTokenExpired  = &Error{ErrCode: 42001, ErrMsg: "access_token expired"}
+	ErrRefreshTokenExpired = &Error{ErrCode: 42002, ErrMsg: "refresh_token expired"}
+	ErrCodeExpired         = &Error{ErrCode: 42003, ErrMsg: "code expired"}
+	ErrAPIUnauthorized     = &Error{ErrCode: 48001, ErrMsg: "api unauthorized"}
+)
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("wechat error: errcode %d, %s", e.ErrCode, e.ErrMsg)
+}
+
+// Is match errors by errcode
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.ErrCode == e.ErrCode
+}
+
+// checkError return *Error when body carry a non zero errcode
+func checkError(data []byte) error {
+	var e = &Error{}
+	if err := json.Unmarshal(data, e); err != nil {
+		return nil
+	}
+	if e.ErrCode != 0 {
+		return e
+	}
+	return nil
+}
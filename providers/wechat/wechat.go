@@ -0,0 +1,273 @@
+// Package wechat wechat open platform and official account login adapter.
+// WeChat use appid and secret query params, GET token requests,
+// the #wechat_redirect authorize fragment and errcode/errmsg errors inside 200 responses
+package wechat
+
+import (
+	"encoding/json"
+	"github.com/demo007x/oauth2-client/oauth"
+	"github.com/demo007x/oauth2-client/types"
+	"net/http"
+	"net/url"
+)
+
+const (
+	// QRConnectURL open platform website qr code login
+	QRConnectURL = "https://open.weixin.qq.com/connect/qrconnect"
+	// OfficialAccountAuthorizeURL official account web page authorization inside wechat
+	OfficialAccountAuthorizeURL = "https://open.weixin.qq.com/connect/oauth2/authorize"
+	TokenURL                    = "https://api.weixin.qq.com/sns/oauth2/access_token"
+	RefreshTokenURL             = "https://api.weixin.qq.com/sns/oauth2/refresh_token"
+	UserInfoURL                 = "https://api.weixin.qq.com/sns/userinfo"
+
+	// SnsapiBase silent authorization, only openid
+	SnsapiBase = "snsapi_base"
+	// SnsapiUserinfo user confirmed authorization, openid and profile
+	SnsapiUserinfo = "snsapi_userinfo"
+	// SnsapiLogin open platform website login
+	SnsapiLogin = "snsapi_login"
+)
+
+type (
+	Option func(w *WeChat)
+	// WeChat wechat application
+	WeChat struct {
+		AppID       string
+		Secret      string
+		RedirectURI string
+		Scope       string
+		// Lang userinfo language. zh_CN, zh_TW or en
+		Lang string
+
+		AuthorizeEndpoint    string
+		TokenEndpoint        string
+		RefreshTokenEndpoint string
+		UserInfoEndpoint     string
+	}
+
+	// Token wechat access token with openid and unionid
+	Token struct {
+		*oauth.Token
+		OpenID  string
+		UnionID string
+	}
+
+	// User normalized wechat user profile
+	User struct {
+		OpenID   string
+		UnionID  string
+		Nickname string
+		Avatar   string
+		// Gender male, female or empty
+		Gender    string
+		Province  string
+		City      string
+		Country   string
+		Privilege []string
+		Raw       map[string]interface{}
+	}
+)
+
+// WithScope set authorize scope. snsapi_base or snsapi_userinfo
+func WithScope(scope string) Option {
+	return func(w *WeChat) {
+		w.Scope = scope
+	}
+}
+
+// WithLang set userinfo language
+func WithLang(lang string) Option {
+	return func(w *WeChat) {
+		w.Lang = lang
+	}
+}
+
+// ResponseHandler read body and return errcode as *Error
+func ResponseHandler(resp *http.Response) ([]byte, error) {
+	data, err := types.DefaultOauthResponseHandler(resp)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkError(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// AuthorizeURL build authorize url with appid and #wechat_redirect fragment
+func (w *WeChat) AuthorizeURL(state string, opts ...oauth.WithOption) (string, error) {
+	opts = append([]oauth.WithOption{
+		oauth.WithRedirectURI(w.RedirectURI),
+		oauth.WithScope(w.Scope),
+		oauth.WithState(state),
+	}, opts...)
+	authURL, err := oauth.NewOauth2Client(w.AuthorizeEndpoint, w.AppID, opts...).AuthorizeURL()
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(authURL)
+	if err != nil {
+		return "", err
+	}
+	// wechat call client_id appid
+	var values = u.Query()
+	values.Set("appid", values.Get("client_id"))
+	values.Del("client_id")
+	u.RawQuery = values.Encode()
+	u.Fragment = "wechat_redirect"
+	return u.String(), nil
+}
+
+// Exchange exchange authorization code for access token and openid
+func (w *WeChat) Exchange(code string) (*Token, error) {
+	data, err := oauth.NewAccessToken(w.TokenEndpoint, w.AppID, w.Secret, code,
+		oauth.AccessTokenWithGrantType(types.DefaultAccessTokenGrantType),
+		oauth.AccessTokenWithAuthMethod(oauth.AuthMethodNone),
+		oauth.AccessTokenWithMethod(http.MethodGet),
+		oauth.AccessTokenWithQuery(map[string]string{"appid": w.AppID, "secret": w.Secret}),
+		oauth.AccessTokenWithResponseHandler(ResponseHandler),
+	).DoRequest()
+	if err != nil {
+		return nil, err
+	}
+	return parseToken(data)
+}
+
+// Refresh renew access token, the refresh token is valid for 30 days
+func (w *WeChat) Refresh(refreshToken string) (*Token, error) {
+	data, err := oauth.NewRefreshToken(w.RefreshTokenEndpoint, w.AppID, "", refreshToken,
+		oauth.RefreshTokenWithGrantType("refresh_token"),
+		oauth.RefreshTokenWithAuthMethod(oauth.AuthMethodNone),
+		oauth.RefreshTokenWithMethod(http.MethodGet),
+		oauth.RefreshTokenWithQuery(map[string]string{"appid": w.AppID}),
+		oauth.RefreshTokenWithResponseHandler(ResponseHandler),
+	).DoRequest()
+	if err != nil {
+		return nil, err
+	}
+	return parseToken(data)
+}
+
+// UserInfo get user profile, need snsapi_userinfo or snsapi_login scope
+func (w *WeChat) UserInfo(accessToken, openID string) (*User, error) {
+	var values = url.Values{}
+	values.Set("access_token", accessToken)
+	values.Set("openid", openID)
+	if w.Lang != "" {
+		values.Set("lang", w.Lang)
+	}
+	data, err := oauth.NewUserInfo(w.UserInfoEndpoint+"?"+values.Encode(), accessToken,
+		oauth.UserInfoWithMethod(http.MethodGet),
+		oauth.UserInfoWithResponseHandler(ResponseHandler),
+	).DoRequest()
+	if err != nil {
+		return nil, err
+	}
+	var raw = map[string]interface{}{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	return normalize(raw), nil
+}
+
+// Login exchange the code and fetch user profile.
+// With snsapi_base scope only openid and unionid are filled
+func (w *WeChat) Login(code string) (*User, *Token, error) {
+	token, err := w.Exchange(code)
+	if err != nil {
+		return nil, nil, err
+	}
+	if w.Scope == SnsapiBase {
+		return &User{OpenID: token.OpenID, UnionID: token.UnionID}, token, nil
+	}
+	user, err := w.UserInfo(token.AccessToken, token.OpenID)
+	if err != nil {
+		return nil, token, err
+	}
+	return user, token, nil
+}
+
+func parseToken(data []byte) (*Token, error) {
+	token, err := oauth.ParseToken(data)
+	if err != nil {
+		return nil, err
+	}
+	var openID, _ = token.Raw["openid"].(string)
+	var unionID, _ = token.Raw["unionid"].(string)
+	return &Token{Token: token, OpenID: openID, UnionID: unionID}, nil
+}
+
+// Profile normalized profile, subject is unionid when bound to open platform or openid
+func (u *User) Profile() *oauth.Profile {
+	return Profile(u.Raw)
+}
+
+// Profile map sns/userinfo, subject is unionid when bound to open platform or openid
+func Profile(raw map[string]interface{}) *oauth.Profile {
+	var subject = oauth.StringClaim(raw, "unionid")
+	if subject == "" {
+		subject = oauth.StringClaim(raw, "openid")
+	}
+	return &oauth.Profile{
+		Subject:  subject,
+		Name:     oauth.StringClaim(raw, "nickname"),
+		Picture:  oauth.StringClaim(raw, "headimgurl"),
+		Provider: "wechat",
+		Raw:      raw,
+	}
+}
+
+func normalize(raw map[string]interface{}) *User {
+	var user = &User{
+		OpenID:   oauth.StringClaim(raw, "openid"),
+		UnionID:  oauth.StringClaim(raw, "unionid"),
+		Nickname: oauth.StringClaim(raw, "nickname"),
+		Avatar:   oauth.StringClaim(raw, "headimgurl"),
+		Province: oauth.StringClaim(raw, "province"),
+		City:     oauth.StringClaim(raw, "city"),
+		Country:  oauth.StringClaim(raw, "country"),
+		Raw:      raw,
+	}
+	switch oauth.StringClaim(raw, "sex") {
+	case "1":
+		user.Gender = "male"
+	case "2":
+		user.Gender = "female"
+	}
+	if privileges, ok := raw["privilege"].([]interface{}); ok {
+		for _, p := range privileges {
+			if s, ok := p.(string); ok {
+				user.Privilege = append(user.Privilege, s)
+			}
+		}
+	}
+	return user
+}
+
+// NewOfficialAccount return adapter of official account web page authorization.
+// snsapi_base by default
+func NewOfficialAccount(appID, secret, redirectURI string, opts ...Option) *WeChat {
+	return newWeChat(OfficialAccountAuthorizeURL, SnsapiBase, appID, secret, redirectURI, opts...)
+}
+
+// NewOpenPlatform return adapter of open platform website qr code login
+func NewOpenPlatform(appID, secret, redirectURI string, opts ...Option) *WeChat {
+	return newWeChat(QRConnectURL, SnsapiLogin, appID, secret, redirectURI, opts...)
+}
+
+func newWeChat(authorizeURL, scope, appID, secret, redirectURI string, opts ...Option) *WeChat {
+	var w = &WeChat{
+		AppID:                appID,
+		Secret:               secret,
+		RedirectURI:          redirectURI,
+		Scope:                scope,
+		AuthorizeEndpoint:    authorizeURL,
+		TokenEndpoint:        TokenURL,
+		RefreshTokenEndpoint: RefreshTokenURL,
+		UserInfoEndpoint:     UserInfoURL,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
@@ -0,0 +1,93 @@
+package wechat
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestWeChat(t *testing.T) {
+	var usedCodes = map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.Method != http.MethodGet || (strings.HasPrefix(r.URL.Path, "/sns/oauth2/") && query.Get("appid") != "wx1") {
+			w.Write([]byte(`{"errcode":40013,"errmsg":"invalid appid"}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/sns/oauth2/access_token":
+			if query.Get("secret") != "s" {
+				w.Write([]byte(`{"errcode":40001,"errmsg":"invalid credential"}`))
+				return
+			}
+			if usedCodes[query.Get("code")] {
+				w.Write([]byte(`{"errcode":40163,"errmsg":"code been used, rid: 1"}`))
+				return
+			}
+			usedCodes[query.Get("code")] = true
+			w.Write([]byte(`{"access_token":"AT","expires_in":7200,"refresh_token":"RT","openid":"OPENID","scope":"snsapi_userinfo","unionid":"UNIONID"}`))
+		case "/sns/oauth2/refresh_token":
+			if query.Get("grant_type") != "refresh_token" || query.Get("refresh_token") != "RT" {
+				w.Write([]byte(`{"errcode":40030,"errmsg":"invalid refresh_token"}`))
+				return
+			}
+			w.Write([]byte(`{"access_token":"AT2","expires_in":7200,"refresh_token":"RT","openid":"OPENID","scope":"snsapi_userinfo"}`))
+		case "/sns/userinfo":
+			if query.Get("openid") != "OPENID" {
+				w.Write([]byte(`{"errcode":40003,"errmsg":"invalid openid"}`))
+				return
+			}
+			w.Write([]byte(`{"openid":"OPENID","nickname":"NICKNAME","sex":2,"province":"PROVINCE","city":"CITY","country":"COUNTRY","headimgurl":"https://thirdwx.qlogo.cn/mmopen/46","privilege":["PRIVILEGE1"],"unionid":"UNIONID"}`))
+		}
+	}))
+	defer server.Close()
+
+	wx := NewOfficialAccount("wx1", "s", "https://app.example.com/wechat", WithScope(SnsapiUserinfo), WithLang("zh_CN"))
+	wx.TokenEndpoint = server.URL + "/sns/oauth2/access_token"
+	wx.RefreshTokenEndpoint = server.URL + "/sns/oauth2/refresh_token"
+	wx.UserInfoEndpoint = server.URL + "/sns/userinfo"
+
+	authURL, err := wx.AuthorizeURL("state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(authURL)
+	if !strings.HasSuffix(authURL, "#wechat_redirect") || u.Query().Get("appid") != "wx1" || u.Query().Get("client_id") != "" || u.Query().Get("scope") != SnsapiUserinfo {
+		t.Errorf("unexpected authorize url %s", authURL)
+	}
+
+	user, token, err := wx.Login("code")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.OpenID != "OPENID" || token.UnionID != "UNIONID" || token.RefreshToken != "RT" {
+		t.Errorf("unexpected token %+v", token)
+	}
+	if user.Nickname != "NICKNAME" || user.Gender != "female" || user.Avatar == "" || len(user.Privilege) != 1 {
+		t.Errorf("unexpected user %+v", user)
+	}
+	if profile := user.Profile(); profile.Subject != "UNIONID" || profile.Provider != "wechat" {
+		t.Errorf("unexpected profile %+v", profile)
+	}
+
+	if _, err := wx.Exchange("code"); !errors.Is(err, ErrCodeUsed) {
+		t.Errorf("expected code been used, got %v", err)
+	}
+	token, err = wx.Refresh("RT")
+	if err != nil || token.AccessToken != "AT2" {
+		t.Errorf("unexpected refresh %+v %v", token, err)
+	}
+	if _, err := wx.Refresh("bad"); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("expected invalid refresh_token, got %v", err)
+	}
+
+	base := NewOfficialAccount("wx1", "s", "https://app.example.com/wechat")
+	base.TokenEndpoint = wx.TokenEndpoint
+	user, _, err = base.Login("code2")
+	if err != nil || user.OpenID != "OPENID" || user.Nickname != "" {
+		t.Errorf("snsapi_base login should only return openid, %+v %v", user, err)
+	}
+}
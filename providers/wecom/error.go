@@ -0,0 +1,54 @@
+package wecom
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Error wecom api error returned as errcode and errmsg
+type Error struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+// Common wecom errors, compare with errors.Is
+var (
+	ErrInvalidSecret      = &Error{ErrCode: 40001, ErrMsg: "invalid secret"}
+	ErrInvalidCorpID      = &Error{ErrCode: 40013, ErrMsg: "invalid corpid"}
+	ErrInvalidAccessToken = &Error{ErrCode: 40014, ErrMsg: "invalid access_token"}
+	ErrInvalidCode        = &Error{ErrCode: 40029, ErrMsg: "invalid code"}
+	ErrInvalidAgentID     = &Error{ErrCode: 40056, ErrMsg: "invalid agentid"}
+	ErrAccessTokenExpired = &Error{ErrCode: 42001, ErrMsg: "access_token expired"}
+	ErrAPIForbidden       = &Error{ErrCode: 48002, ErrMsg: "api forbidden"}
+	ErrUntrustedRedirect  = &Error{ErrCode: 50001, ErrMsg: "redirect_url not trusted"}
+	ErrNoPrivilege        = &Error{ErrCode: 60011, ErrMsg: "no privilege to access/modify contact/party/agent"}
+	ErrUserNotFound       = &Error{ErrCode: 60111, ErrMsg: "userid not found"}
+)
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("wecom error: errcode %d, %s", e.ErrCode, e.ErrMsg)
+}
+
+// Is match errors by errcode
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.ErrCode == e.ErrCode
+}
+
+// IsTokenInvalid whether the corp access token is rejected and should be renewed
+func IsTokenInvalid(err error) bool {
+	return errors.Is(err, ErrInvalidAccessToken) || errors.Is(err, ErrAccessTokenExpired)
+}
+
+// checkError return *Error when body carry a non zero errcode
+func checkError(data []byte) error {
+	var e = &Error{}
+	if err := json.Unmarshal(data, e); err != nil {
+		return nil
+	}
+	if e.ErrCode != 0 {
+		return e
+	}
+	return nil
+}
@@ -0,0 +1,356 @@
+// Package wecom wecom (wechat work) login adapter.
+// WeCom has no user access token: the code is resolved to a userid and user_ticket
+// with the cached corp access token, errors are errcode/errmsg inside 200 responses
+package wecom
+
+import (
+	"encoding/json"
+	"github.com/demo007x/oauth2-client/errorx"
+	"github.com/demo007x/oauth2-client/oauth"
+	"github.com/demo007x/oauth2-client/providers"
+	"github.com/demo007x/oauth2-client/types"
+	"github.com/demo007x/oauth2-client/utils"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// OAuthAuthorizeURL web page authorization inside wecom client
+	OAuthAuthorizeURL = "https://open.weixin.qq.com/connect/oauth2/authorize"
+	// QRLoginURL website qr code login
+	QRLoginURL = "https://login.work.weixin.qq.com/wwlogin/sso/login"
+	APIURL     = "https://qyapi.weixin.qq.com"
+
+	// SnsapiBase silent authorization, userid only
+	SnsapiBase = "snsapi_base"
+	// SnsapiPrivateInfo user confirmed authorization, also return user_ticket for sensitive profile
+	SnsapiPrivateInfo = "snsapi_privateinfo"
+)
+
+type (
+	Option func(w *WeCom)
+	// WeCom wecom self built application
+	WeCom struct {
+		CorpID      string
+		AgentID     string
+		Secret      string
+		RedirectURI string
+		Scope       string
+
+		AuthorizeEndpoint string
+		APIEndpoint       string
+
+		qrLogin      bool
+		accessToken  *providers.AppTokenCache
+		interceptors []utils.RequestInterceptor
+		observer     oauth.Observer
+	}
+
+	// Token identity of the login user. AccessToken hold the user_ticket when scope is snsapi_privateinfo
+	Token struct {
+		*oauth.Token
+		UserID string
+		// OpenID set for non corp members
+		OpenID         string
+		ExternalUserID string
+	}
+
+	// User normalized wecom user profile
+	User struct {
+		UserID string
+		OpenID string
+		Name   string
+		Avatar string
+		// Gender male, female or empty
+		Gender   string
+		Mobile   string
+		Email    string
+		BizMail  string
+		Position string
+		Raw      map[string]interface{}
+	}
+)
+
+// WithScope set authorize scope. snsapi_base or snsapi_privateinfo
+func WithScope(scope string) Option {
+	return func(w *WeCom) {
+		w.Scope = scope
+	}
+}
+
+// WithInterceptors modify or audit every api request before it is sent
+func WithInterceptors(interceptors ...utils.RequestInterceptor) Option {
+	return func(w *WeCom) {
+		w.interceptors = append(w.interceptors, interceptors...)
+	}
+}
+
+// WithObserver report every api call to observer
+func WithObserver(observer oauth.Observer) Option {
+	return func(w *WeCom) {
+		w.observer = observer
+	}
+}
+
+// AuthorizeURL build authorize url with appid and agentid
+func (w *WeCom) AuthorizeURL(state string, opts ...oauth.WithOption) (string, error) {
+	opts = append([]oauth.WithOption{
+		oauth.WithRedirectURI(w.RedirectURI),
+		oauth.WithScope(w.Scope),
+		oauth.WithState(state),
+	}, opts...)
+	authURL, err := oauth.NewOauth2Client(w.AuthorizeEndpoint, w.CorpID, opts...).AuthorizeURL()
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(authURL)
+	if err != nil {
+		return "", err
+	}
+	var values = u.Query()
+	values.Set("appid", values.Get("client_id"))
+	values.Del("client_id")
+	values.Set("agentid", w.AgentID)
+	if w.qrLogin {
+		values.Set("login_type", "CorpApp")
+		values.Del("response_type")
+		values.Del("scope")
+	} else {
+		u.Fragment = "wechat_redirect"
+	}
+	u.RawQuery = values.Encode()
+	return u.String(), nil
+}
+
+// Exchange resolve authorization code to userid and user_ticket
+func (w *WeCom) Exchange(code string) (*Token, error) {
+	if strings.TrimSpace(code) == "" {
+		return nil, errorx.CodeEmptyError
+	}
+	data, err := w.withAccessToken(func(accessToken string) ([]byte, error) {
+		var values = url.Values{}
+		values.Set("access_token", accessToken)
+		values.Set("code", code)
+		return w.do(http.MethodGet, "/cgi-bin/auth/getuserinfo?"+values.Encode(), nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var raw = map[string]interface{}{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	var token = &Token{
+		Token:          &oauth.Token{AccessToken: oauth.StringClaim(raw, "user_ticket"), Raw: raw},
+		UserID:         oauth.StringClaim(raw, "userid"),
+		OpenID:         oauth.StringClaim(raw, "openid"),
+		ExternalUserID: oauth.StringClaim(raw, "external_userid"),
+	}
+	if token.UserID == "" && token.OpenID == "" {
+		return nil, errorx.TokenEmptyError
+	}
+	if expiresIn, _ := raw["expires_in"].(float64); expiresIn > 0 {
+		token.ExpiresIn = int64(expiresIn)
+		token.Expiry = time.Now().Add(time.Duration(expiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// UserInfo get the profile of a corp member.
+// The sensitive fields (avatar, mobile, email) are filled only with a user_ticket
+func (w *WeCom) UserInfo(token *Token) (*User, error) {
+	data, err := w.withAccessToken(func(accessToken string) ([]byte, error) {
+		var values = url.Values{}
+		values.Set("access_token", accessToken)
+		values.Set("userid", token.UserID)
+		return w.do(http.MethodGet, "/cgi-bin/user/get?"+values.Encode(), nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var raw = map[string]interface{}{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	if token.AccessToken != "" {
+		data, err := w.withAccessToken(func(accessToken string) ([]byte, error) {
+			return w.do(http.MethodPost, "/cgi-bin/auth/getuserdetail?access_token="+url.QueryEscape(accessToken),
+				map[string]string{"user_ticket": token.AccessToken})
+		})
+		if err != nil {
+			return nil, err
+		}
+		var detail = map[string]interface{}{}
+		if err := json.Unmarshal(data, &detail); err != nil {
+			return nil, err
+		}
+		for key, val := range detail {
+			if key != "errcode" && key != "errmsg" {
+				raw[key] = val
+			}
+		}
+	}
+	return normalize(raw), nil
+}
+
+// Login resolve the code and fetch profile of corp members.
+// Non corp members only have openid
+func (w *WeCom) Login(code string) (*User, *Token, error) {
+	token, err := w.Exchange(code)
+	if err != nil {
+		return nil, nil, err
+	}
+	if token.UserID == "" {
+		return &User{OpenID: token.OpenID}, token, nil
+	}
+	user, err := w.UserInfo(token)
+	if err != nil {
+		return nil, token, err
+	}
+	return user, token, nil
+}
+
+// AccessToken return the cached corp access token of the application
+func (w *WeCom) AccessToken() (string, error) {
+	return w.accessToken.Token()
+}
+
+func (w *WeCom) fetchAccessToken() (string, int64, error) {
+	var values = url.Values{}
+	values.Set("corpid", w.CorpID)
+	values.Set("corpsecret", w.Secret)
+	data, err := w.do(http.MethodGet, "/cgi-bin/gettoken?"+values.Encode(), nil)
+	if err != nil {
+		return "", 0, err
+	}
+	var resp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return "", 0, err
+	}
+	if resp.AccessToken == "" {
+		return "", 0, errorx.TokenEmptyError
+	}
+	return resp.AccessToken, resp.ExpiresIn, nil
+}
+
+// withAccessToken call api with the cached token, renew the token and call once more if it is rejected
+func (w *WeCom) withAccessToken(call func(accessToken string) ([]byte, error)) ([]byte, error) {
+	accessToken, err := w.AccessToken()
+	if err != nil {
+		return nil, err
+	}
+	data, err := call(accessToken)
+	if IsTokenInvalid(err) {
+		w.accessToken.Invalidate()
+		if accessToken, err = w.AccessToken(); err != nil {
+			return nil, err
+		}
+		data, err = call(accessToken)
+	}
+	return data, err
+}
+
+func (w *WeCom) do(method, path string, params interface{}) ([]byte, error) {
+	var opts = []oauth.APIRequestOption{
+		oauth.APIRequestWithResponseHandler(ResponseHandler),
+		oauth.APIRequestWithInterceptors(w.interceptors...),
+		oauth.APIRequestWithObserver(w.observer),
+	}
+	if params != nil {
+		opts = append(opts, oauth.APIRequestWithJSON(params))
+	}
+	return oauth.NewAPIRequest(w.APIEndpoint+path, method, opts...).DoRequest()
+}
+
+// ResponseHandler read body and return errcode as *Error
+func ResponseHandler(resp *http.Response) ([]byte, error) {
+	data, err := types.DefaultOauthResponseHandler(resp)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkError(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Profile normalized profile, subject is userid of corp members or openid
+func (u *User) Profile() *oauth.Profile {
+	return Profile(u.Raw)
+}
+
+// Profile map user detail, subject is userid of corp members or openid
+func Profile(raw map[string]interface{}) *oauth.Profile {
+	var subject = oauth.StringClaim(raw, "userid")
+	if subject == "" {
+		subject = oauth.StringClaim(raw, "open_userid")
+	}
+	var email = oauth.StringClaim(raw, "email")
+	if email == "" {
+		email = oauth.StringClaim(raw, "biz_mail")
+	}
+	return &oauth.Profile{
+		Subject:  subject,
+		Email:    email,
+		Name:     oauth.StringClaim(raw, "name"),
+		Picture:  oauth.StringClaim(raw, "avatar"),
+		Provider: "wecom",
+		Raw:      raw,
+	}
+}
+
+func normalize(raw map[string]interface{}) *User {
+	var user = &User{
+		UserID:   oauth.StringClaim(raw, "userid"),
+		OpenID:   oauth.StringClaim(raw, "open_userid"),
+		Name:     oauth.StringClaim(raw, "name"),
+		Avatar:   oauth.StringClaim(raw, "avatar"),
+		Mobile:   oauth.StringClaim(raw, "mobile"),
+		Email:    oauth.StringClaim(raw, "email"),
+		BizMail:  oauth.StringClaim(raw, "biz_mail"),
+		Position: oauth.StringClaim(raw, "position"),
+		Raw:      raw,
+	}
+	switch oauth.StringClaim(raw, "gender") {
+	case "1":
+		user.Gender = "male"
+	case "2":
+		user.Gender = "female"
+	}
+	return user
+}
+
+// New return adapter of web page authorization inside wecom client.
+// snsapi_base by default
+func New(corpID, agentID, secret, redirectURI string, opts ...Option) *WeCom {
+	return newWeCom(OAuthAuthorizeURL, false, corpID, agentID, secret, redirectURI, opts...)
+}
+
+// NewQRLogin return adapter of website qr code login
+func NewQRLogin(corpID, agentID, secret, redirectURI string, opts ...Option) *WeCom {
+	return newWeCom(QRLoginURL, true, corpID, agentID, secret, redirectURI, opts...)
+}
+
+func newWeCom(authorizeURL string, qrLogin bool, corpID, agentID, secret, redirectURI string, opts ...Option) *WeCom {
+	var w = &WeCom{
+		CorpID:            corpID,
+		AgentID:           agentID,
+		Secret:            secret,
+		RedirectURI:       redirectURI,
+		Scope:             SnsapiBase,
+		AuthorizeEndpoint: authorizeURL,
+		APIEndpoint:       APIURL,
+		qrLogin:           qrLogin,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	w.accessToken = providers.NewAppTokenCache(w.fetchAccessToken)
+	return w
+}
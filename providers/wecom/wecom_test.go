@@ -0,0 +1,129 @@
+package wecom
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/demo007x/oauth2-client/oauth"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestWeCom(t *testing.T) {
+	var accessTokens int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path == "/cgi-bin/gettoken" {
+			if query.Get("corpid") != "ww1" || query.Get("corpsecret") != "s" {
+				w.Write([]byte(`{"errcode":40001,"errmsg":"invalid credential"}`))
+				return
+			}
+			accessTokens++
+			w.Write([]byte(`{"errcode":0,"errmsg":"ok","access_token":"AT` + string(rune('0'+accessTokens)) + `","expires_in":7200}`))
+			return
+		}
+		// the first corp access token is expired on server side
+		if query.Get("access_token") != "AT2" {
+			w.Write([]byte(`{"errcode":42001,"errmsg":"access_token expired"}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/cgi-bin/auth/getuserinfo":
+			switch query.Get("code") {
+			case "member":
+				w.Write([]byte(`{"errcode":0,"errmsg":"ok","userid":"zhangsan","user_ticket":"TICKET","expires_in":1800}`))
+			case "guest":
+				w.Write([]byte(`{"errcode":0,"errmsg":"ok","openid":"OPENID","external_userid":"EXT"}`))
+			default:
+				w.Write([]byte(`{"errcode":40029,"errmsg":"invalid code"}`))
+			}
+		case "/cgi-bin/user/get":
+			w.Write([]byte(`{"errcode":0,"errmsg":"ok","userid":"zhangsan","name":"张三","position":"engineer","open_userid":"woAJ2GCAAA"}`))
+		case "/cgi-bin/auth/getuserdetail":
+			var body = map[string]string{}
+			json.NewDecoder(r.Body).Decode(&body)
+			if body["user_ticket"] != "TICKET" {
+				w.Write([]byte(`{"errcode":40001,"errmsg":"invalid user_ticket"}`))
+				return
+			}
+			w.Write([]byte(`{"errcode":0,"errmsg":"ok","userid":"zhangsan","gender":"1","avatar":"https://wework.qpic.cn/a.png","mobile":"13800000000","email":"zhangsan@example.com","biz_mail":"zhangsan@corp.com"}`))
+		}
+	}))
+	defer server.Close()
+
+	wc := New("ww1", "1000002", "s", "https://app.example.com/wecom", WithScope(SnsapiPrivateInfo))
+	wc.APIEndpoint = server.URL
+
+	authURL, err := wc.AuthorizeURL("state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(authURL)
+	if q := u.Query(); !strings.HasSuffix(authURL, "#wechat_redirect") || q.Get("appid") != "ww1" || q.Get("agentid") != "1000002" || q.Get("client_id") != "" {
+		t.Errorf("unexpected authorize url %s", authURL)
+	}
+
+	user, token, err := wc.Login("member")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.UserID != "zhangsan" || token.AccessToken != "TICKET" || token.Expiry.IsZero() {
+		t.Errorf("unexpected token %+v", token)
+	}
+	if user.Name != "张三" || user.Gender != "male" || user.Email != "zhangsan@example.com" || user.Position != "engineer" {
+		t.Errorf("unexpected user %+v", user)
+	}
+	if profile := user.Profile(); profile.Subject != "zhangsan" || profile.Provider != "wecom" {
+		t.Errorf("unexpected profile %+v", profile)
+	}
+
+	user, token, err = wc.Login("guest")
+	if err != nil || user.OpenID != "OPENID" || token.ExternalUserID != "EXT" {
+		t.Errorf("unexpected guest %+v %+v %v", user, token, err)
+	}
+	if _, err := wc.Exchange("bad"); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("expected invalid code, got %v", err)
+	}
+	if accessTokens != 2 {
+		t.Errorf("access token should be cached and renewed once, fetched %d times", accessTokens)
+	}
+
+	qr := NewQRLogin("ww1", "1000002", "s", "https://app.example.com/wecom")
+	authURL, err = qr.AuthorizeURL("state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ = url.Parse(authURL)
+	if q := u.Query(); u.Fragment != "" || q.Get("login_type") != "CorpApp" || q.Get("appid") != "ww1" {
+		t.Errorf("unexpected qr login url %s", authURL)
+	}
+}
+
+func TestWeComInterceptors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Tenant") != "t1" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"errcode":0,"errmsg":"ok","access_token":"AT","expires_in":7200}`))
+	}))
+	defer server.Close()
+
+	var observed []*oauth.Observation
+	wc := New("ww1", "1000002", "s", "",
+		WithInterceptors(func(r *http.Request) error {
+			r.Header.Set("X-Tenant", "t1")
+			return nil
+		}),
+		WithObserver(oauth.ObserverFunc(func(o *oauth.Observation) { observed = append(observed, o) })),
+	)
+	wc.APIEndpoint = server.URL
+	if token, err := wc.AccessToken(); err != nil || token != "AT" {
+		t.Fatalf("expected interceptor header sent, got %q %v", token, err)
+	}
+	if len(observed) != 1 || observed[0].Endpoint != oauth.EndpointAPI || observed[0].StatusCode != http.StatusOK {
+		t.Errorf("expected the api call observed, got %+v", observed)
+	}
+}
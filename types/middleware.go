@@ -0,0 +1,180 @@
+package types
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"github.com/demo007x/oauth2-client/errorx"
+	"github.com/demo007x/oauth2-client/utils"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type (
+	// ResponseMiddleware wrap a response handler to check or transform the response
+	ResponseMiddleware func(next OauthResponseHandler) OauthResponseHandler
+	// StatusErrorMapper map error status and body to typed error
+	StatusErrorMapper func(statusCode int, body []byte) error
+
+	// limitedBody fail the read once the body is larger than the limit
+	limitedBody struct {
+		io.ReadCloser
+		remaining int64
+	}
+
+	// gzipBody close both the gzip reader and the original body
+	gzipBody struct {
+		*gzip.Reader
+		body io.Closer
+	}
+)
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		var one [1]byte
+		if n, _ := b.ReadCloser.Read(one[:]); n > 0 {
+			return 0, errorx.ResponseTooLargeError
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
+
+func (b *gzipBody) Close() error {
+	b.Reader.Close()
+	return b.body.Close()
+}
+
+// ChainResponseHandler stack middlewares on DefaultOauthResponseHandler, the first one is the outermost.
+// eg: ChainResponseHandler(LoggingTap(log.Printf), CheckStatus(nil), Gzip(), MaxBodySize(1<<20), DecodeBody())
+func ChainResponseHandler(middlewares ...ResponseMiddleware) OauthResponseHandler {
+	var handler OauthResponseHandler = DefaultOauthResponseHandler
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// CheckStatus map status >= 400 to error, *errorx.OauthError when mapper is nil
+func CheckStatus(mapper StatusErrorMapper) ResponseMiddleware {
+	if mapper == nil {
+		mapper = func(statusCode int, body []byte) error {
+			return errorx.ParseOauthError(statusCode, body)
+		}
+	}
+	return func(next OauthResponseHandler) OauthResponseHandler {
+		return func(resp *http.Response) ([]byte, error) {
+			data, err := next(resp)
+			if err != nil {
+				return nil, err
+			}
+			if resp.StatusCode >= http.StatusBadRequest {
+				return nil, mapper(resp.StatusCode, data)
+			}
+			return data, nil
+		}
+	}
+}
+
+// MaxBodySize fail with errorx.ResponseTooLargeError when body is larger than limit.
+// Put it after Gzip to limit the decompressed size
+func MaxBodySize(limit int64) ResponseMiddleware {
+	return func(next OauthResponseHandler) OauthResponseHandler {
+		return func(resp *http.Response) ([]byte, error) {
+			if resp.ContentLength > limit {
+				resp.Body.Close()
+				return nil, errorx.ResponseTooLargeError
+			}
+			resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: limit}
+			return next(resp)
+		}
+	}
+}
+
+// Gzip decompress body with gzip content encoding
+func Gzip() ResponseMiddleware {
+	return func(next OauthResponseHandler) OauthResponseHandler {
+		return func(resp *http.Response) ([]byte, error) {
+			if !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+				return next(resp)
+			}
+			reader, err := gzip.NewReader(resp.Body)
+			if err != nil {
+				resp.Body.Close()
+				return nil, err
+			}
+			resp.Body = &gzipBody{Reader: reader, body: resp.Body}
+			resp.Header.Del("Content-Encoding")
+			resp.ContentLength = -1
+			return next(resp)
+		}
+	}
+}
+
+// DecodeBody normalize form and jsonp body to json by content type, json body is returned unchanged.
+// eg: access_token=x&expires_in=7200 to {"access_token":"x","expires_in":"7200"}
+func DecodeBody() ResponseMiddleware {
+	return func(next OauthResponseHandler) OauthResponseHandler {
+		return func(resp *http.Response) ([]byte, error) {
+			data, err := next(resp)
+			if err != nil || len(strings.TrimSpace(string(data))) == 0 {
+				return data, err
+			}
+			mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+			switch {
+			case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+				return data, nil
+			case mediaType == "application/x-www-form-urlencoded":
+				return formToJSON(data)
+			}
+			// text/plain and javascript bodies of legacy providers
+			data = utils.UnwrapJSONP(data)
+			if json.Valid(data) || !strings.Contains(string(data), "=") {
+				return data, nil
+			}
+			return formToJSON(data)
+		}
+	}
+}
+
+// Tap call fn with response, body and error of the inner handlers. eg: metrics, audit
+func Tap(fn func(resp *http.Response, data []byte, err error)) ResponseMiddleware {
+	return func(next OauthResponseHandler) OauthResponseHandler {
+		return func(resp *http.Response) ([]byte, error) {
+			data, err := next(resp)
+			fn(resp, data, err)
+			return data, err
+		}
+	}
+}
+
+// LoggingTap log status, content type and body size of the response. The body is never logged, it carries tokens
+func LoggingTap(logf func(format string, v ...interface{})) ResponseMiddleware {
+	return Tap(func(resp *http.Response, data []byte, err error) {
+		var target string
+		if resp.Request != nil {
+			target = resp.Request.Method + " " + resp.Request.URL.Host + resp.Request.URL.Path
+		}
+		logf("oauth response %s: status %d, content type %q, %d bytes, error %v", target, resp.StatusCode, resp.Header.Get("Content-Type"), len(data), err)
+	})
+}
+
+// formToJSON convert form encoded body to json object
+func formToJSON(data []byte) ([]byte, error) {
+	values, err := url.ParseQuery(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, err
+	}
+	var object = make(map[string]string, len(values))
+	for key := range values {
+		object[key] = values.Get(key)
+	}
+	return json.Marshal(object)
+}
@@ -0,0 +1,70 @@
+package types
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"github.com/demo007x/oauth2-client/errorx"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func newResponse(status int, header map[string]string, body []byte) *http.Response {
+	var resp = &http.Response{
+		StatusCode:    status,
+		Header:        http.Header{},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: -1,
+	}
+	for key, val := range header {
+		resp.Header.Set(key, val)
+	}
+	return resp
+}
+
+func TestChainResponseHandler(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte("access_token=at&expires_in=7200"))
+	zw.Close()
+
+	var logged []string
+	handler := ChainResponseHandler(
+		LoggingTap(func(format string, v ...interface{}) { logged = append(logged, format) }),
+		CheckStatus(nil),
+		Gzip(),
+		MaxBodySize(1<<10),
+		DecodeBody(),
+	)
+	data, err := handler(newResponse(http.StatusOK, map[string]string{"Content-Encoding": "gzip", "Content-Type": "text/plain"}, buf.Bytes()))
+	if err != nil || string(data) != `{"access_token":"at","expires_in":"7200"}` {
+		t.Errorf("unexpected decoded body %s %v", data, err)
+	}
+	if len(logged) != 1 {
+		t.Errorf("expected one log line, got %d", len(logged))
+	}
+
+	data, err = handler(newResponse(http.StatusOK, map[string]string{"Content-Type": "application/javascript"}, []byte(`callback( {"openid":"x"} );`)))
+	if err != nil || string(data) != `{"openid":"x"}` {
+		t.Errorf("unexpected jsonp body %s %v", data, err)
+	}
+
+	_, err = handler(newResponse(http.StatusBadRequest, map[string]string{"Content-Type": "application/json"}, []byte(`{"error":"invalid_grant"}`)))
+	var oe *errorx.OauthError
+	if !errors.As(err, &oe) || oe.Code != "invalid_grant" || oe.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected invalid_grant, got %v", err)
+	}
+
+	_, err = handler(newResponse(http.StatusOK, nil, []byte(strings.Repeat("a", 2<<10))))
+	if err != errorx.ResponseTooLargeError {
+		t.Errorf("expected ResponseTooLargeError, got %v", err)
+	}
+
+	var mapped = errors.New("unauthorized")
+	_, err = ChainResponseHandler(CheckStatus(func(statusCode int, body []byte) error { return mapped }))(newResponse(http.StatusUnauthorized, nil, nil))
+	if err != mapped {
+		t.Errorf("expected mapped error, got %v", err)
+	}
+}
@@ -1,8 +1,8 @@
 package types
 
 import (
+	"github.com/demo007x/oauth2-client/utils"
 	"io"
-	"log"
 	"net/http"
 )
 
@@ -19,7 +19,7 @@ type OauthResponseHandler func(resp *http.Response) ([]byte, error)
 func DefaultOauthResponseHandler(resp *http.Response) ([]byte, error) {
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
-			log.Println(err)
+			utils.GetLogger().Error("close response body failed", "error", err)
 		}
 	}()
 	return io.ReadAll(resp.Body)
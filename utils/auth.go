@@ -15,11 +15,5 @@ func GenerateBaseAuthorization(name, password string) string {
 
 // GenerateBearAuthorization Generate Bearer Authorization
 func GenerateBearAuthorization(accessToken string) string {
-	buf := bytes.NewBuffer([]byte("Bearer "))
-
-	if _, err := buf.Write([]byte(accessToken)); err != nil {
-		fmt.Println(err.Error())
-	}
-
-	return buf.String()
+	return "Bearer " + accessToken
 }
@@ -1,34 +1,161 @@
 package utils
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptrace"
 	nurl "net/url"
+	"sync/atomic"
+	"time"
 )
 
-func DoRequest(url, method string, header map[string]string) (*http.Response, error) {
-	req, err := buildRequest(context.Background(), method, url, header)
+type (
+	// RequestOption config the outgoing http request
+	RequestOption func(r *request)
+
+	request struct {
+		ctx          context.Context
+		body         io.Reader
+		client       *http.Client
+		interceptors []RequestInterceptor
+		trace        *httptrace.ClientTrace
+	}
+)
+
+var defaultClient atomic.Value
+
+func init() {
+	defaultClient.Store(http.DefaultClient)
+}
+
+// SetDefaultHTTPClient set the http client of requests without RequestWithHTTPClient, nil restore http.DefaultClient.
+// eg: route every call through Recorder
+func SetDefaultHTTPClient(client *http.Client) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	defaultClient.Store(client)
+}
+
+// RequestWithBody set request body. eg: form encoded params
+func RequestWithBody(body io.Reader) RequestOption {
+	return func(r *request) {
+		r.body = body
+	}
+}
+
+// RequestWithBodyBytes set request body with a fresh reader per request, so the request can be resent. eg: retry
+func RequestWithBodyBytes(data []byte) RequestOption {
+	return func(r *request) {
+		r.body = bytes.NewReader(data)
+	}
+}
+
+// RequestWithHTTPClient send request with custom http client. eg: mutual tls
+func RequestWithHTTPClient(client *http.Client) RequestOption {
+	return func(r *request) {
+		r.client = client
+	}
+}
+
+// RequestWithContext send request with context. eg: cancel or deadline of the caller
+func RequestWithContext(ctx context.Context) RequestOption {
+	return func(r *request) {
+		if ctx != nil {
+			r.ctx = ctx
+		}
+	}
+}
+
+// RequestWithTrace collect connection timings of the request
+func RequestWithTrace(trace *httptrace.ClientTrace) RequestOption {
+	return func(r *request) {
+		r.trace = trace
+	}
+}
+
+func DoRequest(url, method string, header map[string]string, opts ...RequestOption) (*http.Response, error) {
+	var r = &request{ctx: context.Background(), client: defaultClient.Load().(*http.Client)}
+	for _, opt := range opts {
+		opt(r)
+	}
+	var ctx = r.ctx
+	if r.trace != nil {
+		ctx = httptrace.WithClientTrace(ctx, r.trace)
+	}
+	var debug = debugEnabled()
+	var requestBody []byte
+	if debug && r.body != nil {
+		data, err := io.ReadAll(r.body)
+		if err != nil {
+			return nil, err
+		}
+		requestBody, r.body = data, bytes.NewReader(data)
+	}
+	req, err := buildRequest(ctx, method, url, header, r.body)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := http.DefaultClient.Do(req)
+	for _, interceptor := range r.interceptors {
+		if err := interceptor(req); err != nil {
+			return nil, err
+		}
+	}
+	if debug {
+		GetLogger().Debug("oauth request", "method", req.Method, "url", RedactURL(req.URL.String()),
+			"header", RedactHeader(req.Header), "body", string(RedactBody(requestBody)))
+	}
+	var start = time.Now()
+	resp, err := r.client.Do(req)
 	if err != nil {
+		GetLogger().Error("oauth request failed", "method", req.Method, "url", RedactURL(req.URL.String()), "error", RedactError(err))
 		return nil, err
 	}
+	if debug {
+		if err := dumpResponse(resp, time.Since(start)); err != nil {
+			return nil, err
+		}
+	}
 
 	return resp, nil
 }
 
+// maxDumpSize largest response body read for the debug log, the rest stream to the handler
+const maxDumpSize = 64 << 10
+
+// dumpResponse log the response and restore its body for the handler.
+// Only the first maxDumpSize bytes are buffered, so types.MaxBodySize still applies
+func dumpResponse(resp *http.Response, elapsed time.Duration) error {
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDumpSize+1))
+	if err != nil {
+		resp.Body.Close()
+		return err
+	}
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), resp.Body), resp.Body}
+	// a truncated body can not be parsed for redaction, log its size only
+	var body = fmt.Sprintf("[more than %d bytes]", maxDumpSize)
+	if len(data) <= maxDumpSize {
+		body = string(RedactBody(data))
+	}
+	GetLogger().Debug("oauth response", "method", resp.Request.Method, "url", RedactURL(resp.Request.URL.String()),
+		"status", resp.StatusCode, "elapsed", elapsed, "header", RedactHeader(resp.Header), "body", body)
+	return nil
+}
+
 // buildRequest build http request params
-func buildRequest(ctx context.Context, method, url string, header map[string]string) (*http.Request, error) {
+func buildRequest(ctx context.Context, method, url string, header map[string]string, body io.Reader) (*http.Request, error) {
 	u, err := nurl.Parse(url)
 	if err != nil {
 		return nil, err
 	}
 
-	var reader io.Reader
-	req, err := http.NewRequestWithContext(ctx, method, u.String(), reader)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,22 @@
+package utils
+
+import "net/http"
+
+// RequestInterceptor inspect or modify the outgoing request before it is sent.
+// eg: User-Agent, correlation id, request signing, audit. Returning error aborts the request
+type RequestInterceptor func(req *http.Request) error
+
+// RequestWithInterceptors run interceptors in order on the built request
+func RequestWithInterceptors(interceptors ...RequestInterceptor) RequestOption {
+	return func(r *request) {
+		r.interceptors = append(r.interceptors, interceptors...)
+	}
+}
+
+// SetHeader interceptor set a request header. eg: SetHeader("User-Agent", "app/1.0")
+func SetHeader(key, value string) RequestInterceptor {
+	return func(req *http.Request) error {
+		req.Header.Set(key, value)
+		return nil
+	}
+}
@@ -0,0 +1,15 @@
+package utils
+
+import "bytes"
+
+// UnwrapJSONP strip jsonp callback wrapper. eg: callback( {"openid":"x"} );
+// data without wrapper is returned unchanged
+func UnwrapJSONP(data []byte) []byte {
+	var trimmed = bytes.TrimSpace(data)
+	var start = bytes.IndexByte(trimmed, '(')
+	var end = bytes.LastIndexByte(trimmed, ')')
+	if start <= 0 || end < start || bytes.IndexAny(trimmed[:start], "{[\"=&") >= 0 {
+		return data
+	}
+	return bytes.TrimSpace(trimmed[start+1 : end])
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// Logger structured logger, keyvals are alternating keys and values.
+	// Adapt it to the logging library of the application. eg: slog, zap, logrus
+	Logger interface {
+		Debug(msg string, keyvals ...interface{})
+		Error(msg string, keyvals ...interface{})
+	}
+
+	// logging current logger and debug mode
+	logging struct {
+		logger Logger
+		debug  bool
+	}
+
+	nopLogger struct{}
+
+	// stdLogger logfmt lines. eg: time=2023-04-08T10:00:00Z level=debug msg="oauth request" method=POST
+	stdLogger struct {
+		mu sync.Mutex
+		w  io.Writer
+	}
+)
+
+var current atomic.Value
+
+func init() {
+	current.Store(&logging{logger: nopLogger{}})
+}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Error(string, ...interface{}) {}
+
+// NewStdLogger return Logger writing logfmt lines to w
+func NewStdLogger(w io.Writer) Logger {
+	return &stdLogger{w: w}
+}
+
+func (l *stdLogger) Debug(msg string, keyvals ...interface{}) {
+	l.log("debug", msg, keyvals)
+}
+
+func (l *stdLogger) Error(msg string, keyvals ...interface{}) {
+	l.log("error", msg, keyvals)
+}
+
+func (l *stdLogger) log(level, msg string, keyvals []interface{}) {
+	var b strings.Builder
+	b.WriteString("time=" + time.Now().UTC().Format(time.RFC3339))
+	b.WriteString(" level=" + level)
+	b.WriteString(" msg=" + logfmtValue(msg))
+	for i := 0; i < len(keyvals); i += 2 {
+		var val interface{} = "(missing)"
+		if i+1 < len(keyvals) {
+			val = keyvals[i+1]
+		}
+		b.WriteString(" " + fmt.Sprint(keyvals[i]) + "=" + logfmtValue(fmt.Sprint(val)))
+	}
+	b.WriteString("\n")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	io.WriteString(l.w, b.String())
+}
+
+func logfmtValue(s string) string {
+	if s == "" || strings.ContainsAny(s, " =\"\t\r\n") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// SetLogger set the logger of every oauth call, nil disable logging.
+// Debug mode dump each request and response with secrets redacted
+func SetLogger(logger Logger, debug bool) {
+	if logger == nil {
+		logger, debug = nopLogger{}, false
+	}
+	current.Store(&logging{logger: logger, debug: debug})
+}
+
+// GetLogger return the current logger, it never returns nil
+func GetLogger() Logger {
+	return current.Load().(*logging).logger
+}
+
+// debugEnabled report whether requests and responses are dumped
+func debugEnabled() bool {
+	return current.Load().(*logging).debug
+}
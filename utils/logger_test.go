@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDebugLogging(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "grant_type=authorization_code&code=secret-code" {
+			t.Errorf("request body not restored: %s", body)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"secret-at","refresh_token":"secret-rt","expires_in":7200}`))
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	SetLogger(NewStdLogger(&buf), true)
+	defer SetLogger(nil, false)
+
+	header := map[string]string{"Authorization": GenerateBaseAuthorization("id", "secret-pw")}
+	resp, err := DoRequest(ts.URL+"?client_secret=secret-cs&state=xyz", http.MethodPost, header,
+		RequestWithBodyBytes([]byte("grant_type=authorization_code&code=secret-code")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "secret-at") {
+		t.Errorf("response body not restored: %s", body)
+	}
+
+	var logs = buf.String()
+	if strings.Count(logs, "level=debug") != 2 || !strings.Contains(logs, "state=xyz") || !strings.Contains(logs, "expires_in") {
+		t.Errorf("unexpected logs %s", logs)
+	}
+	for _, secret := range []string{"secret-code", "secret-at", "secret-rt", "secret-cs", GenerateBaseAuthorization("id", "secret-pw")} {
+		if strings.Contains(logs, secret) {
+			t.Errorf("secret %s leaked in logs %s", secret, logs)
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+	if got := string(RedactBody([]byte(`{"code":0,"data":{"id_token":"x","name":"n"}}`))); got != `{"code":0,"data":{"id_token":"[REDACTED]","name":"n"}}` {
+		t.Errorf("unexpected json %s", got)
+	}
+	if got := string(RedactBody([]byte("client_assertion=x&scope=openid"))); got != "client_assertion=%5BREDACTED%5D&scope=openid" {
+		t.Errorf("unexpected form %s", got)
+	}
+	if got := string(RedactBody([]byte("eyJhbGciOi.eyJzdWIi.sig"))); got != "[23 bytes]" {
+		t.Errorf("unexpected opaque body %s", got)
+	}
+	if got := RedactURL("https://u:p@example.com/cb?code=x&state=s"); got != "https://u@example.com/cb?code=%5BREDACTED%5D&state=s" {
+		t.Errorf("unexpected url %s", got)
+	}
+	redacted := RedactHeader(http.Header{"Proxy-Authorization": {"Basic x"}, "X-Api-Key": {"k"}, "Api-Key": {"k"}, "Accept": {"application/json"}})
+	for _, key := range []string{"Proxy-Authorization", "X-Api-Key", "Api-Key"} {
+		if redacted.Get(key) != Redacted {
+			t.Errorf("%s not redacted: %v", key, redacted)
+		}
+	}
+	if redacted.Get("Accept") != "application/json" {
+		t.Errorf("unexpected header %v", redacted)
+	}
+
+	redacted = RedactHeader(http.Header{
+		"Location":         {"https://app.example.com/cb#access_token=at&state=s"},
+		"Content-Location": {"https://app.example.com/cb?code=x"},
+	})
+	if got := redacted.Get("Location"); got != "https://app.example.com/cb#access_token=%5BREDACTED%5D&state=s" {
+		t.Errorf("unexpected location %s", got)
+	}
+	if got := redacted.Get("Content-Location"); got != "https://app.example.com/cb?code=%5BREDACTED%5D" {
+		t.Errorf("unexpected content location %s", got)
+	}
+}
+
+func TestDebugLoggingLargeBody(t *testing.T) {
+	var large = strings.Repeat("a", maxDumpSize*2)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"access_token":"secret-at","padding":"` + large + `"}`))
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	SetLogger(NewStdLogger(&buf), true)
+	defer SetLogger(nil, false)
+
+	resp, err := DoRequest(ts.URL, http.MethodGet, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if len(body) != len(large)+len(`{"access_token":"secret-at","padding":""}`) {
+		t.Errorf("response body not fully restored, got %d bytes", len(body))
+	}
+	if logs := buf.String(); strings.Contains(logs, "secret-at") || !strings.Contains(logs, "more than") {
+		t.Errorf("large body should be logged by size only: %.200s", logs)
+	}
+}
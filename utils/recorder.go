@@ -0,0 +1,274 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/demo007x/oauth2-client/errorx"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// Archive HAR like log of the recorded exchanges, secrets are redacted
+	Archive struct {
+		Log ArchiveLog `json:"log"`
+	}
+
+	ArchiveLog struct {
+		Version string         `json:"version"`
+		Creator ArchiveCreator `json:"creator"`
+		Entries []ArchiveEntry `json:"entries"`
+	}
+
+	ArchiveCreator struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+
+	// ArchiveEntry one request and its response. Time in milliseconds
+	ArchiveEntry struct {
+		StartedDateTime time.Time       `json:"startedDateTime"`
+		Time            float64         `json:"time"`
+		Request         ArchiveRequest  `json:"request"`
+		Response        ArchiveResponse `json:"response"`
+		// Comment transport error of the request, the response is empty
+		Comment string `json:"comment,omitempty"`
+	}
+
+	ArchiveRequest struct {
+		Method   string             `json:"method"`
+		URL      string             `json:"url"`
+		Headers  []ArchiveNameValue `json:"headers"`
+		PostData *ArchivePostData   `json:"postData,omitempty"`
+	}
+
+	ArchiveResponse struct {
+		Status     int                `json:"status"`
+		StatusText string             `json:"statusText"`
+		Headers    []ArchiveNameValue `json:"headers"`
+		Content    ArchiveContent     `json:"content"`
+		// RedirectURL redacted Location header
+		RedirectURL string `json:"redirectURL"`
+	}
+
+	ArchiveNameValue struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+
+	ArchivePostData struct {
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text"`
+	}
+
+	ArchiveContent struct {
+		Size     int    `json:"size"`
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text"`
+	}
+
+	// Recorder http.RoundTripper record every exchange with secrets redacted.
+	// Install it with SetDefaultHTTPClient or as transport of the mutual tls client
+	Recorder struct {
+		// Transport send the requests, http.DefaultTransport when nil
+		Transport http.RoundTripper
+
+		// internal field
+		mu      sync.Mutex
+		entries []ArchiveEntry
+	}
+
+	// Replayer http.RoundTripper serve the recorded exchanges back offline.
+	// Requests match by method and redacted url, repeated requests get the recorded responses in order
+	Replayer struct {
+		mu      sync.Mutex
+		entries []ArchiveEntry
+		served  []bool
+	}
+)
+
+// NewRecorder return Recorder sending requests with transport
+func NewRecorder(transport http.RoundTripper) *Recorder {
+	return &Recorder{Transport: transport}
+}
+
+// RoundTrip implement http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var transport = r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	var entry = ArchiveEntry{
+		StartedDateTime: time.Now(),
+		Request: ArchiveRequest{
+			Method:  req.Method,
+			URL:     RedactURL(req.URL.String()),
+			Headers: archiveHeaders(req.Header),
+		},
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(data))
+		entry.Request.PostData = &ArchivePostData{MimeType: req.Header.Get("Content-Type"), Text: string(RedactBody(data))}
+	}
+
+	resp, err := transport.RoundTrip(req)
+	entry.Time = float64(time.Since(entry.StartedDateTime)) / float64(time.Millisecond)
+	if err != nil {
+		entry.Comment = RedactError(err).Error()
+		r.append(entry)
+		return nil, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	entry.Response = ArchiveResponse{
+		Status:     resp.StatusCode,
+		StatusText: http.StatusText(resp.StatusCode),
+		Headers:    archiveHeaders(resp.Header),
+		Content: ArchiveContent{
+			Size:     len(data),
+			MimeType: resp.Header.Get("Content-Type"),
+			Text:     string(RedactBody(data)),
+		},
+	}
+	if location := resp.Header.Get("Location"); location != "" {
+		entry.Response.RedirectURL = RedactURL(location)
+	}
+	r.append(entry)
+	return resp, nil
+}
+
+func (r *Recorder) append(entry ArchiveEntry) {
+	r.mu.Lock()
+	r.entries = append(r.entries, entry)
+	r.mu.Unlock()
+}
+
+// Archive return the exchanges recorded so far
+func (r *Recorder) Archive() *Archive {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &Archive{Log: ArchiveLog{
+		Version: "1.2",
+		Creator: ArchiveCreator{Name: "oauth2-client", Version: "1.0"},
+		Entries: append([]ArchiveEntry{}, r.entries...),
+	}}
+}
+
+// WriteTo write the recorded exchanges as json
+func (r *Recorder) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.MarshalIndent(r.Archive(), "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// Save write the recorded exchanges to the file. eg: oauth.har
+func (r *Recorder) Save(filename string) error {
+	var buf bytes.Buffer
+	if _, err := r.WriteTo(&buf); err != nil {
+		return err
+	}
+	return os.WriteFile(filename, buf.Bytes(), 0600)
+}
+
+// NewReplayer return Replayer serving the archive
+func NewReplayer(archive *Archive) *Replayer {
+	return &Replayer{entries: archive.Log.Entries, served: make([]bool, len(archive.Log.Entries))}
+}
+
+// NewReplayerFromFile load the archive saved by Recorder
+func NewReplayerFromFile(filename string) (*Replayer, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var archive Archive
+	if err := json.Unmarshal(data, &archive); err != nil {
+		return nil, err
+	}
+	return NewReplayer(&archive), nil
+}
+
+// RoundTrip implement http.RoundTripper. The last matching exchange is served again when all are used
+func (p *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	var url = RedactURL(req.URL.String())
+
+	p.mu.Lock()
+	var found = -1
+	for i, entry := range p.entries {
+		if entry.Request.Method != req.Method || entry.Request.URL != url {
+			continue
+		}
+		found = i
+		if !p.served[i] {
+			break
+		}
+	}
+	if found >= 0 {
+		p.served[found] = true
+	}
+	p.mu.Unlock()
+
+	if found < 0 {
+		return nil, fmt.Errorf("%w: %s %s", errorx.ExchangeNotRecordedError, req.Method, url)
+	}
+	var entry = p.entries[found]
+	if entry.Comment != "" && entry.Response.Status == 0 {
+		return nil, fmt.Errorf("recorded transport error: %s", entry.Comment)
+	}
+	var resp = &http.Response{
+		Status:        fmt.Sprintf("%d %s", entry.Response.Status, entry.Response.StatusText),
+		StatusCode:    entry.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{},
+		Body:          io.NopCloser(strings.NewReader(entry.Response.Content.Text)),
+		ContentLength: int64(len(entry.Response.Content.Text)),
+		Request:       req,
+	}
+	for _, h := range entry.Response.Headers {
+		resp.Header.Add(h.Name, h.Value)
+	}
+	// the recorded length and encoding do not match the redacted body
+	resp.Header.Del("Content-Length")
+	resp.Header.Del("Content-Encoding")
+	return resp, nil
+}
+
+func archiveHeaders(header http.Header) []ArchiveNameValue {
+	var redacted = RedactHeader(header)
+	var names = make([]string, 0, len(redacted))
+	for name := range redacted {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var headers = []ArchiveNameValue{}
+	for _, name := range names {
+		for _, value := range redacted[name] {
+			headers = append(headers, ArchiveNameValue{Name: name, Value: value})
+		}
+	}
+	return headers
+}
@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"errors"
+	"github.com/demo007x/oauth2-client/errorx"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecorderReplayer(t *testing.T) {
+	var calls int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/userinfo" {
+			w.Write([]byte(`{"sub":"alice"}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid_grant","refresh_token":"secret-rt"}`))
+	}))
+	defer ts.Close()
+
+	var recorder = NewRecorder(nil)
+	SetDefaultHTTPClient(&http.Client{Transport: recorder})
+	header := map[string]string{"Authorization": GenerateBearAuthorization("secret-at"), "Content-Type": "application/x-www-form-urlencoded"}
+	for _, path := range []string{"/token?code=secret-code", "/userinfo"} {
+		resp, err := DoRequest(ts.URL+path, http.MethodPost, header, RequestWithBodyBytes([]byte("client_secret=secret-cs&scope=openid")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if len(body) == 0 {
+			t.Errorf("response body not restored for %s", path)
+		}
+	}
+
+	var filename = filepath.Join(t.TempDir(), "oauth.har")
+	if err := recorder.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filename)
+	for _, secret := range []string{"secret-code", "secret-at", "secret-rt", "secret-cs"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("secret %s leaked in archive %s", secret, data)
+		}
+	}
+
+	replayer, err := NewReplayerFromFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetDefaultHTTPClient(&http.Client{Transport: replayer})
+	defer SetDefaultHTTPClient(nil)
+
+	// the code differ from the recorded one, urls match after redaction
+	resp, err := DoRequest(ts.URL+"/token?code=other-code", http.MethodPost, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusBadRequest || !strings.Contains(string(body), "invalid_grant") || resp.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected replayed response %d %s", resp.StatusCode, body)
+	}
+	if _, err := DoRequest(ts.URL+"/unknown", http.MethodGet, nil); !errors.Is(err, errorx.ExchangeNotRecordedError) {
+		t.Errorf("expected ExchangeNotRecordedError, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected replay offline, server got %d calls", calls)
+	}
+}
+
+func TestRecorderRedirect(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "https://app.example.com/cb?code=secret-code#access_token=secret-at&state=s")
+		w.WriteHeader(http.StatusFound)
+	}))
+	defer ts.Close()
+
+	var recorder = NewRecorder(nil)
+	client := &http.Client{Transport: recorder, CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := DoRequest(ts.URL+"/authorize", http.MethodGet, nil, RequestWithHTTPClient(client))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	var filename = filepath.Join(t.TempDir(), "oauth.har")
+	if err := recorder.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filename)
+	for _, secret := range []string{"secret-code", "secret-at"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("secret %s leaked in archive %s", secret, data)
+		}
+	}
+	if !strings.Contains(string(data), `"redirectURL": "https://app.example.com/cb?code=%5BREDACTED%5D#access_token=%5BREDACTED%5D\u0026state=s"`) {
+		t.Errorf("redirect url not recorded %s", data)
+	}
+}
@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	nurl "net/url"
+	"strings"
+)
+
+// Redacted replacement of secret values
+const Redacted = "[REDACTED]"
+
+// secretKeys params always redacted, compared without case, '_' and '-'
+var secretKeys = map[string]bool{
+	"code":               true,
+	"authcode":           true,
+	"codeverifier":       true,
+	"devicecode":         true,
+	"usercode":           true,
+	"clientassertion":    true,
+	"assertion":          true,
+	"password":           true,
+	"authorization":      true,
+	"proxyauthorization": true,
+	"apikey":             true,
+	"xapikey":            true,
+	"cookie":             true,
+	"setcookie":          true,
+	"dpop":               true,
+	"privatekey":         true,
+}
+
+// IsSecretKey report whether the param or header carries a secret.
+// eg: client_secret, code, code_verifier, access_token, refreshToken, id_token, client_assertion
+func IsSecretKey(key string) bool {
+	var k = strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(key))
+	return secretKeys[k] || strings.HasSuffix(k, "token") || strings.HasSuffix(k, "secret") || strings.HasSuffix(k, "ticket")
+}
+
+// urlHeaders headers carrying an url, the redirect to the client callback has the code or the implicit tokens
+var urlHeaders = map[string]bool{"Location": true, "Content-Location": true}
+
+// RedactHeader copy header with secret values replaced. eg: Authorization, DPoP, x-acs-dingtalk-access-token
+func RedactHeader(header http.Header) http.Header {
+	var redacted = make(http.Header, len(header))
+	for key, values := range header {
+		if IsSecretKey(key) {
+			redacted[key] = []string{Redacted}
+			continue
+		}
+		redacted[key] = append([]string(nil), values...)
+		if urlHeaders[http.CanonicalHeaderKey(key)] {
+			for i, value := range redacted[key] {
+				redacted[key][i] = RedactURL(value)
+			}
+		}
+	}
+	return redacted
+}
+
+// RedactURL replace secret params of the url query and fragment. eg: implicit flow tokens
+func RedactURL(url string) string {
+	u, err := nurl.Parse(url)
+	if err != nil {
+		return Redacted
+	}
+	if u.User != nil {
+		u.User = nurl.User(u.User.Username())
+	}
+	if u.RawQuery != "" {
+		u.RawQuery = redactValues(u.Query()).Encode()
+	}
+	if values, err := nurl.ParseQuery(u.Fragment); err == nil && strings.Contains(u.Fragment, "=") {
+		u.RawFragment = redactValues(values).Encode()
+		u.Fragment, _ = nurl.PathUnescape(u.RawFragment)
+	}
+	return u.String()
+}
+
+// RedactBody replace secret string values of json, jsonp or form body.
+// Other bodies are replaced by their size, they may carry secrets in unknown places
+func RedactBody(body []byte) []byte {
+	var trimmed = bytes.TrimSpace(UnwrapJSONP(body))
+	if len(trimmed) == 0 {
+		return body
+	}
+	var v interface{}
+	var decoder = json.NewDecoder(bytes.NewReader(trimmed))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err == nil {
+		data, err := json.Marshal(redactJSON(v))
+		if err == nil {
+			return data
+		}
+	}
+	if values, err := nurl.ParseQuery(string(trimmed)); err == nil && bytes.IndexByte(trimmed, '=') > 0 {
+		return []byte(redactValues(values).Encode())
+	}
+	return []byte(fmt.Sprintf("[%d bytes]", len(body)))
+}
+
+// RedactError replace the secret query params of url errors
+func RedactError(err error) error {
+	if ue, ok := err.(*nurl.Error); ok {
+		return &nurl.Error{Op: ue.Op, URL: RedactURL(ue.URL), Err: ue.Err}
+	}
+	return err
+}
+
+func redactValues(values nurl.Values) nurl.Values {
+	for key := range values {
+		if IsSecretKey(key) {
+			values[key] = []string{Redacted}
+		}
+	}
+	return values
+}
+
+func redactJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			// numbers are never secrets. eg: {"code":0,"msg":"ok"}
+			if _, ok := val.(string); ok && IsSecretKey(key) {
+				v[key] = Redacted
+				continue
+			}
+			v[key] = redactJSON(val)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = redactJSON(v[i])
+		}
+	}
+	return v
+}